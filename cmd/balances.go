@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/service"
+)
+
+const balancesUsage = "usage: balances rebuild [group_id] | check [group_id]"
+
+// runBalances implements the "balances" subcommand:
+//
+//	balances rebuild [group_id]  recompute the balances of one group, or of every group, from the ledger
+//	balances check [group_id]    report balances that differ from the ledger, exiting with status 1 if any do
+func runBalances(balanceService *service.BalanceService, args []string) {
+	if len(args) == 0 || len(args) > 2 {
+		log.Fatal(balancesUsage)
+	}
+
+	var groupIDs []int
+	if len(args) == 2 {
+		groupID, err := strconv.Atoi(args[1])
+		if err != nil || groupID <= 0 {
+			log.Fatal(balancesUsage)
+		}
+		groupIDs = append(groupIDs, groupID)
+	}
+
+	switch args[0] {
+	case "rebuild":
+		rebuilt, err := balanceService.RebuildBalances(groupIDs...)
+		if err != nil {
+			log.Fatalf("Error rebuilding balances: %v", err)
+		}
+		log.Printf("Rebuilt balances of %d groups", rebuilt)
+
+	case "check":
+		drifts, err := balanceService.CheckBalances(groupIDs...)
+		if err != nil {
+			log.Fatalf("Error checking balances: %v", err)
+		}
+		for _, drift := range drifts {
+			fmt.Fprintf(os.Stdout, "group %d: user %d owes user %d %s, ledger says %s\n",
+				drift.GroupID, drift.FromUserID, drift.ToUserID, drift.Stored, drift.Expected)
+		}
+		if len(drifts) > 0 {
+			log.Fatalf("Found %d drifted balances; run \"balances rebuild\" to fix them", len(drifts))
+		}
+		log.Println("Balances match the ledger")
+
+	default:
+		log.Fatal(balancesUsage)
+	}
+}
@@ -1,15 +1,19 @@
 package main
 
 import (
+	"crypto/rand"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/shreyansh/expense-go-collab-backend/internal/blobstore"
 	"github.com/shreyansh/expense-go-collab-backend/internal/config"
 	"github.com/shreyansh/expense-go-collab-backend/internal/handler"
-	"github.com/shreyansh/expense-go-collab-backend/internal/repositorypg"
+	"github.com/shreyansh/expense-go-collab-backend/internal/mailer"
 	"github.com/shreyansh/expense-go-collab-backend/internal/service"
 )
 
@@ -17,32 +21,92 @@ func main() {
 	// Load environment variables
 	_ = godotenv.Load()
 
-	// Initialize database
-	db := config.InitDB()
-	defer db.Close()
+	// STORAGE=memory runs without a database, keeping all data in memory
+	var repos *repositories
+	if os.Getenv("STORAGE") == "memory" {
+		log.Println("Using in-memory storage, data will be lost on exit")
+		repos = memoryRepositories()
+	} else {
+		// Initialize database
+		db := config.InitDB()
+		defer db.Close()
 
-	// Initialize repositories
-	userRepo := repositorypg.NewUserRepositoryPG(db)
-	groupRepo := repositorypg.NewGroupRepositoryPG(db)
-	memberRepo := repositorypg.NewGroupMemberRepositoryPG(db)
-	expenseRepo := repositorypg.NewExpenseRepositoryPG(db)
-	splitRepo := repositorypg.NewExpenseSplitRepositoryPG(db)
-	balanceRepo := repositorypg.NewBalanceRepositoryPG(db)
-	settlementRepo := repositorypg.NewSettlementRepositoryPG(db)
+		// "migrate up|down|status" manages the schema and exits
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			runMigrate(db, os.Args[2:])
+			return
+		}
+		migrateOnStart(db)
+
+		repos = postgresRepositories(db)
+	}
 
 	// Initialize services
-	userService := service.NewUserService(userRepo)
-	groupService := service.NewGroupService(userRepo, groupRepo, memberRepo, expenseRepo, splitRepo, balanceRepo)
-	expenseService := service.NewExpenseService(userRepo, expenseRepo, splitRepo, memberRepo)
-	balanceService := service.NewBalanceService(balanceRepo)
-	settlementService := service.NewSettlementService(settlementRepo, userRepo, balanceRepo)
+	userService := service.NewUserService(repos.users, repos.uow)
+	authService := service.NewAuthService(repos.users, repos.tokens, authSecret())
+	groupService := service.NewGroupService(repos.users, repos.groups, repos.members, repos.expenses, repos.splits, repos.balances, repos.uow)
+	expenseService := service.NewExpenseService(repos.users, repos.groups, repos.expenses, repos.splits, repos.members, repos.rates, repos.categories, repos.uow)
+	balanceService := service.NewBalanceService(repos.balances, repos.groups, repos.uow)
+	settlementService := service.NewSettlementService(repos.settlements, repos.users, repos.groups, repos.members, repos.balances, repos.rates, repos.uow)
+	settlePlanService := service.NewSettlePlanService(repos.balances, repos.groups)
+	exchangeRateService := service.NewExchangeRateService(repos.rates)
+	categoryService := service.NewCategoryService(repos.categories, repos.groups)
+	reportService := service.NewReportService(repos.reports, repos.groups, repos.users)
+	importService := service.NewImportService(repos.users, repos.groups, repos.members, repos.categories, repos.rates, repos.uow)
+	placeholderService := service.NewPlaceholderService(repos.users, repos.groups, repos.members, repos.uow)
+	invitationService := service.NewInvitationService(repos.invitations, repos.groups, repos.users, repos.members, newMailer(), repos.uow, appURL())
+	recurringService := service.NewRecurringExpenseService(repos.recurring, repos.groups, repos.members, repos.categories, expenseService, repos.uow)
+	blobs := newBlobStore()
+	attachmentService := service.NewAttachmentService(repos.attachments, repos.expenses, blobs, attachmentMaxSize())
+	commentService := service.NewCommentService(repos.comments, repos.expenses, repos.settlements)
+	activityService := service.NewActivityService(repos.activities)
+	exportService := service.NewExportService(repos.categories, repos.uow)
+	retentionService := service.NewRetentionService(repos.groups, repos.expenses, repos.settlements, repos.attachments, blobs, deletedRetention())
+	authzService := service.NewAuthorizationService(repos.members, repos.expenses, repos.splits, repos.settlements)
+
+	// "balances rebuild|check" maintains the materialized balances and exits
+	if len(os.Args) > 1 && os.Args[1] == "balances" {
+		runBalances(balanceService, os.Args[2:])
+		return
+	}
+
+	// Load exchange rates from a local file, if configured
+	if ratesFile := os.Getenv("FX_RATES_FILE"); ratesFile != "" {
+		count, err := exchangeRateService.LoadRatesFromFile(ratesFile)
+		if err != nil {
+			log.Printf("Error loading exchange rates: %v", err)
+		} else {
+			log.Printf("Loaded %d exchange rates from %s", count, ratesFile)
+		}
+	}
+
+	// Create the expenses of recurring expenses as they fall due
+	if interval := recurringInterval(); interval > 0 {
+		go recurringService.RunScheduler(interval)
+	}
+
+	// Purge soft-deleted rows once they are past the retention period
+	if interval := retentionInterval(); interval > 0 {
+		go retentionService.RunRetention(interval)
+	}
 
 	// Initialize handlers
-	userHandler := handler.NewUserHandler(userService)
-	groupHandler := handler.NewGroupHandler(groupService)
-	expenseHandler := handler.NewExpenseHandler(expenseService)
-	balanceHandler := handler.NewBalanceHandler(balanceService, userRepo)
-	settlementHandler := handler.NewSettlementHandler(settlementService)
+	userHandler := handler.NewUserHandler(userService, authzService)
+	authHandler := handler.NewAuthHandler(authService)
+	groupHandler := handler.NewGroupHandler(groupService, authzService)
+	expenseHandler := handler.NewExpenseHandler(expenseService, authzService)
+	balanceHandler := handler.NewBalanceHandler(balanceService, settlePlanService, repos.users, authzService)
+	settlementHandler := handler.NewSettlementHandler(settlementService, authzService)
+	categoryHandler := handler.NewCategoryHandler(categoryService, authzService)
+	reportHandler := handler.NewReportHandler(reportService, authzService)
+	exportHandler := handler.NewExportHandler(exportService, authzService)
+	importHandler := handler.NewImportHandler(importService, authzService)
+	placeholderHandler := handler.NewPlaceholderHandler(placeholderService, authzService)
+	invitationHandler := handler.NewInvitationHandler(invitationService, authzService)
+	recurringHandler := handler.NewRecurringExpenseHandler(recurringService, authzService)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService, authzService)
+	commentHandler := handler.NewCommentHandler(commentService, authzService)
+	activityHandler := handler.NewActivityHandler(activityService, authzService)
 
 	// Create router
 	router := gin.Default()
@@ -58,52 +122,116 @@ func main() {
 	// Metrics endpoint
 	router.GET("/metrics", gin.WrapF(promhttp.Handler().ServeHTTP))
 
-	// User routes
+	// Public auth routes
 	router.POST("/api/users/register", userHandler.Register)
-	router.GET("/api/users/login", userHandler.Login)
-	router.GET("/api/users", userHandler.GetAllUsers)
-	router.GET("/api/users/:id", userHandler.GetUser)
-	router.PUT("/api/users/:id", userHandler.UpdateUser)
-	router.DELETE("/api/users/:id", userHandler.DeleteUser)
+	router.POST("/api/auth/login", authHandler.Login)
+	router.POST("/api/auth/refresh", authHandler.Refresh)
+	router.POST("/api/invitations/:token/decline", invitationHandler.DeclineInvitation)
+
+	// Every other API route requires a valid access token
+	authed := router.Group("/", handler.AuthMiddleware(authService))
+	authed.POST("/api/auth/logout", authHandler.Logout)
+	authed.GET("/api/auth/me", authHandler.Me)
+
+	// User routes
+	authed.GET("/api/users", userHandler.GetAllUsers)
+	authed.GET("/api/users/:id", userHandler.GetUser)
+	authed.PUT("/api/users/:id", userHandler.UpdateUser)
+	authed.DELETE("/api/users/:id", userHandler.DeleteUser)
 
 	// Group routes
-	router.POST("/api/groups", groupHandler.CreateGroup)
-	router.GET("/api/groups", groupHandler.GetAllGroups) //////////TO REMOVE THIS LATER
-	router.GET("/api/groups/:id", groupHandler.GetGroup)
-	router.PUT("/api/groups/:id", groupHandler.UpdateGroup)
-	router.DELETE("/api/groups/:id", groupHandler.DeleteGroup)
-	router.GET("/api/groups/user/:user_id", groupHandler.GetUserGroups)
+	authed.POST("/api/groups", groupHandler.CreateGroup)
+	authed.GET("/api/groups", groupHandler.GetAllGroups)
+	authed.GET("/api/groups/:id", groupHandler.GetGroup)
+	authed.PUT("/api/groups/:id", groupHandler.UpdateGroup)
+	authed.DELETE("/api/groups/:id", groupHandler.DeleteGroup)
+	authed.POST("/api/groups/:id/restore", groupHandler.RestoreGroup)
+	authed.GET("/api/groups/user/:user_id", groupHandler.GetUserGroups)
+	authed.GET("/api/groups/:id/export.csv", exportHandler.ExportGroupLedgerCSV)
+	authed.POST("/api/groups/:id/import", importHandler.ImportExpenses)
+	authed.POST("/api/groups/:id/import/splitwise", importHandler.ImportSplitwise)
+	authed.GET("/api/groups/:id/activity", activityHandler.GetGroupActivity)
 
 	// Group member routes (use different path structure)
-	router.POST("/api/members", groupHandler.AddGroupMember)
-	router.DELETE("/api/members/:group_id/:user_id", groupHandler.RemoveGroupMember)
-	router.GET("/api/members/group/:group_id", groupHandler.GetGroupMembers)
+	authed.POST("/api/members", groupHandler.AddGroupMember)
+	authed.DELETE("/api/members/:group_id/:user_id", groupHandler.RemoveGroupMember)
+	authed.GET("/api/members/group/:group_id", groupHandler.GetGroupMembers)
+	authed.PUT("/api/members/:group_id/:user_id/role", groupHandler.UpdateGroupMemberRole)
+	authed.POST("/api/members/placeholder", placeholderHandler.AddPlaceholderMember)
+	authed.POST("/api/placeholders/:id/claim", placeholderHandler.ClaimPlaceholder)
+
+	// Invitation routes
+	authed.POST("/api/invitations", invitationHandler.CreateInvitation)
+	authed.GET("/api/invitations/group/:group_id", invitationHandler.GetGroupInvitations)
+	authed.DELETE("/api/invitations/:id", invitationHandler.RevokeInvitation)
+	authed.POST("/api/invitations/:token/accept", invitationHandler.AcceptInvitation)
 
 	// Expense routes
-	router.POST("/api/expenses", expenseHandler.CreateExpense)
-	router.GET("/api/expenses/:id", expenseHandler.GetExpense) ////////////ADDED THIS
-	router.GET("/api/expenses/group/:group_id", expenseHandler.GetGroupExpenses)
-	router.GET("/api/expenses/user/:user_id", expenseHandler.GetUserExpenses)
-	router.PUT("/api/expenses/:id", expenseHandler.UpdateExpense)
-	router.DELETE("/api/expenses/:id", expenseHandler.DeleteExpense)
+	authed.POST("/api/expenses", expenseHandler.CreateExpense)
+	authed.GET("/api/expenses/:id", expenseHandler.GetExpense)
+	authed.GET("/api/expenses/group/:group_id", expenseHandler.GetGroupExpenses)
+	authed.GET("/api/expenses/user/:user_id", expenseHandler.GetUserExpenses)
+	authed.PUT("/api/expenses/:id", expenseHandler.UpdateExpense)
+	authed.DELETE("/api/expenses/:id", expenseHandler.DeleteExpense)
+	authed.POST("/api/expenses/:id/restore", expenseHandler.RestoreExpense)
+
+	// Expense attachment routes
+	authed.POST("/api/expenses/:id/attachments", attachmentHandler.UploadAttachment)
+	authed.GET("/api/expenses/:id/attachments", attachmentHandler.GetExpenseAttachments)
+	authed.GET("/api/expenses/:id/attachments/:attachment_id", attachmentHandler.DownloadAttachment)
+	authed.GET("/api/expenses/:id/attachments/:attachment_id/thumbnail", attachmentHandler.DownloadThumbnail)
+	authed.DELETE("/api/expenses/:id/attachments/:attachment_id", attachmentHandler.DeleteAttachment)
+
+	// Comment routes
+	authed.POST("/api/expenses/:id/comments", commentHandler.CommentOnExpense)
+	authed.GET("/api/expenses/:id/comments", commentHandler.GetExpenseComments)
+	authed.POST("/api/settle/:id/comments", commentHandler.CommentOnSettlement)
+	authed.GET("/api/settle/:id/comments", commentHandler.GetSettlementComments)
+	authed.PUT("/api/comments/:id", commentHandler.UpdateComment)
+	authed.DELETE("/api/comments/:id", commentHandler.DeleteComment)
+
+	// Recurring expense routes
+	authed.POST("/api/recurring", recurringHandler.CreateRecurringExpense)
+	authed.GET("/api/recurring/group/:group_id", recurringHandler.GetGroupRecurringExpenses)
+	authed.GET("/api/recurring/:id", recurringHandler.GetRecurringExpense)
+	authed.PUT("/api/recurring/:id", recurringHandler.UpdateRecurringExpense)
+	authed.DELETE("/api/recurring/:id", recurringHandler.DeleteRecurringExpense)
+	authed.POST("/api/recurring/:id/pause", recurringHandler.PauseRecurringExpense)
+	authed.POST("/api/recurring/:id/resume", recurringHandler.ResumeRecurringExpense)
+	authed.POST("/api/recurring/:id/skip", recurringHandler.SkipRecurringExpense)
+
+	// Category routes
+	authed.GET("/api/categories/group/:group_id", categoryHandler.GetGroupCategories)
+	authed.POST("/api/categories", categoryHandler.CreateCategory)
+	authed.PUT("/api/categories/:id", categoryHandler.UpdateCategory)
+	authed.DELETE("/api/categories/:id", categoryHandler.DeleteCategory)
 
 	// Expense split routes
-	router.POST("/api/splits", expenseHandler.AddExpenseSplit)
-	router.GET("/api/splits/expense/:expense_id", expenseHandler.GetExpenseSplits)
-	router.GET("/api/splits/user/:user_id", expenseHandler.GetUserSplits)
-	router.PUT("/api/splits/:id", expenseHandler.UpdateExpenseSplit)
+	authed.POST("/api/splits", expenseHandler.AddExpenseSplit)
+	authed.GET("/api/splits/expense/:expense_id", expenseHandler.GetExpenseSplits)
+	authed.GET("/api/splits/user/:user_id", expenseHandler.GetUserSplits)
+	authed.PUT("/api/splits/:id", expenseHandler.UpdateExpenseSplit)
+	authed.PUT("/api/splits/expense/:expense_id", expenseHandler.ReplaceExpenseSplits)
 
 	// Balance routes
-	router.GET("/api/balance/user/:user_id/group/:group_id", balanceHandler.GetUserBalance)
-	router.GET("/api/balance/group/:group_id", balanceHandler.GetGroupBalances)
-	router.GET("/api/balance/:user_id/group/:group_id", balanceHandler.GetUserGroupBalances)
+	authed.GET("/api/balance/user/:user_id/group/:group_id", balanceHandler.GetUserBalance)
+	authed.GET("/api/balance/group/:group_id", balanceHandler.GetGroupBalances)
+	authed.GET("/api/balance/group/:group_id/settle-plan", balanceHandler.GetSettlePlan)
+	authed.GET("/api/balance/:user_id/group/:group_id", balanceHandler.GetUserGroupBalances)
 
 	// Settlement/Payment routes
-	router.POST("/api/settle", settlementHandler.CreateSettlement)
-	router.GET("/api/settle/:id", settlementHandler.GetSettlementByID)
-	router.GET("/api/settle/group/:group_id", settlementHandler.GetGroupSettlements)
-	router.GET("/api/settle/user/:user_id", settlementHandler.GetUserSettlements)
-	router.GET("/api/settle", settlementHandler.GetAllSettlements)
+	authed.POST("/api/settle", settlementHandler.CreateSettlement)
+	authed.POST("/api/settle/batch", settlementHandler.CreateSettlementBatch)
+	authed.GET("/api/settle/:id", settlementHandler.GetSettlementByID)
+	authed.DELETE("/api/settle/:id", settlementHandler.DeleteSettlement)
+	authed.POST("/api/settle/:id/restore", settlementHandler.RestoreSettlement)
+	authed.GET("/api/settle/group/:group_id", settlementHandler.GetGroupSettlements)
+	authed.GET("/api/settle/user/:user_id", settlementHandler.GetUserSettlements)
+	authed.GET("/api/settle", settlementHandler.GetAllSettlements)
+
+	// Report routes
+	authed.GET("/api/reports/group/:group_id", reportHandler.GetGroupReport)
+	authed.GET("/api/reports/user/:user_id", reportHandler.GetUserReport)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -115,3 +243,123 @@ func main() {
 		log.Fatalf("Error starting server: %v", err)
 	}
 }
+
+// authSecret returns the key access tokens are signed with. Without JWT_SECRET a
+// random key is used, which logs everyone out whenever the server restarts.
+func authSecret() []byte {
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+
+	log.Println("JWT_SECRET is not set, using a random secret")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("Error generating JWT secret: %v", err)
+	}
+	return secret
+}
+
+// newMailer sends emails through SMTP when MAILER=smtp. Otherwise emails are
+// only written to MAIL_LOG_FILE, or to the log when it is unset.
+func newMailer() mailer.Mailer {
+	if os.Getenv("MAILER") != "smtp" {
+		log.Println("Emails are logged, not sent (set MAILER=smtp to send them)")
+		return mailer.NewLogMailer(os.Getenv("MAIL_LOG_FILE"))
+	}
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	return mailer.NewSMTPMailer(os.Getenv("SMTP_HOST"), port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("MAIL_FROM"))
+}
+
+// recurringInterval is how often due recurring expenses are created, from
+// RECURRING_INTERVAL (a duration such as 30s or 5m, 1m by default). 0 turns the
+// scheduler off on this server.
+func recurringInterval() time.Duration {
+	value := os.Getenv("RECURRING_INTERVAL")
+	if value == "" {
+		return time.Minute
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid RECURRING_INTERVAL %q: %v", value, err)
+	}
+	return interval
+}
+
+// deletedRetention is how long deleted groups, expenses and settlements can
+// still be restored before they are purged, from DELETED_RETENTION (a duration
+// such as 168h, 720h by default)
+func deletedRetention() time.Duration {
+	value := os.Getenv("DELETED_RETENTION")
+	if value == "" {
+		return 30 * 24 * time.Hour
+	}
+
+	retention, err := time.ParseDuration(value)
+	if err != nil || retention < 0 {
+		log.Fatalf("Invalid DELETED_RETENTION %q", value)
+	}
+	return retention
+}
+
+// retentionInterval is how often deleted rows past their retention are purged,
+// from RETENTION_INTERVAL (1h by default). 0 turns the purge off on this
+// server.
+func retentionInterval() time.Duration {
+	value := os.Getenv("RETENTION_INTERVAL")
+	if value == "" {
+		return time.Hour
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid RETENTION_INTERVAL %q: %v", value, err)
+	}
+	return interval
+}
+
+// newBlobStore keeps attachments in an S3-compatible bucket when
+// BLOB_STORAGE=s3, and under ATTACHMENTS_DIR (./data/attachments by default)
+// otherwise
+func newBlobStore() blobstore.Store {
+	if os.Getenv("BLOB_STORAGE") != "s3" {
+		dir := os.Getenv("ATTACHMENTS_DIR")
+		if dir == "" {
+			dir = "./data/attachments"
+		}
+		return blobstore.NewLocalStore(dir)
+	}
+
+	region := os.Getenv("S3_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+	return blobstore.NewS3Store(os.Getenv("S3_ENDPOINT"), region, os.Getenv("S3_BUCKET"), os.Getenv("S3_ACCESS_KEY"), os.Getenv("S3_SECRET_KEY"))
+}
+
+// attachmentMaxSize is the largest attachment accepted, in bytes, from
+// ATTACHMENT_MAX_BYTES (10 MiB by default)
+func attachmentMaxSize() int64 {
+	value := os.Getenv("ATTACHMENT_MAX_BYTES")
+	if value == "" {
+		return 10 << 20
+	}
+
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size <= 0 {
+		log.Fatalf("Invalid ATTACHMENT_MAX_BYTES %q", value)
+	}
+	return size
+}
+
+// appURL is where links in emails point to
+func appURL() string {
+	if url := os.Getenv("APP_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:8080"
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/migrations"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status"
+
+// runMigrate implements the "migrate" subcommand:
+//
+//	migrate up            apply all pending migrations
+//	migrate down [steps]  revert the last migration, or the last steps migrations
+//	migrate status        list migrations and when they were applied
+func runMigrate(db *sql.DB, args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		log.Fatalf("Error loading migrations: %v", err)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			log.Fatalf("Error applying migrations: %v", err)
+		}
+		log.Printf("Applied %d migrations", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				log.Fatal(migrateUsage)
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		if err != nil {
+			log.Fatalf("Error reverting migrations: %v", err)
+		}
+		log.Printf("Reverted %d migrations", reverted)
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("Error reading migration status: %v", err)
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(os.Stdout, "%04d_%s\t%s\n", status.Version, status.Name, applied)
+		}
+
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
+
+// migrateOnStart applies pending migrations when the server starts, unless
+// AUTO_MIGRATE is set to false
+func migrateOnStart(db *sql.DB) {
+	if os.Getenv("AUTO_MIGRATE") == "false" {
+		return
+	}
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		log.Fatalf("Error loading migrations: %v", err)
+	}
+	applied, err := migrator.Up()
+	if err != nil {
+		log.Fatalf("Error applying migrations: %v", err)
+	}
+	log.Printf("Database schema up to date (%d migrations applied)", applied)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"database/sql"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repositorymem"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repositorypg"
+)
+
+// repositories is every repository the services are built from
+type repositories struct {
+	users       repository.UserRepository
+	groups      repository.GroupRepository
+	members     repository.GroupMemberRepository
+	expenses    repository.ExpenseRepository
+	splits      repository.ExpenseSplitRepository
+	balances    repository.BalanceRepository
+	settlements repository.SettlementRepository
+	categories  repository.CategoryRepository
+	reports     repository.ReportRepository
+	rates       repository.ExchangeRateRepository
+	tokens      repository.RefreshTokenRepository
+	invitations repository.InvitationRepository
+	recurring   repository.RecurringExpenseRepository
+	attachments repository.AttachmentRepository
+	comments    repository.CommentRepository
+	activities  repository.ActivityRepository
+	uow         repository.UnitOfWork
+}
+
+func postgresRepositories(db *sql.DB) *repositories {
+	return &repositories{
+		users:       repositorypg.NewUserRepositoryPG(db),
+		groups:      repositorypg.NewGroupRepositoryPG(db),
+		members:     repositorypg.NewGroupMemberRepositoryPG(db),
+		expenses:    repositorypg.NewExpenseRepositoryPG(db),
+		splits:      repositorypg.NewExpenseSplitRepositoryPG(db),
+		balances:    repositorypg.NewBalanceRepositoryPG(db),
+		settlements: repositorypg.NewSettlementRepositoryPG(db),
+		categories:  repositorypg.NewCategoryRepositoryPG(db),
+		reports:     repositorypg.NewReportRepositoryPG(db),
+		rates:       repositorypg.NewExchangeRateRepositoryPG(db),
+		tokens:      repositorypg.NewRefreshTokenRepositoryPG(db),
+		invitations: repositorypg.NewInvitationRepositoryPG(db),
+		recurring:   repositorypg.NewRecurringExpenseRepositoryPG(db),
+		attachments: repositorypg.NewAttachmentRepositoryPG(db),
+		comments:    repositorypg.NewCommentRepositoryPG(db),
+		activities:  repositorypg.NewActivityRepositoryPG(db),
+		uow:         repositorypg.NewUnitOfWorkPG(db),
+	}
+}
+
+// memoryRepositories keeps everything in memory; data is lost when the server stops
+func memoryRepositories() *repositories {
+	store := repositorymem.NewStore()
+	return &repositories{
+		users:       repositorymem.NewUserRepositoryMem(store),
+		groups:      repositorymem.NewGroupRepositoryMem(store),
+		members:     repositorymem.NewGroupMemberRepositoryMem(store),
+		expenses:    repositorymem.NewExpenseRepositoryMem(store),
+		splits:      repositorymem.NewExpenseSplitRepositoryMem(store),
+		balances:    repositorymem.NewBalanceRepositoryMem(store),
+		settlements: repositorymem.NewSettlementRepositoryMem(store),
+		categories:  repositorymem.NewCategoryRepositoryMem(store),
+		reports:     repositorymem.NewReportRepositoryMem(store),
+		rates:       repositorymem.NewExchangeRateRepositoryMem(store),
+		tokens:      repositorymem.NewRefreshTokenRepositoryMem(store),
+		invitations: repositorymem.NewInvitationRepositoryMem(store),
+		recurring:   repositorymem.NewRecurringExpenseRepositoryMem(store),
+		attachments: repositorymem.NewAttachmentRepositoryMem(store),
+		comments:    repositorymem.NewCommentRepositoryMem(store),
+		activities:  repositorymem.NewActivityRepositoryMem(store),
+		uow:         repositorymem.NewUnitOfWorkMem(store),
+	}
+}
@@ -0,0 +1,23 @@
+// Package blobstore keeps file contents, like expense receipts, outside the
+// database. The local store writes them to a directory; the S3 store to a
+// bucket of any S3-compatible service, such as AWS S3 or MinIO.
+package blobstore
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when no blob is stored under a key
+var ErrNotFound = errors.New("blob not found")
+
+// Store saves blobs under keys made of path segments separated by slashes,
+// such as "expenses/12/receipt"
+type Store interface {
+	Put(key string, content []byte, contentType string) error
+	// Get opens the blob stored under key; the caller closes it
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under key. Deleting a missing blob is not
+	// an error.
+	Delete(key string) error
+}
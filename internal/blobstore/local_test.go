@@ -0,0 +1,76 @@
+package blobstore
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStoreKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "nested key", key: "expenses/12/receipt"},
+		{name: "dots in a name", key: "expenses/12/..receipt.."},
+		{name: "parent", key: "../receipt", wantErr: true},
+		{name: "parent inside", key: "expenses/../../receipt", wantErr: true},
+		{name: "parent at the end", key: "expenses/..", wantErr: true},
+		{name: "current directory", key: "expenses/./receipt", wantErr: true},
+		{name: "absolute", key: "/etc/receipt", wantErr: true},
+		{name: "empty segment", key: "expenses//receipt", wantErr: true},
+		{name: "empty", key: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The store lives one level down, so a key escaping it would land
+			// in root
+			root := t.TempDir()
+			store := NewLocalStore(filepath.Join(root, "blobs"))
+
+			err := store.Put(tt.key, []byte("content"), "text/plain")
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Put succeeded, want an error")
+				}
+				if _, err := store.Get(tt.key); err == nil || errors.Is(err, ErrNotFound) {
+					t.Errorf("Get = %v, want an invalid key error", err)
+				}
+				if err := store.Delete(tt.key); err == nil {
+					t.Error("Delete succeeded, want an error")
+				}
+				if _, err := os.Stat(filepath.Join(root, "receipt")); !os.IsNotExist(err) {
+					t.Error("a file was written outside the store")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+
+			blob, err := store.Get(tt.key)
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			content, err := io.ReadAll(blob)
+			blob.Close()
+			if err != nil || string(content) != "content" {
+				t.Errorf("Get read %q, %v, want %q", content, err, "content")
+			}
+
+			if err := store.Delete(tt.key); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if _, err := store.Get(tt.key); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get after Delete = %v, want %v", err, ErrNotFound)
+			}
+			if err := store.Delete(tt.key); err != nil {
+				t.Errorf("deleting a missing blob: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package blobstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// s3Stub stands in for an S3 bucket: it checks the signature of every request
+// and answers with status, or with the body it holds for a GET
+type s3Stub struct {
+	t      *testing.T
+	status int
+	body   string
+
+	// paths are the escaped paths requested, in order
+	paths []string
+}
+
+func newS3Stub(t *testing.T, status int) (*s3Stub, *S3Store) {
+	stub := &s3Stub{t: t, status: status}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	return stub, NewS3Store(server.URL+"/", "eu-west-1", "receipts", "AKIDEXAMPLE", "secret")
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.t.Errorf("reading request body: %v", err)
+	}
+	s.paths = append(s.paths, r.URL.EscapedPath())
+	s.checkSignature(r, content)
+
+	w.WriteHeader(s.status)
+	io.WriteString(w, s.body)
+}
+
+// checkSignature recomputes the Signature Version 4 of the request the way S3
+// does, from what arrived on the wire
+func (s *s3Stub) checkSignature(r *http.Request, content []byte) {
+	s.t.Helper()
+
+	sum := sha256.Sum256(content)
+	payloadHash := hex.EncodeToString(sum[:])
+	if got := r.Header.Get("X-Amz-Content-Sha256"); got != payloadHash {
+		s.t.Errorf("x-amz-content-sha256 = %q, want %q", got, payloadHash)
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	signedAt, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil {
+		s.t.Errorf("x-amz-date %q: %v", amzDate, err)
+		return
+	}
+	if age := time.Since(signedAt); age < -time.Minute || age > time.Minute {
+		s.t.Errorf("x-amz-date %q is %s away from now", amzDate, age)
+	}
+
+	date := amzDate[:8]
+	scope := date + "/eu-west-1/s3/aws4_request"
+	canonicalRequest := r.Method + "\n" +
+		r.URL.EscapedPath() + "\n" +
+		r.URL.RawQuery + "\n" +
+		"host:" + r.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n" +
+		"\n" +
+		"host;x-amz-content-sha256;x-amz-date\n" +
+		payloadHash
+	requestSum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestSum[:])
+
+	key := []byte("AWS4secret")
+	for _, part := range []string{date, "eu-west-1", "s3", "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+
+	want := fmt.Sprintf("AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/%s, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=%s",
+		scope, hex.EncodeToString(key))
+	if got := r.Header.Get("Authorization"); got != want {
+		s.t.Errorf("%s %s: Authorization = %q, want %q", r.Method, r.URL.EscapedPath(), got, want)
+	}
+}
+
+func TestS3StoreSignsRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		wantPath string
+	}{
+		{name: "plain key", key: "expenses/12/receipt.jpg", wantPath: "/receipts/expenses/12/receipt.jpg"},
+		{name: "space and plus", key: "expenses/12/my receipt+1.jpg", wantPath: "/receipts/expenses/12/my%20receipt%2B1.jpg"},
+		{name: "reserved characters", key: "expenses/12/a=b&c@d:e$f!.pdf", wantPath: "/receipts/expenses/12/a%3Db%26c%40d%3Ae%24f%21.pdf"},
+		{name: "unreserved characters", key: "expenses/12/A-z_0.9~", wantPath: "/receipts/expenses/12/A-z_0.9~"},
+		{name: "non-ASCII", key: "expenses/12/reçu.png", wantPath: "/receipts/expenses/12/re%C3%A7u.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub, store := newS3Stub(t, http.StatusOK)
+			stub.body = "content"
+
+			if err := store.Put(tt.key, []byte("content"), "image/jpeg"); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+			blob, err := store.Get(tt.key)
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			content, err := io.ReadAll(blob)
+			blob.Close()
+			if err != nil || string(content) != "content" {
+				t.Errorf("Get read %q, %v, want %q", content, err, "content")
+			}
+			if err := store.Delete(tt.key); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+
+			for _, path := range stub.paths {
+				if path != tt.wantPath {
+					t.Errorf("requested %s, want %s", path, tt.wantPath)
+				}
+			}
+		})
+	}
+}
+
+func TestS3StoreStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		// want is what each operation returns: nil, ErrNotFound, or errS3 for
+		// any other error
+		wantPut    error
+		wantGet    error
+		wantDelete error
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "no content", status: http.StatusNoContent, wantPut: errS3, wantGet: errS3},
+		{name: "not found", status: http.StatusNotFound, wantPut: errS3, wantGet: ErrNotFound},
+		{name: "forbidden", status: http.StatusForbidden, wantPut: errS3, wantGet: errS3, wantDelete: errS3},
+		{name: "server error", status: http.StatusInternalServerError, wantPut: errS3, wantGet: errS3, wantDelete: errS3},
+		{name: "unavailable", status: http.StatusServiceUnavailable, wantPut: errS3, wantGet: errS3, wantDelete: errS3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, store := newS3Stub(t, tt.status)
+
+			checkS3Error(t, "Put", store.Put("expenses/1/receipt", []byte("content"), "text/plain"), tt.wantPut)
+			blob, err := store.Get("expenses/1/receipt")
+			if err == nil {
+				blob.Close()
+			}
+			checkS3Error(t, "Get", err, tt.wantGet)
+			checkS3Error(t, "Delete", store.Delete("expenses/1/receipt"), tt.wantDelete)
+		})
+	}
+}
+
+// errS3 stands for any error other than ErrNotFound
+var errS3 = errors.New("S3 error")
+
+func checkS3Error(t *testing.T, op string, err, want error) {
+	t.Helper()
+	switch {
+	case want == nil && err != nil:
+		t.Errorf("%s failed: %v", op, err)
+	case want == ErrNotFound && !errors.Is(err, ErrNotFound):
+		t.Errorf("%s = %v, want %v", op, err, ErrNotFound)
+	case want == errS3 && (err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "S3 error")):
+		t.Errorf("%s = %v, want an S3 error", op, err)
+	}
+}
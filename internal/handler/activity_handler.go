@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/service"
+)
+
+type ActivityHandler struct {
+	activityService *service.ActivityService
+	authz           *service.AuthorizationService
+}
+
+func NewActivityHandler(activityService *service.ActivityService, authz *service.AuthorizationService) *ActivityHandler {
+	return &ActivityHandler{activityService: activityService, authz: authz}
+}
+
+// GetGroupActivity pages through the group's activity log, newest first. It
+// takes the cursor, limit, order, from and to parameters of other lists.
+func (h *ActivityHandler) GetGroupActivity(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	if err := h.authz.RequireMember(groupID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	query, err := parseListQuery(c, model.ActivitySortFields)
+	if err == nil && query.HasLedgerFilters() {
+		err = fmt.Errorf("activity can only be filtered by date")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	activities, next, err := h.activityService.GetGroupActivity(groupID, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"activities": activities, "next_cursor": next})
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repositorymem"
+	"github.com/shreyansh/expense-go-collab-backend/internal/service"
+)
+
+// testAPI serves the routes of the API the tests use, on an in-memory store.
+// tokens holds an access token for each registered user, by name.
+type testAPI struct {
+	router *gin.Engine
+	tokens map[string]string
+}
+
+func newTestAPI(t *testing.T, names ...string) *testAPI {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	store := repositorymem.NewStore()
+	users := repositorymem.NewUserRepositoryMem(store)
+	groups := repositorymem.NewGroupRepositoryMem(store)
+	members := repositorymem.NewGroupMemberRepositoryMem(store)
+	expenses := repositorymem.NewExpenseRepositoryMem(store)
+	splits := repositorymem.NewExpenseSplitRepositoryMem(store)
+	balances := repositorymem.NewBalanceRepositoryMem(store)
+	settlements := repositorymem.NewSettlementRepositoryMem(store)
+	categories := repositorymem.NewCategoryRepositoryMem(store)
+	rates := repositorymem.NewExchangeRateRepositoryMem(store)
+	tokens := repositorymem.NewRefreshTokenRepositoryMem(store)
+	uow := repositorymem.NewUnitOfWorkMem(store)
+
+	authService := service.NewAuthService(users, tokens, []byte("test secret"))
+	authz := service.NewAuthorizationService(members, expenses, splits, settlements)
+	userHandler := NewUserHandler(service.NewUserService(users, uow), authz)
+	authHandler := NewAuthHandler(authService)
+	groupHandler := NewGroupHandler(service.NewGroupService(users, groups, members, expenses, splits, balances, uow), authz)
+	expenseHandler := NewExpenseHandler(service.NewExpenseService(users, groups, expenses, splits, members, rates, categories, uow), authz)
+	balanceHandler := NewBalanceHandler(service.NewBalanceService(balances, groups, uow), service.NewSettlePlanService(balances, groups), users, authz)
+	settlementHandler := NewSettlementHandler(service.NewSettlementService(settlements, users, groups, members, balances, rates, uow), authz)
+	exportHandler := NewExportHandler(service.NewExportService(categories, uow), authz)
+	placeholderHandler := NewPlaceholderHandler(service.NewPlaceholderService(users, groups, members, uow), authz)
+
+	router := gin.New()
+	router.POST("/api/users/register", userHandler.Register)
+	router.POST("/api/auth/login", authHandler.Login)
+
+	authed := router.Group("/", AuthMiddleware(authService))
+	authed.GET("/api/users", userHandler.GetAllUsers)
+	authed.GET("/api/users/:id", userHandler.GetUser)
+	authed.POST("/api/groups", groupHandler.CreateGroup)
+	authed.GET("/api/groups/:id", groupHandler.GetGroup)
+	authed.GET("/api/groups/:id/export.csv", exportHandler.ExportGroupLedgerCSV)
+	authed.POST("/api/members", groupHandler.AddGroupMember)
+	authed.POST("/api/members/placeholder", placeholderHandler.AddPlaceholderMember)
+	authed.POST("/api/placeholders/:id/claim", placeholderHandler.ClaimPlaceholder)
+	authed.POST("/api/expenses", expenseHandler.CreateExpense)
+	authed.GET("/api/expenses/:id", expenseHandler.GetExpense)
+	authed.DELETE("/api/expenses/:id", expenseHandler.DeleteExpense)
+	authed.POST("/api/expenses/:id/restore", expenseHandler.RestoreExpense)
+	authed.GET("/api/balance/group/:group_id", balanceHandler.GetGroupBalances)
+	authed.GET("/api/balance/group/:group_id/settle-plan", balanceHandler.GetSettlePlan)
+	authed.POST("/api/settle", settlementHandler.CreateSettlement)
+	authed.DELETE("/api/settle/:id", settlementHandler.DeleteSettlement)
+
+	api := &testAPI{router: router, tokens: make(map[string]string)}
+	for _, name := range names {
+		body := fmt.Sprintf(`{"email":"%s@example.com","name":"%s","password":"password123"}`, name, name)
+		if w := api.do("", http.MethodPost, "/api/users/register", body); w.Code != http.StatusCreated {
+			t.Fatalf("registering %s: %d %s", name, w.Code, w.Body)
+		}
+
+		body = fmt.Sprintf(`{"email":"%s@example.com","password":"password123"}`, name)
+		w := api.do("", http.MethodPost, "/api/auth/login", body)
+		var login struct {
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &login); err != nil || login.AccessToken == "" {
+			t.Fatalf("logging in %s: %d %s", name, w.Code, w.Body)
+		}
+		api.tokens[name] = login.AccessToken
+	}
+
+	return api
+}
+
+// do sends a request as the named user, or without a token when user is empty
+func (a *testAPI) do(user, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		req.Header.Set("Authorization", "Bearer "+a.tokens[user])
+	}
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	return w
+}
+
+// apiStep is a request and what its response must be. wantBody lists strings
+// the response body must contain, and notBody strings it must not.
+type apiStep struct {
+	user       string
+	method     string
+	path       string
+	body       string
+	wantStatus int
+	wantBody   []string
+	notBody    []string
+}
+
+func runSteps(t *testing.T, api *testAPI, steps []apiStep) {
+	t.Helper()
+	for i, step := range steps {
+		w := api.do(step.user, step.method, step.path, step.body)
+		if w.Code != step.wantStatus {
+			t.Fatalf("step %d: %s %s as %q = %d, want %d: %s", i+1, step.method, step.path, step.user, w.Code, step.wantStatus, w.Body)
+		}
+		for _, want := range step.wantBody {
+			if !strings.Contains(w.Body.String(), want) {
+				t.Errorf("step %d: %s %s: body %s does not contain %s", i+1, step.method, step.path, w.Body, want)
+			}
+		}
+		for _, unwanted := range step.notBody {
+			if strings.Contains(w.Body.String(), unwanted) {
+				t.Errorf("step %d: %s %s: body %s contains %s", i+1, step.method, step.path, w.Body, unwanted)
+			}
+		}
+	}
+}
+
+func TestSharedExpenseFlow(t *testing.T) {
+	api := newTestAPI(t, "ann", "bob", "cat")
+
+	runSteps(t, api, []apiStep{
+		{user: "ann", method: http.MethodPost, path: "/api/groups", body: `{"name":"Trip"}`, wantStatus: http.StatusCreated},
+		{user: "ann", method: http.MethodPost, path: "/api/members", body: `{"group_id":1,"email":"bob@example.com"}`, wantStatus: http.StatusCreated},
+
+		// ann pays 30.00 for both of them, so bob owes her 15.00
+		{user: "ann", method: http.MethodPost, path: "/api/expenses", body: `{"group_id":1,"paid_by_id":1,"amount":30,"description":"Dinner"}`,
+			wantStatus: http.StatusCreated, wantBody: []string{`"base_amount":30.00`}},
+		{user: "bob", method: http.MethodGet, path: "/api/balance/group/1", wantStatus: http.StatusOK,
+			wantBody: []string{`"user_id":1,"user_name":"ann","amount":15.00`, `"user_id":2,"user_name":"bob","amount":-15.00`}},
+		{user: "bob", method: http.MethodGet, path: "/api/balance/group/1/settle-plan", wantStatus: http.StatusOK,
+			wantBody: []string{`"from_user_id":2,"to_user_id":1,"amount":15.00`}},
+
+		// Paying back settles them
+		{user: "bob", method: http.MethodPost, path: "/api/settle", body: `{"group_id":1,"from_user_id":2,"to_user_id":1,"amount":15}`, wantStatus: http.StatusCreated},
+		{user: "ann", method: http.MethodGet, path: "/api/balance/group/1", wantStatus: http.StatusOK,
+			wantBody: []string{`"user_id":1,"user_name":"ann","amount":0.00`, `"user_id":2,"user_name":"bob","amount":0.00`}},
+		{user: "ann", method: http.MethodGet, path: "/api/groups/1/export.csv", wantStatus: http.StatusOK,
+			wantBody: []string{"expense,1,,Dinner,,ann,,30.00", "settlement,,1,,,bob,ann,15.00,USD,15.00,USD,0.00,0.00"}},
+
+		// Deleting the expense leaves only the payment, which ann now owes back
+		{user: "ann", method: http.MethodDelete, path: "/api/expenses/1", wantStatus: http.StatusNoContent},
+		{user: "ann", method: http.MethodGet, path: "/api/expenses/1", wantStatus: http.StatusNotFound},
+		{user: "ann", method: http.MethodGet, path: "/api/balance/group/1", wantStatus: http.StatusOK,
+			wantBody: []string{`"user_id":1,"user_name":"ann","amount":-15.00`}},
+		{user: "ann", method: http.MethodPost, path: "/api/expenses/1/restore", wantStatus: http.StatusOK},
+		{user: "ann", method: http.MethodGet, path: "/api/balance/group/1", wantStatus: http.StatusOK,
+			wantBody: []string{`"user_id":1,"user_name":"ann","amount":0.00`}},
+	})
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/service"
+)
+
+// multipartOverhead is room for the multipart framing around an uploaded file
+const multipartOverhead = 64 << 10
+
+type AttachmentHandler struct {
+	attachmentService *service.AttachmentService
+	authz             *service.AuthorizationService
+}
+
+func NewAttachmentHandler(attachmentService *service.AttachmentService, authz *service.AuthorizationService) *AttachmentHandler {
+	return &AttachmentHandler{attachmentService: attachmentService, authz: authz}
+}
+
+// UploadAttachment attaches the file in the "file" field of a multipart form
+// to the expense. Requires being allowed to edit the expense.
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	expenseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expense id"})
+		return
+	}
+
+	if err := h.authz.CanEditExpense(expenseID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.attachmentService.MaxSize()+multipartOverhead)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": service.ErrAttachmentTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expected a multipart form with a file field"})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.attachmentService.UploadAttachment(expenseID, CurrentUser(c).ID, header.Filename, file)
+	if err != nil {
+		if errors.Is(err, service.ErrAttachmentTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+func (h *AttachmentHandler) GetExpenseAttachments(c *gin.Context) {
+	expenseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expense id"})
+		return
+	}
+
+	if err := h.authz.CanReadExpense(expenseID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	attachments, err := h.attachmentService.GetExpenseAttachments(expenseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// DownloadAttachment sends the content of an attachment
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	h.download(c, false)
+}
+
+// DownloadThumbnail sends the JPEG thumbnail of an image attachment
+func (h *AttachmentHandler) DownloadThumbnail(c *gin.Context) {
+	h.download(c, true)
+}
+
+func (h *AttachmentHandler) download(c *gin.Context, thumbnail bool) {
+	attachment, ok := h.attachment(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authz.CanReadExpense(attachment.ExpenseID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	content, err := h.attachmentService.OpenAttachment(attachment, thumbnail)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	defer content.Close()
+
+	contentType, size := attachment.ContentType, attachment.Size
+	if thumbnail {
+		contentType, size = "image/jpeg", -1
+	}
+	c.DataFromReader(http.StatusOK, size, contentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("inline", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteAttachment removes an attachment. Requires being its uploader or
+// being allowed to edit the expense.
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	attachment, ok := h.attachment(c)
+	if !ok {
+		return
+	}
+
+	// Uploaders can remove their files as long as they are in the group
+	authorize := h.authz.CanEditExpense
+	if attachment.UploadedByID != nil && *attachment.UploadedByID == CurrentUser(c).ID {
+		authorize = h.authz.CanReadExpense
+	}
+	if err := authorize(attachment.ExpenseID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	if err := h.attachmentService.DeleteAttachment(attachment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// attachment returns the attachment in the URL. Otherwise it responds with the
+// error and ok is false.
+func (h *AttachmentHandler) attachment(c *gin.Context) (*model.Attachment, bool) {
+	expenseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expense id"})
+		return nil, false
+	}
+	id, err := strconv.Atoi(c.Param("attachment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment id"})
+		return nil, false
+	}
+
+	attachment, err := h.attachmentService.GetAttachment(expenseID, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return attachment, true
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/service"
+)
+
+// authUserKey is the gin context key holding the authenticated *model.User
+const authUserKey = "auth_user"
+
+// AuthMiddleware rejects requests without a valid "Authorization: Bearer <token>"
+// header and stores the authenticated user on the context
+func AuthMiddleware(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		user, err := authService.Authenticate(token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(authUserKey, user)
+		c.Next()
+	}
+}
+
+// CurrentUser returns the user authenticated by AuthMiddleware
+func CurrentUser(c *gin.Context) *model.User {
+	return c.MustGet(authUserKey).(*model.User)
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyansh/expense-go-collab-backend/internal/service"
+)
+
+// respondAuthzError reports a failed authorization check: 403 when the user may
+// not access the resource, 404 when the resource itself was not found
+func respondAuthzError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+}
+
+// requireSelf checks that a user ID taken from the URL is the authenticated
+// user, for routes listing one user's data across groups
+func requireSelf(c *gin.Context, userID int) bool {
+	if userID != CurrentUser(c).ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: you can only view your own data"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAccessControl(t *testing.T) {
+	api := newTestAPI(t, "ann", "bob", "cat")
+
+	runSteps(t, api, []apiStep{
+		{user: "ann", method: http.MethodPost, path: "/api/groups", body: `{"name":"Trip"}`, wantStatus: http.StatusCreated},
+		{user: "ann", method: http.MethodPost, path: "/api/members", body: `{"group_id":1,"email":"bob@example.com"}`, wantStatus: http.StatusCreated},
+		{user: "ann", method: http.MethodPost, path: "/api/expenses", body: `{"group_id":1,"paid_by_id":1,"amount":30}`, wantStatus: http.StatusCreated},
+
+		// Without a token
+		{method: http.MethodGet, path: "/api/groups/1", wantStatus: http.StatusUnauthorized},
+
+		// cat is not in the group
+		{user: "cat", method: http.MethodGet, path: "/api/groups/1", wantStatus: http.StatusForbidden},
+		{user: "cat", method: http.MethodGet, path: "/api/balance/group/1", wantStatus: http.StatusForbidden},
+		{user: "cat", method: http.MethodGet, path: "/api/expenses/1", wantStatus: http.StatusForbidden},
+		{user: "cat", method: http.MethodGet, path: "/api/groups/1/export.csv", wantStatus: http.StatusForbidden},
+		{user: "cat", method: http.MethodPost, path: "/api/expenses", body: `{"group_id":1,"paid_by_id":3,"amount":30}`, wantStatus: http.StatusForbidden},
+		{user: "cat", method: http.MethodPost, path: "/api/settle", body: `{"group_id":1,"from_user_id":2,"to_user_id":1,"amount":5}`, wantStatus: http.StatusForbidden},
+
+		// Users only see themselves and the members of their groups
+		{user: "bob", method: http.MethodGet, path: "/api/users/1", wantStatus: http.StatusOK, wantBody: []string{"ann@example.com"}},
+		{user: "cat", method: http.MethodGet, path: "/api/users/1", wantStatus: http.StatusForbidden},
+		{user: "cat", method: http.MethodGet, path: "/api/users/3", wantStatus: http.StatusOK},
+		{user: "cat", method: http.MethodGet, path: "/api/users/99", wantStatus: http.StatusForbidden},
+		{user: "bob", method: http.MethodGet, path: "/api/users", wantStatus: http.StatusOK,
+			wantBody: []string{"ann@example.com", "bob@example.com"}, notBody: []string{"cat@example.com"}},
+		{user: "cat", method: http.MethodGet, path: "/api/users", wantStatus: http.StatusOK,
+			wantBody: []string{"cat@example.com"}, notBody: []string{"ann@example.com", "bob@example.com"}},
+
+		// Emails are one account whatever their case, and a placeholder is only
+		// claimed by the person it was added for, not by a group admin
+		{method: http.MethodPost, path: "/api/users/register", body: `{"email":"BOB@Example.com","name":"Bob","password":"password123"}`, wantStatus: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/api/auth/login", body: `{"email":"Bob@example.com","password":"password123"}`, wantStatus: http.StatusOK},
+		{user: "ann", method: http.MethodPost, path: "/api/members/placeholder", body: `{"group_id":1,"name":"Cat","email":"CAT@example.com"}`, wantStatus: http.StatusBadRequest},
+		{user: "ann", method: http.MethodPost, path: "/api/members/placeholder", body: `{"group_id":1,"name":"Sam"}`, wantStatus: http.StatusCreated},
+		{user: "ann", method: http.MethodPost, path: "/api/placeholders/4/claim", wantStatus: http.StatusForbidden},
+		{user: "bob", method: http.MethodPost, path: "/api/placeholders/4/claim", wantStatus: http.StatusForbidden},
+
+		// Only the payer and group admins change an expense
+		{user: "bob", method: http.MethodDelete, path: "/api/expenses/1", wantStatus: http.StatusForbidden},
+		{user: "ann", method: http.MethodDelete, path: "/api/expenses/1", wantStatus: http.StatusNoContent},
+		{user: "ann", method: http.MethodDelete, path: "/api/expenses/1", wantStatus: http.StatusNotFound},
+
+		// Bad input
+		{user: "ann", method: http.MethodPost, path: "/api/expenses", body: `{"group_id":1,"paid_by_id":1,"amount":-5}`, wantStatus: http.StatusBadRequest},
+		{user: "ann", method: http.MethodPost, path: "/api/members", body: `{"group_id":1}`, wantStatus: http.StatusBadRequest},
+	})
+}
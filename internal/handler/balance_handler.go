@@ -5,19 +5,28 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/shreyansh/expense-go-collab-backend/internal/repositorypg"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
 	"github.com/shreyansh/expense-go-collab-backend/internal/service"
 )
 
 type BalanceHandler struct {
-	balanceService *service.BalanceService
-	userRepo       *repositorypg.UserRepositoryPG
+	balanceService    *service.BalanceService
+	settlePlanService *service.SettlePlanService
+	userRepo          repository.UserRepository
+	authz             *service.AuthorizationService
 }
 
-func NewBalanceHandler(balanceService *service.BalanceService, userRepo *repositorypg.UserRepositoryPG) *BalanceHandler {
+func NewBalanceHandler(
+	balanceService *service.BalanceService,
+	settlePlanService *service.SettlePlanService,
+	userRepo repository.UserRepository,
+	authz *service.AuthorizationService,
+) *BalanceHandler {
 	return &BalanceHandler{
-		balanceService: balanceService,
-		userRepo:       userRepo,
+		balanceService:    balanceService,
+		settlePlanService: settlePlanService,
+		userRepo:          userRepo,
+		authz:             authz,
 	}
 }
 
@@ -34,13 +43,18 @@ func (h *BalanceHandler) GetUserBalance(c *gin.Context) {
 		return
 	}
 
+	if err := h.authz.RequireMember(groupID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
 	balance, err := h.balanceService.GetUserBalance(userID, groupID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"user_id": userID, "group_id": groupID, "balance": balance})
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "group_id": groupID, "balance": balance.Amount, "currency": balance.Currency})
 }
 
 func (h *BalanceHandler) GetGroupBalances(c *gin.Context) {
@@ -50,6 +64,11 @@ func (h *BalanceHandler) GetGroupBalances(c *gin.Context) {
 		return
 	}
 
+	if err := h.authz.RequireMember(groupID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
 	balances, err := h.balanceService.GetGroupBalancesWithNames(groupID, h.userRepo)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -73,6 +92,11 @@ func (h *BalanceHandler) GetUserGroupBalances(c *gin.Context) {
 		return
 	}
 
+	if err := h.authz.RequireMember(groupID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
 	balances, err := h.balanceService.GetUserRelativeBalances(groupID, userID, h.userRepo)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -81,3 +105,35 @@ func (h *BalanceHandler) GetUserGroupBalances(c *gin.Context) {
 
 	c.JSON(http.StatusOK, balances)
 }
+
+// GetSettlePlan returns the transfers that would settle every debt in the group
+// GET /api/balance/group/:group_id/settle-plan?simplify=true
+func (h *BalanceHandler) GetSettlePlan(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	if err := h.authz.RequireMember(groupID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	simplify := true
+	if raw := c.Query("simplify"); raw != "" {
+		simplify, err = strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid simplify flag"})
+			return
+		}
+	}
+
+	plan, err := h.settlePlanService.GetSettlePlan(groupID, simplify)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
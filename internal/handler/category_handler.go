@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/service"
+)
+
+type CategoryHandler struct {
+	categoryService *service.CategoryService
+	authz           *service.AuthorizationService
+}
+
+func NewCategoryHandler(categoryService *service.CategoryService, authz *service.AuthorizationService) *CategoryHandler {
+	return &CategoryHandler{categoryService: categoryService, authz: authz}
+}
+
+// GetGroupCategories lists the global categories and the group's own as a tree
+func (h *CategoryHandler) GetGroupCategories(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	if err := h.authz.RequireMember(groupID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	categories, err := h.categoryService.GetGroupCategories(groupID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// CreateCategory adds a custom category to a group. Requires the admin role.
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var req model.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if _, err := h.authz.RequireRole(req.GroupID, CurrentUser(c).ID, model.RoleAdmin); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	category, err := h.categoryService.CreateCategory(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory renames or moves a group's custom category. Requires the admin
+// role in that group.
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	id, ok := h.requireCategoryAdmin(c)
+	if !ok {
+		return
+	}
+
+	var req model.CategoryUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	category, err := h.categoryService.UpdateCategory(id, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory deletes a group's custom category, leaving its expenses
+// uncategorized. Requires the admin role in that group.
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	id, ok := h.requireCategoryAdmin(c)
+	if !ok {
+		return
+	}
+
+	if err := h.categoryService.DeleteCategory(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// requireCategoryAdmin reads the category ID from the URL and checks that the
+// category belongs to a group the user administers. Global categories belong
+// to no group and cannot be changed by anyone.
+func (h *CategoryHandler) requireCategoryAdmin(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+		return 0, false
+	}
+
+	category, err := h.categoryService.GetCategoryByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	if category.GroupID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: global categories cannot be changed"})
+		return 0, false
+	}
+
+	if _, err := h.authz.RequireRole(*category.GroupID, CurrentUser(c).ID, model.RoleAdmin); err != nil {
+		respondAuthzError(c, err)
+		return 0, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/service"
+)
+
+type CommentHandler struct {
+	commentService *service.CommentService
+	authz          *service.AuthorizationService
+}
+
+func NewCommentHandler(commentService *service.CommentService, authz *service.AuthorizationService) *CommentHandler {
+	return &CommentHandler{commentService: commentService, authz: authz}
+}
+
+// CommentOnExpense adds a comment to an expense. Any member of its group can
+// take part in the discussion.
+func (h *CommentHandler) CommentOnExpense(c *gin.Context) {
+	expenseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expense id"})
+		return
+	}
+
+	if err := h.authz.CanReadExpense(expenseID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	var req model.CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	comment, err := h.commentService.CommentOnExpense(expenseID, CurrentUser(c).ID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+func (h *CommentHandler) GetExpenseComments(c *gin.Context) {
+	expenseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expense id"})
+		return
+	}
+
+	if err := h.authz.CanReadExpense(expenseID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	comments, err := h.commentService.GetExpenseComments(expenseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+// CommentOnSettlement adds a comment to a settlement. Any member of its group
+// can take part in the discussion.
+func (h *CommentHandler) CommentOnSettlement(c *gin.Context) {
+	settlementID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid settlement id"})
+		return
+	}
+
+	if err := h.authz.CanReadSettlement(settlementID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	var req model.CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	comment, err := h.commentService.CommentOnSettlement(settlementID, CurrentUser(c).ID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+func (h *CommentHandler) GetSettlementComments(c *gin.Context) {
+	settlementID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid settlement id"})
+		return
+	}
+
+	if err := h.authz.CanReadSettlement(settlementID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	comments, err := h.commentService.GetSettlementComments(settlementID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+// UpdateComment edits a comment. Only its author can edit it.
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	comment, ok := h.editableComment(c)
+	if !ok {
+		return
+	}
+
+	var req model.CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	updated, err := h.commentService.UpdateComment(comment, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteComment removes a comment. Only its author can delete it.
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	comment, ok := h.editableComment(c)
+	if !ok {
+		return
+	}
+
+	if err := h.commentService.DeleteComment(comment.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// editableComment returns the comment in the URL once the user is allowed to
+// change it. Otherwise it responds with the error and ok is false.
+func (h *CommentHandler) editableComment(c *gin.Context) (*model.Comment, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid comment id"})
+		return nil, false
+	}
+
+	comment, err := h.commentService.GetComment(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	if err := h.authz.CanEditComment(comment, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return nil, false
+	}
+
+	return comment, true
+}
@@ -11,10 +11,11 @@ import (
 
 type ExpenseHandler struct {
 	expenseService *service.ExpenseService
+	authz          *service.AuthorizationService
 }
 
-func NewExpenseHandler(expenseService *service.ExpenseService) *ExpenseHandler {
-	return &ExpenseHandler{expenseService: expenseService}
+func NewExpenseHandler(expenseService *service.ExpenseService, authz *service.AuthorizationService) *ExpenseHandler {
+	return &ExpenseHandler{expenseService: expenseService, authz: authz}
 }
 
 func (h *ExpenseHandler) CreateExpense(c *gin.Context) {
@@ -25,7 +26,12 @@ func (h *ExpenseHandler) CreateExpense(c *gin.Context) {
 		return
 	}
 
-	expense, err := h.expenseService.CreateExpense(req.GroupID, req.PaidByID, req.Amount, req.Description)
+	if _, err := h.authz.RequireRole(req.GroupID, CurrentUser(c).ID, model.RoleMember); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	expense, err := h.expenseService.CreateExpense(&req, CurrentUser(c).ID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -41,6 +47,11 @@ func (h *ExpenseHandler) GetExpense(c *gin.Context) {
 		return
 	}
 
+	if err := h.authz.CanReadExpense(id, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
 	expense, err := h.expenseService.GetExpenseByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -57,7 +68,18 @@ func (h *ExpenseHandler) GetGroupExpenses(c *gin.Context) {
 		return
 	}
 
-	expenses, err := h.expenseService.GetExpensesByGroupID(groupID)
+	if err := h.authz.RequireMember(groupID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	query, err := parseListQuery(c, model.ExpenseSortFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expenses, err := h.expenseService.GetExpensesByGroupID(groupID, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -73,7 +95,17 @@ func (h *ExpenseHandler) GetUserExpenses(c *gin.Context) {
 		return
 	}
 
-	expenses, err := h.expenseService.GetExpensesByUserID(userID)
+	if !requireSelf(c, userID) {
+		return
+	}
+
+	query, err := parseListQuery(c, model.ExpenseSortFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expenses, err := h.expenseService.GetExpensesByUserID(userID, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -89,25 +121,20 @@ func (h *ExpenseHandler) UpdateExpense(c *gin.Context) {
 		return
 	}
 
-	var req map[string]interface{}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+	if err := h.authz.CanEditExpense(id, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
 		return
 	}
 
-	var amount float64
-	var description string
-
-	if val, ok := req["amount"].(float64); ok {
-		amount = val
-	}
-	if val, ok := req["description"].(string); ok {
-		description = val
+	var req model.ExpenseUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
 	}
 
-	expense, err := h.expenseService.UpdateExpense(id, amount, description)
+	expense, err := h.expenseService.UpdateExpense(id, &req, CurrentUser(c).ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -121,7 +148,12 @@ func (h *ExpenseHandler) DeleteExpense(c *gin.Context) {
 		return
 	}
 
-	err = h.expenseService.DeleteExpense(id)
+	if err := h.authz.CanEditExpense(id, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	err = h.expenseService.DeleteExpense(id, CurrentUser(c).ID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -130,6 +162,27 @@ func (h *ExpenseHandler) DeleteExpense(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+func (h *ExpenseHandler) RestoreExpense(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expense id"})
+		return
+	}
+
+	if err := h.authz.CanRestoreExpense(id, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	expense, err := h.expenseService.RestoreExpense(id, CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, expense)
+}
+
 func (h *ExpenseHandler) AddExpenseSplit(c *gin.Context) {
 	var req model.ExpenseSplitRequest
 
@@ -138,7 +191,12 @@ func (h *ExpenseHandler) AddExpenseSplit(c *gin.Context) {
 		return
 	}
 
-	split, err := h.expenseService.AddSplit(req.ExpenseID, req.UserID, req.Amount)
+	if err := h.authz.CanEditExpense(req.ExpenseID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	split, err := h.expenseService.AddSplit(req.ExpenseID, req.UserID, req.Amount, CurrentUser(c).ID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -154,6 +212,11 @@ func (h *ExpenseHandler) GetExpenseSplits(c *gin.Context) {
 		return
 	}
 
+	if err := h.authz.CanReadExpense(expenseID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
 	splits, err := h.expenseService.GetSplitsByExpenseID(expenseID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -170,6 +233,10 @@ func (h *ExpenseHandler) GetUserSplits(c *gin.Context) {
 		return
 	}
 
+	if !requireSelf(c, userID) {
+		return
+	}
+
 	splits, err := h.expenseService.GetSplitsByUserID(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -186,22 +253,51 @@ func (h *ExpenseHandler) UpdateExpenseSplit(c *gin.Context) {
 		return
 	}
 
-	var req map[string]interface{}
+	if err := h.authz.CanEditSplit(id, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	var req model.ExpenseSplitUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
 
-	var amount float64
-	if val, ok := req["amount"].(float64); ok {
-		amount = val
+	split, err := h.expenseService.UpdateSplit(id, req.Amount, CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	split, err := h.expenseService.UpdateSplit(id, amount)
+	c.JSON(http.StatusOK, split)
+}
+
+// ReplaceExpenseSplits replaces all splits of an expense with a new split spec
+// PUT /api/splits/expense/:expense_id
+func (h *ExpenseHandler) ReplaceExpenseSplits(c *gin.Context) {
+	expenseID, err := strconv.Atoi(c.Param("expense_id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expense id"})
 		return
 	}
 
-	c.JSON(http.StatusOK, split)
+	if err := h.authz.CanEditExpense(expenseID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	var req model.SplitSpec
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	splits, err := h.expenseService.ReplaceSplits(expenseID, &req, CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, splits)
 }
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyansh/expense-go-collab-backend/internal/service"
+)
+
+type ExportHandler struct {
+	exportService *service.ExportService
+	authz         *service.AuthorizationService
+}
+
+func NewExportHandler(exportService *service.ExportService, authz *service.AuthorizationService) *ExportHandler {
+	return &ExportHandler{exportService: exportService, authz: authz}
+}
+
+// ExportGroupLedgerCSV streams the group's expenses, splits and settlements as
+// a CSV file
+func (h *ExportHandler) ExportGroupLedgerCSV(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	if err := h.authz.RequireMember(groupID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="group-%d-ledger.csv"`, groupID))
+	c.Status(http.StatusOK)
+
+	if err := h.exportService.WriteGroupLedgerCSV(groupID, c.Writer); err != nil {
+		// Once rows have been sent the status can no longer change; the
+		// truncated file is all the client gets
+		if !c.Writer.Written() {
+			c.Header("Content-Type", "")
+			c.Header("Content-Disposition", "")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error exporting ledger of group %d: %v", groupID, err)
+	}
+}
@@ -11,12 +11,14 @@ import (
 
 type GroupHandler struct {
 	groupService *service.GroupService
+	authz        *service.AuthorizationService
 }
 
-func NewGroupHandler(groupService *service.GroupService) *GroupHandler {
-	return &GroupHandler{groupService: groupService}
+func NewGroupHandler(groupService *service.GroupService, authz *service.AuthorizationService) *GroupHandler {
+	return &GroupHandler{groupService: groupService, authz: authz}
 }
 
+// CreateGroup creates a group owned by the authenticated user
 func (h *GroupHandler) CreateGroup(c *gin.Context) {
 	var req model.GroupRequest
 
@@ -25,7 +27,7 @@ func (h *GroupHandler) CreateGroup(c *gin.Context) {
 		return
 	}
 
-	group, err := h.groupService.CreateGroup(req.Name, req.Description, req.CreatorID)
+	group, err := h.groupService.CreateGroup(req.Name, req.Description, req.Currency, CurrentUser(c).ID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -41,6 +43,11 @@ func (h *GroupHandler) GetGroup(c *gin.Context) {
 		return
 	}
 
+	if err := h.authz.RequireMember(id, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
 	group, err := h.groupService.GetGroupByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -50,8 +57,9 @@ func (h *GroupHandler) GetGroup(c *gin.Context) {
 	c.JSON(http.StatusOK, group)
 }
 
+// GetAllGroups lists the groups the authenticated user belongs to
 func (h *GroupHandler) GetAllGroups(c *gin.Context) {
-	groups, err := h.groupService.GetAllGroups()
+	groups, err := h.groupService.GetGroupsByUserID(CurrentUser(c).ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -67,6 +75,10 @@ func (h *GroupHandler) GetUserGroups(c *gin.Context) {
 		return
 	}
 
+	if !requireSelf(c, userID) {
+		return
+	}
+
 	groups, err := h.groupService.GetGroupsByUserID(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -83,6 +95,11 @@ func (h *GroupHandler) UpdateGroup(c *gin.Context) {
 		return
 	}
 
+	if _, err := h.authz.RequireRole(id, CurrentUser(c).ID, model.RoleAdmin); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
 	var req map[string]string
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
@@ -97,7 +114,7 @@ func (h *GroupHandler) UpdateGroup(c *gin.Context) {
 
 	description := req["description"]
 
-	group, err := h.groupService.UpdateGroup(id, name, description)
+	group, err := h.groupService.UpdateGroup(id, name, description, CurrentUser(c).ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -113,7 +130,12 @@ func (h *GroupHandler) DeleteGroup(c *gin.Context) {
 		return
 	}
 
-	err = h.groupService.DeleteGroup(id)
+	if _, err := h.authz.RequireRole(id, CurrentUser(c).ID, model.RoleAdmin); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	err = h.groupService.DeleteGroup(id, CurrentUser(c).ID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -122,6 +144,27 @@ func (h *GroupHandler) DeleteGroup(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+func (h *GroupHandler) RestoreGroup(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	if _, err := h.authz.RequireRole(id, CurrentUser(c).ID, model.RoleAdmin); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	group, err := h.groupService.RestoreGroup(id, CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
 func (h *GroupHandler) AddGroupMember(c *gin.Context) {
 	var req model.GroupMemberRequest
 
@@ -130,7 +173,12 @@ func (h *GroupHandler) AddGroupMember(c *gin.Context) {
 		return
 	}
 
-	member, err := h.groupService.AddMemberToGroupByEmail(req.GroupID, req.Email)
+	if _, err := h.authz.RequireRole(req.GroupID, CurrentUser(c).ID, model.RoleAdmin); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	member, err := h.groupService.AddMemberToGroupByEmail(req.GroupID, req.Email, req.Role, CurrentUser(c).ID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -152,9 +200,17 @@ func (h *GroupHandler) RemoveGroupMember(c *gin.Context) {
 		return
 	}
 
-	err = h.groupService.RemoveMemberFromGroup(groupID, userID)
+	// Members may leave a group; removing anyone else takes an admin
+	if userID != CurrentUser(c).ID {
+		if _, err := h.authz.RequireRole(groupID, CurrentUser(c).ID, model.RoleAdmin); err != nil {
+			respondAuthzError(c, err)
+			return
+		}
+	}
+
+	err = h.groupService.RemoveMemberFromGroup(groupID, userID, CurrentUser(c).ID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -168,6 +224,11 @@ func (h *GroupHandler) GetGroupMembers(c *gin.Context) {
 		return
 	}
 
+	if err := h.authz.RequireMember(groupID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
 	members, err := h.groupService.GetGroupMembers(groupID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -176,3 +237,38 @@ func (h *GroupHandler) GetGroupMembers(c *gin.Context) {
 
 	c.JSON(http.StatusOK, members)
 }
+
+// UpdateGroupMemberRole changes a member's role; only the group owner can do this
+// PUT /api/members/:group_id/:user_id/role
+func (h *GroupHandler) UpdateGroupMemberRole(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if _, err := h.authz.RequireRole(groupID, CurrentUser(c).ID, model.RoleOwner); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	var req model.GroupMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	member, err := h.groupService.UpdateMemberRole(groupID, userID, req.Role, CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
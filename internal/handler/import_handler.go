@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/service"
+)
+
+// maxImportBytes caps the size of an uploaded import file
+const maxImportBytes = 10 << 20
+
+type ImportHandler struct {
+	importService *service.ImportService
+	authz         *service.AuthorizationService
+}
+
+func NewImportHandler(importService *service.ImportService, authz *service.AuthorizationService) *ImportHandler {
+	return &ImportHandler{importService: importService, authz: authz}
+}
+
+// ImportExpenses records expenses in bulk from the request body, a CSV or JSON
+// lines file. With dry_run=true every row is validated and nothing is stored.
+// Requires the admin role.
+func (h *ImportHandler) ImportExpenses(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	if _, err := h.authz.RequireRole(groupID, CurrentUser(c).ID, model.RoleAdmin); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	report, err := h.importService.ImportExpenses(groupID, importFormat(c), body, dryRun, CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch {
+	case report.Applied:
+		c.JSON(http.StatusCreated, report)
+	case report.Invalid > 0 && !dryRun:
+		c.JSON(http.StatusUnprocessableEntity, report)
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// importFormat reads the file format from the format query parameter, falling
+// back to the content type of the request
+func importFormat(c *gin.Context) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+	if strings.Contains(c.ContentType(), "csv") {
+		return model.ImportFormatCSV
+	}
+	return model.ImportFormatJSONL
+}
+
+// ImportSplitwise recreates the expenses and payments of a Splitwise group
+// export sent as the request body. Person columns are mapped to members with
+// members[<column>]=<email> parameters, or else by name; with
+// placeholders=false a column matching no member is an error instead of a new
+// placeholder member. Requires the admin role.
+func (h *ImportHandler) ImportSplitwise(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	if _, err := h.authz.RequireRole(groupID, CurrentUser(c).ID, model.RoleAdmin); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	options := &model.SplitwiseImportOptions{Members: c.QueryMap("members")}
+	if options.DryRun, err = strconv.ParseBool(c.DefaultQuery("dry_run", "false")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+		return
+	}
+	if options.Placeholders, err = strconv.ParseBool(c.DefaultQuery("placeholders", "true")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "placeholders must be true or false"})
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	report, err := h.importService.ImportSplitwise(groupID, body, options, CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch {
+	case report.Applied:
+		c.JSON(http.StatusCreated, report)
+	case report.Invalid > 0 && !options.DryRun:
+		c.JSON(http.StatusUnprocessableEntity, report)
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
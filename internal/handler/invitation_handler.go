@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/service"
+)
+
+type InvitationHandler struct {
+	invitationService *service.InvitationService
+	authz             *service.AuthorizationService
+}
+
+func NewInvitationHandler(invitationService *service.InvitationService, authz *service.AuthorizationService) *InvitationHandler {
+	return &InvitationHandler{invitationService: invitationService, authz: authz}
+}
+
+// CreateInvitation emails an invitation to join a group. Requires the admin
+// role.
+func (h *InvitationHandler) CreateInvitation(c *gin.Context) {
+	var req model.InvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if _, err := h.authz.RequireRole(req.GroupID, CurrentUser(c).ID, model.RoleAdmin); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	invitation, err := h.invitationService.CreateInvitation(&req, CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// GetGroupInvitations lists the invitations of a group. Requires the admin
+// role.
+func (h *InvitationHandler) GetGroupInvitations(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	if _, err := h.authz.RequireRole(groupID, CurrentUser(c).ID, model.RoleAdmin); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	invitations, err := h.invitationService.GetGroupInvitations(groupID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// RevokeInvitation withdraws a pending invitation. Requires the admin role.
+func (h *InvitationHandler) RevokeInvitation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation id"})
+		return
+	}
+
+	invitation, err := h.invitationService.GetInvitation(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.authz.RequireRole(invitation.GroupID, CurrentUser(c).ID, model.RoleAdmin); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	if err := h.invitationService.RevokeInvitation(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AcceptInvitation adds the authenticated user to the group of the invitation
+// whose token is in the URL
+func (h *InvitationHandler) AcceptInvitation(c *gin.Context) {
+	member, err := h.invitationService.AcceptInvitation(c.Param("token"), CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// DeclineInvitation turns down the invitation whose token is in the URL. It
+// needs no account.
+func (h *InvitationHandler) DeclineInvitation(c *gin.Context) {
+	if err := h.invitationService.DeclineInvitation(c.Param("token")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+// parseListQuery reads the query parameters shared by list endpoints:
+//
+//	cursor          next_cursor of the previous page
+//	limit           page size, up to model.MaxListLimit
+//	from, to        creation date range; a date or an RFC 3339 time, a "to" date includes that day
+//	min_amount, max_amount
+//	payer_id, participant_id
+//	description     case-insensitive substring
+//	category_id     a category and its subcategories (expenses only)
+//	sort, order     one of sortFields, and asc or desc (newest first by default)
+func parseListQuery(c *gin.Context, sortFields []string) (*model.ListQuery, error) {
+	query := &model.ListQuery{
+		Limit:     model.DefaultListLimit,
+		SortField: model.SortByCreatedAt,
+		SortOrder: model.SortDesc,
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		if !containsString(sortFields, sort) {
+			return nil, fmt.Errorf("cannot sort by %q", sort)
+		}
+		query.SortField = sort
+		if sort != model.SortByCreatedAt {
+			query.SortOrder = model.SortAsc
+		}
+	}
+	if order := c.Query("order"); order != "" {
+		if order != model.SortAsc && order != model.SortDesc {
+			return nil, fmt.Errorf("order must be %s or %s", model.SortAsc, model.SortDesc)
+		}
+		query.SortOrder = order
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		after, err := model.DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if after.SortField != query.SortField || after.SortOrder != query.SortOrder {
+			return nil, fmt.Errorf("cursor was issued for a different sort")
+		}
+		query.After = after
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > model.MaxListLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", model.MaxListLimit)
+		}
+		query.Limit = n
+	}
+
+	var err error
+	if query.From, err = parseTimeParam(c, "from", false); err != nil {
+		return nil, err
+	}
+	if query.To, err = parseTimeParam(c, "to", true); err != nil {
+		return nil, err
+	}
+
+	if query.MinAmount, err = parseAmountParam(c, "min_amount"); err != nil {
+		return nil, err
+	}
+	if query.MaxAmount, err = parseAmountParam(c, "max_amount"); err != nil {
+		return nil, err
+	}
+
+	if query.PayerID, err = parseIDParam(c, "payer_id"); err != nil {
+		return nil, err
+	}
+	if query.ParticipantID, err = parseIDParam(c, "participant_id"); err != nil {
+		return nil, err
+	}
+
+	if query.CategoryID, err = parseIDParam(c, "category_id"); err != nil {
+		return nil, err
+	}
+
+	query.Description = c.Query("description")
+
+	return query, nil
+}
+
+// parseTimeParam parses a date or RFC 3339 time. With endOfDay a date means the
+// end of that day, so that a range ending on it includes the whole day.
+func parseTimeParam(c *gin.Context, name string, endOfDay bool) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		if endOfDay {
+			date = date.AddDate(0, 0, 1)
+		}
+		return &date, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a date (YYYY-MM-DD) or an RFC 3339 time", name)
+	}
+	return &t, nil
+}
+
+func parseAmountParam(c *gin.Context, name string) (*money.Amount, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	amount, err := money.ParseAmount(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", name, err)
+	}
+	return &amount, nil
+}
+
+func parseIDParam(c *gin.Context, name string) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+	return id, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/service"
+)
+
+type PlaceholderHandler struct {
+	placeholderService *service.PlaceholderService
+	authz              *service.AuthorizationService
+}
+
+func NewPlaceholderHandler(placeholderService *service.PlaceholderService, authz *service.AuthorizationService) *PlaceholderHandler {
+	return &PlaceholderHandler{placeholderService: placeholderService, authz: authz}
+}
+
+// AddPlaceholderMember adds a member who has no account yet. Requires the admin
+// role.
+func (h *PlaceholderHandler) AddPlaceholderMember(c *gin.Context) {
+	var req model.PlaceholderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if _, err := h.authz.RequireRole(req.GroupID, CurrentUser(c).ID, model.RoleAdmin); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	member, err := h.placeholderService.AddPlaceholderMember(&req, CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// ClaimPlaceholder merges a placeholder into the authenticated user
+func (h *PlaceholderHandler) ClaimPlaceholder(c *gin.Context) {
+	placeholderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	placeholder, err := h.placeholderService.GetPlaceholder(placeholderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authz.CanClaimPlaceholder(placeholder, CurrentUser(c)); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	user, err := h.placeholderService.ClaimPlaceholder(placeholderID, CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
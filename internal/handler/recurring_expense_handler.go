@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/service"
+)
+
+type RecurringExpenseHandler struct {
+	recurringService *service.RecurringExpenseService
+	authz            *service.AuthorizationService
+}
+
+func NewRecurringExpenseHandler(recurringService *service.RecurringExpenseService, authz *service.AuthorizationService) *RecurringExpenseHandler {
+	return &RecurringExpenseHandler{recurringService: recurringService, authz: authz}
+}
+
+func (h *RecurringExpenseHandler) CreateRecurringExpense(c *gin.Context) {
+	var req model.RecurringExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.GroupID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if _, err := h.authz.RequireRole(req.GroupID, CurrentUser(c).ID, model.RoleMember); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	recurring, err := h.recurringService.CreateRecurringExpense(&req, CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, recurring)
+}
+
+func (h *RecurringExpenseHandler) GetRecurringExpense(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid recurring expense id"})
+		return
+	}
+
+	recurring, err := h.recurringService.GetRecurringExpense(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authz.RequireMember(recurring.GroupID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recurring)
+}
+
+func (h *RecurringExpenseHandler) GetGroupRecurringExpenses(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	if err := h.authz.RequireMember(groupID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	recurring, err := h.recurringService.GetGroupRecurringExpenses(groupID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, recurring)
+}
+
+// UpdateRecurringExpense replaces the template of future occurrences.
+// Requires being its creator or a group admin.
+func (h *RecurringExpenseHandler) UpdateRecurringExpense(c *gin.Context) {
+	id, ok := h.editableID(c)
+	if !ok {
+		return
+	}
+
+	var req model.RecurringExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	recurring, err := h.recurringService.UpdateRecurringExpense(id, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, recurring)
+}
+
+// DeleteRecurringExpense stops a recurring expense for good. The expenses it
+// already created are kept.
+func (h *RecurringExpenseHandler) DeleteRecurringExpense(c *gin.Context) {
+	id, ok := h.editableID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.recurringService.DeleteRecurringExpense(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *RecurringExpenseHandler) PauseRecurringExpense(c *gin.Context) {
+	h.changeSchedule(c, h.recurringService.PauseRecurringExpense)
+}
+
+func (h *RecurringExpenseHandler) ResumeRecurringExpense(c *gin.Context) {
+	h.changeSchedule(c, h.recurringService.ResumeRecurringExpense)
+}
+
+// SkipRecurringExpense skips the next occurrence
+func (h *RecurringExpenseHandler) SkipRecurringExpense(c *gin.Context) {
+	h.changeSchedule(c, h.recurringService.SkipRecurringExpense)
+}
+
+// changeSchedule applies change to the recurring expense in the URL and
+// responds with the result
+func (h *RecurringExpenseHandler) changeSchedule(c *gin.Context, change func(id int) (*model.RecurringExpense, error)) {
+	id, ok := h.editableID(c)
+	if !ok {
+		return
+	}
+
+	recurring, err := change(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, recurring)
+}
+
+// editableID returns the recurring expense ID in the URL once the user is
+// allowed to change it. Otherwise it responds with the error and ok is false.
+func (h *RecurringExpenseHandler) editableID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid recurring expense id"})
+		return 0, false
+	}
+
+	recurring, err := h.recurringService.GetRecurringExpense(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return 0, false
+	}
+
+	if err := h.authz.CanEditRecurringExpense(recurring, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return 0, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/service"
+)
+
+type ReportHandler struct {
+	reportService *service.ReportService
+	authz         *service.AuthorizationService
+}
+
+func NewReportHandler(reportService *service.ReportService, authz *service.AuthorizationService) *ReportHandler {
+	return &ReportHandler{reportService: reportService, authz: authz}
+}
+
+// GetGroupReport returns the spending report of a group
+func (h *ReportHandler) GetGroupReport(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	if err := h.authz.RequireMember(groupID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	query, err := parseReportQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.reportService.GetGroupReport(groupID, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetUserReport returns the spending report of the authenticated user across
+// their groups
+func (h *ReportHandler) GetUserReport(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if !requireSelf(c, userID) {
+		return
+	}
+
+	query, err := parseReportQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.reportService.GetUserReport(userID, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// parseReportQuery reads the query parameters of a report:
+//
+//	from, to  date range, like list endpoints
+//	bucket    day, week or month (the default)
+func parseReportQuery(c *gin.Context) (*model.ReportQuery, error) {
+	query := &model.ReportQuery{Bucket: model.ReportBucketMonth}
+
+	if bucket := c.Query("bucket"); bucket != "" {
+		if !containsString(model.ReportBuckets, bucket) {
+			return nil, fmt.Errorf("bucket must be %s, %s or %s", model.ReportBucketDay, model.ReportBucketWeek, model.ReportBucketMonth)
+		}
+		query.Bucket = bucket
+	}
+
+	var err error
+	if query.From, err = parseTimeParam(c, "from", false); err != nil {
+		return nil, err
+	}
+	if query.To, err = parseTimeParam(c, "to", true); err != nil {
+		return nil, err
+	}
+
+	return query, nil
+}
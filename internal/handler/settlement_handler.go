@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -11,11 +12,13 @@ import (
 
 type SettlementHandler struct {
 	settlementService *service.SettlementService
+	authz             *service.AuthorizationService
 }
 
-func NewSettlementHandler(settlementService *service.SettlementService) *SettlementHandler {
+func NewSettlementHandler(settlementService *service.SettlementService, authz *service.AuthorizationService) *SettlementHandler {
 	return &SettlementHandler{
 		settlementService: settlementService,
+		authz:             authz,
 	}
 }
 
@@ -28,7 +31,12 @@ func (h *SettlementHandler) CreateSettlement(c *gin.Context) {
 		return
 	}
 
-	settlement, err := h.settlementService.CreateSettlement(&req)
+	if err := h.authz.CanRecordSettlement(&req, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	settlement, err := h.settlementService.CreateSettlement(&req, CurrentUser(c).ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -37,6 +45,31 @@ func (h *SettlementHandler) CreateSettlement(c *gin.Context) {
 	c.JSON(http.StatusCreated, settlement)
 }
 
+// CreateSettlementBatch records several settlements at once, e.g. a settle plan
+// POST /api/settle/batch
+func (h *SettlementHandler) CreateSettlementBatch(c *gin.Context) {
+	var req model.SettlementBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i := range req.Settlements {
+		if err := h.authz.CanRecordSettlement(&req.Settlements[i], CurrentUser(c).ID); err != nil {
+			respondAuthzError(c, err)
+			return
+		}
+	}
+
+	settlements, err := h.settlementService.CreateSettlements(req.Settlements, CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"settlements": settlements})
+}
+
 // GetSettlementByID retrieves a settlement by ID
 // GET /api/settle/:id
 func (h *SettlementHandler) GetSettlementByID(c *gin.Context) {
@@ -46,6 +79,11 @@ func (h *SettlementHandler) GetSettlementByID(c *gin.Context) {
 		return
 	}
 
+	if err := h.authz.CanReadSettlement(id, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
 	settlement, err := h.settlementService.GetSettlementByID(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -55,6 +93,51 @@ func (h *SettlementHandler) GetSettlementByID(c *gin.Context) {
 	c.JSON(http.StatusOK, settlement)
 }
 
+// DeleteSettlement soft-deletes a settlement and reverses it in the balances
+// DELETE /api/settle/:id
+func (h *SettlementHandler) DeleteSettlement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid settlement id"})
+		return
+	}
+
+	if err := h.authz.CanDeleteSettlement(id, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	if err := h.settlementService.DeleteSettlement(id, CurrentUser(c).ID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreSettlement brings back a soft-deleted settlement
+// POST /api/settle/:id/restore
+func (h *SettlementHandler) RestoreSettlement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid settlement id"})
+		return
+	}
+
+	if err := h.authz.CanRestoreSettlement(id, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	settlement, err := h.settlementService.RestoreSettlement(id, CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settlement)
+}
+
 // GetGroupSettlements retrieves all settlements in a group
 // GET /api/settle/group/:group_id
 func (h *SettlementHandler) GetGroupSettlements(c *gin.Context) {
@@ -64,7 +147,18 @@ func (h *SettlementHandler) GetGroupSettlements(c *gin.Context) {
 		return
 	}
 
-	settlements, err := h.settlementService.GetSettlementsByGroupID(groupID)
+	if err := h.authz.RequireMember(groupID, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
+	query, err := parseSettlementListQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settlements, err := h.settlementService.GetSettlementsByGroupID(groupID, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -82,23 +176,52 @@ func (h *SettlementHandler) GetUserSettlements(c *gin.Context) {
 		return
 	}
 
-	settlements, err := h.settlementService.GetSettlementsByUserID(userID)
+	if !requireSelf(c, userID) {
+		return
+	}
+
+	query, err := parseSettlementListQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settlements, next, err := h.settlementService.GetSettlementsByUserID(userID, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"settlements": settlements})
+	c.JSON(http.StatusOK, gin.H{"settlements": settlements, "next_cursor": next})
 }
 
-// GetAllSettlements retrieves all settlements
+// GetAllSettlements retrieves every settlement the authenticated user paid or received
 // GET /api/settle
 func (h *SettlementHandler) GetAllSettlements(c *gin.Context) {
-	settlements, err := h.settlementService.GetAllSettlements()
+	query, err := parseSettlementListQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settlements, next, err := h.settlementService.GetSettlementsByUserID(CurrentUser(c).ID, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"settlements": settlements})
+	c.JSON(http.StatusOK, gin.H{"settlements": settlements, "next_cursor": next})
+}
+
+// parseSettlementListQuery parses the query of a settlement list, which takes
+// every list filter but category_id
+func parseSettlementListQuery(c *gin.Context) (*model.ListQuery, error) {
+	query, err := parseListQuery(c, model.SettlementSortFields)
+	if err != nil {
+		return nil, err
+	}
+	if query.CategoryID != 0 {
+		return nil, fmt.Errorf("settlements have no category")
+	}
+	return query, nil
 }
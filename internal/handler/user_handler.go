@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -11,10 +12,11 @@ import (
 
 type UserHandler struct {
 	userService *service.UserService
+	authz       *service.AuthorizationService
 }
 
-func NewUserHandler(userService *service.UserService) *UserHandler {
-	return &UserHandler{userService: userService}
+func NewUserHandler(userService *service.UserService, authz *service.AuthorizationService) *UserHandler {
+	return &UserHandler{userService: userService, authz: authz}
 }
 
 func (h *UserHandler) Register(c *gin.Context) {
@@ -25,7 +27,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.RegisterUser(req.Email, req.Name)
+	user, err := h.userService.RegisterUser(req.Email, req.Name, req.Password, req.InvitationToken)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -34,40 +36,14 @@ func (h *UserHandler) Register(c *gin.Context) {
 	c.JSON(http.StatusCreated, user)
 }
 
-// Login godoc
-// @Summary Login user
-// @Description Login with email
-// @Tags users
-// @Accept json
-// @Produce json
-// @Param email query string true "User email"
-// @Success 200 {object} model.UserResponse
-// @Failure 404 {object} map[string]string
-// @Router /api/users/login [get]
-func (h *UserHandler) Login(c *gin.Context) {
-	email := c.Query("email")
-
-	if email == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
-		return
-	}
-
-	user, err := h.userService.LoginUser(email)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, user)
-}
-
 // GetUser godoc
 // @Summary Get user by ID
-// @Description Retrieve user information
+// @Description Retrieve yourself or a user who shares a group with you
 // @Tags users
 // @Produce json
 // @Param id path int true "User ID"
 // @Success 200 {object} model.UserResponse
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /api/users/{id} [get]
 func (h *UserHandler) GetUser(c *gin.Context) {
@@ -77,6 +53,11 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		return
 	}
 
+	if err := h.authz.CanSeeUser(id, CurrentUser(c).ID); err != nil {
+		respondAuthzError(c, err)
+		return
+	}
+
 	user, err := h.userService.GetUserByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -88,19 +69,32 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 
 // GetAllUsers godoc
 // @Summary Get all users
-// @Description Retrieve all users in the system
+// @Description Retrieve yourself and the users who share a group with you
 // @Tags users
 // @Produce json
-// @Success 200 {array} model.UserResponse
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "Page size"
+// @Param sort query string false "created_at, name or email"
+// @Param order query string false "asc or desc"
+// @Success 200 {object} map[string]interface{}
 // @Router /api/users [get]
 func (h *UserHandler) GetAllUsers(c *gin.Context) {
-	users, err := h.userService.GetAllUsers()
+	query, err := parseListQuery(c, model.UserSortFields)
+	if err == nil && query.HasLedgerFilters() {
+		err = fmt.Errorf("users can only be filtered by date")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, next, err := h.userService.GetVisibleUsers(CurrentUser(c).ID, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	c.JSON(http.StatusOK, gin.H{"users": users, "next_cursor": next})
 }
 
 // UpdateUser godoc
@@ -121,6 +115,11 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	if id != CurrentUser(c).ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "cannot change another user"})
+		return
+	}
+
 	var req map[string]string
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
@@ -157,6 +156,11 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
+	if id != CurrentUser(c).ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "cannot change another user"})
+		return
+	}
+
 	err = h.userService.DeleteUser(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -0,0 +1,14 @@
+// Package mailer sends the emails of the app, like group invitations. The SMTP
+// mailer delivers them; the log mailer only records them, for development.
+package mailer
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(msg *Message) error
+}
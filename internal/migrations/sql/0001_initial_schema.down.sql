@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS settlements;
+DROP TABLE IF EXISTS balances;
+DROP TABLE IF EXISTS expense_splits;
+DROP TABLE IF EXISTS expenses;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,4 @@
+ALTER TABLE expenses ALTER COLUMN amount TYPE DECIMAL(10, 2) USING amount / 100.0;
+ALTER TABLE expense_splits ALTER COLUMN amount TYPE DECIMAL(10, 2) USING amount / 100.0;
+ALTER TABLE balances ALTER COLUMN amount TYPE DECIMAL(10, 2) USING amount / 100.0;
+ALTER TABLE settlements ALTER COLUMN amount TYPE DECIMAL(10, 2) USING amount / 100.0;
@@ -0,0 +1,12 @@
+-- Store amounts as integer cents instead of DECIMAL(10, 2)
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY['expenses', 'expense_splits', 'balances', 'settlements'] LOOP
+        IF (SELECT data_type FROM information_schema.columns
+            WHERE table_name = t AND column_name = 'amount') = 'numeric' THEN
+            EXECUTE format('ALTER TABLE %I ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)', t);
+        END IF;
+    END LOOP;
+END $$;
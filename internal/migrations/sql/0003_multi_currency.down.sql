@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE settlements DROP COLUMN IF EXISTS base_amount;
+ALTER TABLE settlements DROP COLUMN IF EXISTS fx_rate;
+ALTER TABLE settlements DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE expense_splits DROP COLUMN IF EXISTS base_amount;
+
+ALTER TABLE expenses DROP COLUMN IF EXISTS base_amount;
+ALTER TABLE expenses DROP COLUMN IF EXISTS fx_rate;
+ALTER TABLE expenses DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE groups DROP COLUMN IF EXISTS currency;
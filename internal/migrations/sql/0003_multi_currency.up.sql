@@ -0,0 +1,29 @@
+-- Groups keep balances in their own currency; expenses and settlements record
+-- the currency they were paid in, the rate used and the converted amount.
+-- Existing rows were all recorded in the group currency.
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS fx_rate NUMERIC(20, 10) NOT NULL DEFAULT 1;
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS base_amount BIGINT;
+UPDATE expenses SET base_amount = amount WHERE base_amount IS NULL;
+ALTER TABLE expenses ALTER COLUMN base_amount SET NOT NULL;
+
+ALTER TABLE expense_splits ADD COLUMN IF NOT EXISTS base_amount BIGINT;
+UPDATE expense_splits SET base_amount = amount WHERE base_amount IS NULL;
+ALTER TABLE expense_splits ALTER COLUMN base_amount SET NOT NULL;
+
+ALTER TABLE settlements ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE settlements ADD COLUMN IF NOT EXISTS fx_rate NUMERIC(20, 10) NOT NULL DEFAULT 1;
+ALTER TABLE settlements ADD COLUMN IF NOT EXISTS base_amount BIGINT;
+UPDATE settlements SET base_amount = amount WHERE base_amount IS NULL;
+ALTER TABLE settlements ALTER COLUMN base_amount SET NOT NULL;
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    base_currency VARCHAR(3) NOT NULL,
+    quote_currency VARCHAR(3) NOT NULL,
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    effective_date DATE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (base_currency, quote_currency, effective_date)
+);
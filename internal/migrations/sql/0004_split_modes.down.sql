@@ -0,0 +1,2 @@
+ALTER TABLE expense_splits DROP COLUMN IF EXISTS weight;
+ALTER TABLE expenses DROP COLUMN IF EXISTS split_mode;
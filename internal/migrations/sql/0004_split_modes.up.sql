@@ -0,0 +1,3 @@
+-- Splits created before split modes were equal splits
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS split_mode VARCHAR(20) NOT NULL DEFAULT 'equal';
+ALTER TABLE expense_splits ADD COLUMN IF NOT EXISTS weight BIGINT NOT NULL DEFAULT 1;
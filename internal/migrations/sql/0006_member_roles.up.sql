@@ -0,0 +1,11 @@
+-- Group creators own their groups, everyone else is a member
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'group_members' AND column_name = 'role') THEN
+        ALTER TABLE group_members ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'member';
+        UPDATE group_members gm SET role = 'owner'
+        FROM groups g
+        WHERE g.id = gm.group_id AND g.creator_id = gm.user_id;
+    END IF;
+END $$;
@@ -0,0 +1,3 @@
+ALTER TABLE balances DROP CONSTRAINT IF EXISTS balances_group_pair_key;
+ALTER TABLE balances DROP CONSTRAINT IF EXISTS balances_pair_order;
+DELETE FROM balances;
@@ -0,0 +1,28 @@
+-- balances keeps one row per pair of users in a group. amount is what
+-- from_user_id owes to_user_id in the group currency, negative when the debt
+-- runs the other way; from_user_id is always the lower ID so each pair has a
+-- single row. The table was never written before, so it is rebuilt from the
+-- ledger here.
+DELETE FROM balances;
+
+ALTER TABLE balances DROP CONSTRAINT IF EXISTS balances_pair_order;
+ALTER TABLE balances ADD CONSTRAINT balances_pair_order CHECK (from_user_id < to_user_id);
+
+ALTER TABLE balances DROP CONSTRAINT IF EXISTS balances_group_pair_key;
+ALTER TABLE balances ADD CONSTRAINT balances_group_pair_key UNIQUE (group_id, from_user_id, to_user_id);
+
+INSERT INTO balances (group_id, from_user_id, to_user_id, amount)
+SELECT group_id,
+    LEAST(from_user_id, to_user_id),
+    GREATEST(from_user_id, to_user_id),
+    SUM(CASE WHEN from_user_id < to_user_id THEN amount ELSE -amount END)
+FROM (
+    SELECT e.group_id, es.user_id AS from_user_id, e.paid_by_id AS to_user_id, es.base_amount AS amount
+    FROM expense_splits es
+    JOIN expenses e ON e.id = es.expense_id
+    UNION ALL
+    SELECT s.group_id, s.to_user_id, s.from_user_id, s.base_amount
+    FROM settlements s
+) debts
+WHERE from_user_id <> to_user_id
+GROUP BY group_id, LEAST(from_user_id, to_user_id), GREATEST(from_user_id, to_user_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_users_created;
+DROP INDEX IF EXISTS idx_settlements_to_user_id;
+DROP INDEX IF EXISTS idx_settlements_from_user_id;
+DROP INDEX IF EXISTS idx_settlements_group_created;
+DROP INDEX IF EXISTS idx_expenses_paid_by_created;
+DROP INDEX IF EXISTS idx_expenses_group_created;
@@ -0,0 +1,7 @@
+-- List endpoints page through rows newest first, with the ID breaking ties
+CREATE INDEX IF NOT EXISTS idx_expenses_group_created ON expenses(group_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_expenses_paid_by_created ON expenses(paid_by_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_settlements_group_created ON settlements(group_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_settlements_from_user_id ON settlements(from_user_id);
+CREATE INDEX IF NOT EXISTS idx_settlements_to_user_id ON settlements(to_user_id);
+CREATE INDEX IF NOT EXISTS idx_users_created ON users(created_at, id);
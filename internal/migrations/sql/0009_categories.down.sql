@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_expenses_category_id;
+ALTER TABLE expenses DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS categories;
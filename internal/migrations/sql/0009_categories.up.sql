@@ -0,0 +1,72 @@
+-- Global categories have no group_id and are shared by every group; groups
+-- add their own. Deleting a category leaves its expenses uncategorized.
+CREATE TABLE IF NOT EXISTS categories (
+    id SERIAL PRIMARY KEY,
+    group_id INTEGER REFERENCES groups(id) ON DELETE CASCADE,
+    parent_id INTEGER REFERENCES categories(id),
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_sibling_name
+    ON categories (COALESCE(group_id, 0), COALESCE(parent_id, 0), LOWER(name));
+CREATE INDEX IF NOT EXISTS idx_categories_group_id ON categories(group_id);
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
+
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_expenses_category_id ON expenses(category_id);
+
+-- The default global tree, as in model.DefaultCategories
+INSERT INTO categories (name)
+SELECT name FROM (VALUES
+    ('Food & Drink'),
+    ('Travel'),
+    ('Home'),
+    ('Utilities'),
+    ('Entertainment'),
+    ('Shopping'),
+    ('Health'),
+    ('Other')
+) AS defaults(name)
+WHERE NOT EXISTS (
+    SELECT 1 FROM categories c
+    WHERE c.group_id IS NULL AND c.parent_id IS NULL AND LOWER(c.name) = LOWER(defaults.name)
+);
+
+INSERT INTO categories (parent_id, name)
+SELECT p.id, defaults.name FROM (VALUES
+    ('Food & Drink', 'Groceries'),
+    ('Food & Drink', 'Restaurants'),
+    ('Food & Drink', 'Coffee'),
+    ('Food & Drink', 'Alcohol'),
+    ('Travel', 'Flights'),
+    ('Travel', 'Lodging'),
+    ('Travel', 'Car Rental'),
+    ('Travel', 'Public Transport'),
+    ('Travel', 'Taxi'),
+    ('Home', 'Rent'),
+    ('Home', 'Furniture'),
+    ('Home', 'Maintenance'),
+    ('Home', 'Household Supplies'),
+    ('Utilities', 'Electricity'),
+    ('Utilities', 'Water'),
+    ('Utilities', 'Gas'),
+    ('Utilities', 'Internet'),
+    ('Utilities', 'Phone'),
+    ('Entertainment', 'Movies'),
+    ('Entertainment', 'Games'),
+    ('Entertainment', 'Music'),
+    ('Entertainment', 'Sports'),
+    ('Shopping', 'Clothing'),
+    ('Shopping', 'Electronics'),
+    ('Shopping', 'Gifts'),
+    ('Health', 'Medical'),
+    ('Health', 'Pharmacy'),
+    ('Health', 'Fitness')
+) AS defaults(parent_name, name)
+JOIN categories p ON p.group_id IS NULL AND p.parent_id IS NULL AND p.name = defaults.parent_name
+WHERE NOT EXISTS (
+    SELECT 1 FROM categories c
+    WHERE c.group_id IS NULL AND c.parent_id = p.id AND LOWER(c.name) = LOWER(defaults.name)
+);
@@ -0,0 +1,4 @@
+-- Placeholder users stand for people without an account. They can pay and
+-- share expenses but cannot log in, until they register or are merged into a
+-- registered user.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS placeholder BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,29 @@
+-- Templates the scheduler creates expenses from. next_run_at is the next
+-- occurrence to create, NULL once the template has ended; the scheduler locks
+-- a template's row while it creates an occurrence, so that each one is
+-- created once even when several servers run.
+CREATE TABLE IF NOT EXISTS recurring_expenses (
+    id SERIAL PRIMARY KEY,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    created_by_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    paid_by_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+    description TEXT,
+    split JSONB,
+    frequency VARCHAR(20) NOT NULL,
+    interval_count INTEGER NOT NULL DEFAULT 1,
+    cron VARCHAR(100) NOT NULL DEFAULT '',
+    start_date TIMESTAMP NOT NULL,
+    end_date TIMESTAMP,
+    next_run_at TIMESTAMP,
+    last_run_at TIMESTAMP,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_expenses_group_id ON recurring_expenses(group_id);
+CREATE INDEX IF NOT EXISTS idx_recurring_expenses_next_run_at ON recurring_expenses(next_run_at) WHERE NOT paused;
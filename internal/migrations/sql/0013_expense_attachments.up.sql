@@ -0,0 +1,16 @@
+-- Files attached to expenses, such as receipts. The content is kept in blob
+-- storage; rows only hold where. Attachments outlive the account of whoever
+-- uploaded them.
+CREATE TABLE IF NOT EXISTS expense_attachments (
+    id SERIAL PRIMARY KEY,
+    expense_id INTEGER NOT NULL REFERENCES expenses(id) ON DELETE CASCADE,
+    uploaded_by_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    storage_key VARCHAR(255) UNIQUE NOT NULL,
+    thumbnail_key VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_expense_attachments_expense_id ON expense_attachments(expense_id);
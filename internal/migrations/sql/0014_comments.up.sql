@@ -0,0 +1,16 @@
+-- Discussion threads on expenses and settlements. Each comment belongs to
+-- exactly one of them and goes when it is deleted; comments stay when their
+-- author's account is deleted.
+CREATE TABLE IF NOT EXISTS comments (
+    id SERIAL PRIMARY KEY,
+    expense_id INTEGER REFERENCES expenses(id) ON DELETE CASCADE,
+    settlement_id INTEGER REFERENCES settlements(id) ON DELETE CASCADE,
+    author_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((expense_id IS NULL) <> (settlement_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_expense_id ON comments(expense_id);
+CREATE INDEX IF NOT EXISTS idx_comments_settlement_id ON comments(settlement_id);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_settlements_deleted_at;
+DROP INDEX IF EXISTS idx_expenses_deleted_at;
+DROP INDEX IF EXISTS idx_groups_deleted_at;
+-- Rows that were only soft deleted would otherwise come back
+DELETE FROM settlements WHERE deleted_at IS NOT NULL;
+DELETE FROM expenses WHERE deleted_at IS NOT NULL;
+DELETE FROM groups WHERE deleted_at IS NOT NULL;
+ALTER TABLE settlements DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE expenses DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE groups DROP COLUMN IF EXISTS deleted_at;
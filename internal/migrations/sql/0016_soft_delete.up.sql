@@ -0,0 +1,11 @@
+-- Deleted expenses, settlements and groups are kept with the time they were
+-- deleted, so they can be restored until the retention job purges them.
+-- Deleting a group stamps its live expenses and settlements with the same
+-- time, which is how restoring the group finds them again.
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE settlements ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_groups_deleted_at ON groups(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_expenses_deleted_at ON expenses(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_settlements_deleted_at ON settlements(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,7 @@
+-- Emails are stored in lower case and unique whatever their case, so that
+-- "Bob@example.com" cannot register beside "bob@example.com". Accounts whose
+-- emails differ only in case make the index fail and must be merged first.
+UPDATE users SET email = lower(btrim(email)) WHERE email <> lower(btrim(email));
+UPDATE group_invitations SET email = lower(btrim(email)) WHERE email <> lower(btrim(email));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users(lower(email));
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Actions recorded in the activity log
+const (
+	ActivityCreated  = "created"
+	ActivityUpdated  = "updated"
+	ActivityDeleted  = "deleted"
+	ActivityRestored = "restored"
+)
+
+// Kinds of entity an activity is about. The entity ID of a member is their
+// user ID.
+const (
+	EntityGroup      = "group"
+	EntityMember     = "member"
+	EntityExpense    = "expense"
+	EntitySettlement = "settlement"
+)
+
+// Activity is an entry of a group's append-only activity log: who did what to
+// which entity. Changes maps each field that changed to its value before and
+// after, so a creation only has after values and a deletion only before
+// values. ActorID is nil for changes the server makes itself, such as
+// recurring expenses, and once the actor's account is deleted.
+type Activity struct {
+	ID         int             `json:"id"`
+	GroupID    int             `json:"group_id"`
+	ActorID    *int            `json:"actor_id"`
+	ActorName  string          `json:"actor_name"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   int             `json:"entity_id"`
+	Changes    json.RawMessage `json:"changes"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// FieldChange is the value of a field before and after a change, as JSON.
+// Before is left out for new entities and After for deleted ones.
+type FieldChange struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// ActivitySortValue returns the value of the field an activity list is sorted by
+func ActivitySortValue(activity *Activity, field string) string {
+	return TimeSortValue(activity.CreatedAt)
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// AttachmentContentTypes are the kinds of files that can be attached to an
+// expense, as sniffed from their content
+var AttachmentContentTypes = []string{
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/webp",
+	"application/pdf",
+}
+
+// Attachment is a file, such as a photo of a receipt, attached to an expense.
+// Its content is kept in blob storage under StorageKey; images also get a JPEG
+// thumbnail under ThumbnailKey.
+type Attachment struct {
+	ID           int       `json:"id"`
+	ExpenseID    int       `json:"expense_id"`
+	UploadedByID *int      `json:"uploaded_by_id"`
+	FileName     string    `json:"file_name"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	StorageKey   string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type AttachmentResponse struct {
+	ID           int       `json:"id"`
+	ExpenseID    int       `json:"expense_id"`
+	UploadedByID *int      `json:"uploaded_by_id"`
+	FileName     string    `json:"file_name"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	HasThumbnail bool      `json:"has_thumbnail"`
+	CreatedAt    time.Time `json:"created_at"`
+}
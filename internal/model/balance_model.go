@@ -1,10 +1,12 @@
 package model
 
+import "github.com/shreyansh/expense-go-collab-backend/internal/money"
+
 type Balance struct {
-	ID      int     `json:"id"`
-	UserID  int     `json:"user_id"`
-	GroupID int     `json:"group_id"`
-	Amount  float64 `json:"amount"`
+	ID      int          `json:"id"`
+	UserID  int          `json:"user_id"`
+	GroupID int          `json:"group_id"`
+	Amount  money.Amount `json:"amount"`
 }
 
 type BalanceRequest struct {
@@ -13,20 +15,52 @@ type BalanceRequest struct {
 }
 
 type BalanceResponse struct {
-	UserID  int     `json:"user_id"`
-	GroupID int     `json:"group_id"`
-	Amount  float64 `json:"amount"`
+	UserID   int          `json:"user_id"`
+	GroupID  int          `json:"group_id"`
+	Amount   money.Amount `json:"amount"`
+	Currency string       `json:"currency"`
 }
 
 type UserBalanceResponse struct {
-	UserID   int     `json:"user_id"`
-	UserName string  `json:"user_name"`
-	Amount   float64 `json:"amount"`
+	UserID   int          `json:"user_id"`
+	UserName string       `json:"user_name"`
+	Amount   money.Amount `json:"amount"`
+	Currency string       `json:"currency"`
 }
 
 type UserBalanceView struct {
-	UserID   int     `json:"user_id"`
-	UserName string  `json:"user_name"`
-	Amount   float64 `json:"amount"`
-	Type     string  `json:"type"` // "you_owe" | "owes_you"
+	UserID   int          `json:"user_id"`
+	UserName string       `json:"user_name"`
+	Amount   money.Amount `json:"amount"`
+	Currency string       `json:"currency"`
+	Type     string       `json:"type"` // "you_owe" | "owes_you"
+}
+
+// PairwiseBalance is the net amount FromUserID owes ToUserID within a group,
+// in the group currency
+type PairwiseBalance struct {
+	GroupID    int          `json:"group_id"`
+	FromUserID int          `json:"from_user_id"`
+	ToUserID   int          `json:"to_user_id"`
+	Amount     money.Amount `json:"amount"`
+}
+
+// BalanceDrift is a pair of users whose materialized balance differs from the
+// balance computed from the ledger. Both amounts are what FromUserID owes
+// ToUserID, negative when the debt runs the other way.
+type BalanceDrift struct {
+	GroupID    int          `json:"group_id"`
+	FromUserID int          `json:"from_user_id"`
+	ToUserID   int          `json:"to_user_id"`
+	Stored     money.Amount `json:"stored"`
+	Expected   money.Amount `json:"expected"`
+}
+
+// SettlePlan is the list of transfers that brings every balance in a group to zero.
+// Its settlements can be posted as-is to the settlement batch endpoint.
+type SettlePlan struct {
+	GroupID     int                 `json:"group_id"`
+	Simplified  bool                `json:"simplified"`
+	Currency    string              `json:"currency"`
+	Settlements []SettlementRequest `json:"settlements"`
 }
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+// Category classifies expenses. Global categories (GroupID nil) are shared by
+// every group; groups can add their own, either at the top level or under any
+// category they can see.
+type Category struct {
+	ID        int       `json:"id"`
+	GroupID   *int      `json:"group_id"`
+	ParentID  *int      `json:"parent_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultCategory is a global category with its subcategories
+type DefaultCategory struct {
+	Name     string
+	Children []string
+}
+
+// DefaultCategories is the global category tree every group starts with
+var DefaultCategories = []DefaultCategory{
+	{Name: "Food & Drink", Children: []string{"Groceries", "Restaurants", "Coffee", "Alcohol"}},
+	{Name: "Travel", Children: []string{"Flights", "Lodging", "Car Rental", "Public Transport", "Taxi"}},
+	{Name: "Home", Children: []string{"Rent", "Furniture", "Maintenance", "Household Supplies"}},
+	{Name: "Utilities", Children: []string{"Electricity", "Water", "Gas", "Internet", "Phone"}},
+	{Name: "Entertainment", Children: []string{"Movies", "Games", "Music", "Sports"}},
+	{Name: "Shopping", Children: []string{"Clothing", "Electronics", "Gifts"}},
+	{Name: "Health", Children: []string{"Medical", "Pharmacy", "Fitness"}},
+	{Name: "Other"},
+}
+
+type CategoryRequest struct {
+	GroupID  int    `json:"group_id" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	ParentID *int   `json:"parent_id"`
+}
+
+// CategoryUpdateRequest renames or moves a group category. A parent ID of 0
+// moves it to the top level.
+type CategoryUpdateRequest struct {
+	Name     *string `json:"name"`
+	ParentID *int    `json:"parent_id"`
+}
+
+// CategoryResponse is a category with its subcategories
+type CategoryResponse struct {
+	ID       int                 `json:"id"`
+	GroupID  *int                `json:"group_id"`
+	ParentID *int                `json:"parent_id"`
+	Name     string              `json:"name"`
+	Global   bool                `json:"global"`
+	Children []*CategoryResponse `json:"children"`
+}
+
+// CategoryTotal sums expenses of one category (nil for uncategorized) that
+// were recorded in groups with the same currency
+type CategoryTotal struct {
+	CategoryID   *int         `json:"category_id"`
+	CategoryName string       `json:"category_name"`
+	Currency     string       `json:"currency"`
+	Total        money.Amount `json:"total"`
+	Count        int          `json:"count"`
+}
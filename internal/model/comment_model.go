@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// MaxCommentLength is the longest comment accepted, in characters
+const MaxCommentLength = 2000
+
+// Comment is a message in the discussion of an expense or of a settlement;
+// exactly one of ExpenseID and SettlementID is set. AuthorName is filled in
+// when comments are read. The author is nil once their account is deleted.
+type Comment struct {
+	ID           int       `json:"id"`
+	ExpenseID    *int      `json:"expense_id,omitempty"`
+	SettlementID *int      `json:"settlement_id,omitempty"`
+	AuthorID     *int      `json:"author_id"`
+	AuthorName   string    `json:"author_name"`
+	Body         string    `json:"body"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CommentRequest posts or edits a comment
+type CommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
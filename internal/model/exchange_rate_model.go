@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+// ExchangeRate states that one unit of BaseCurrency is worth Rate units of
+// QuoteCurrency from EffectiveDate onwards
+type ExchangeRate struct {
+	BaseCurrency  string     `json:"base_currency"`
+	QuoteCurrency string     `json:"quote_currency"`
+	Rate          money.Rate `json:"rate"`
+	EffectiveDate time.Time  `json:"effective_date"`
+}
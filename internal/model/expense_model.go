@@ -1,30 +1,122 @@
 package model
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
 
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+// Split modes an expense can be divided with
+const (
+	SplitModeEqual      = "equal"
+	SplitModeExact      = "exact"
+	SplitModePercentage = "percentage"
+	SplitModeShares     = "shares"
+)
+
+// Expense amounts are recorded in the currency they were paid in. FXRate is the
+// rate to the group currency snapshotted when the expense was created, and
+// BaseAmount is the amount converted with it.
 type Expense struct {
-	ID          int       `json:"id"`
-	GroupID     int       `json:"group_id"`
-	PaidByID    int       `json:"paid_by_id"`
-	Amount      float64   `json:"amount" binding:"required"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int          `json:"id"`
+	GroupID     int          `json:"group_id"`
+	PaidByID    int          `json:"paid_by_id"`
+	Amount      money.Amount `json:"amount" binding:"required"`
+	Currency    string       `json:"currency"`
+	FXRate      money.Rate   `json:"fx_rate"`
+	BaseAmount  money.Amount `json:"base_amount"`
+	SplitMode   string       `json:"split_mode"`
+	CategoryID  *int         `json:"category_id"`
+	Description string       `json:"description"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	DeletedAt   *time.Time   `json:"deleted_at,omitempty"`
 }
 
 type ExpenseRequest struct {
-	GroupID     int     `json:"group_id" binding:"required"`
-	PaidByID    int     `json:"paid_by_id" binding:"required"`
-	Amount      float64 `json:"amount" binding:"required"`
-	Description string  `json:"description"`
+	GroupID     int          `json:"group_id" binding:"required"`
+	PaidByID    int          `json:"paid_by_id" binding:"required"`
+	Amount      money.Amount `json:"amount" binding:"required"`
+	Currency    string       `json:"currency"`
+	CategoryID  *int         `json:"category_id"`
+	Description string       `json:"description"`
+	Split       *SplitSpec   `json:"split"`
+}
+
+// SplitSpec describes how an expense is divided. Without a spec, or with an equal
+// spec listing no participants, the expense is split equally among all group members.
+type SplitSpec struct {
+	Mode         string             `json:"mode"`
+	Participants []SplitParticipant `json:"participants"`
+}
+
+// Value stores the spec in a JSONB column. It is passed as a string, since the
+// driver sends []byte as bytea.
+func (s SplitSpec) Value() (driver.Value, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan reads the spec from a JSONB column
+func (s *SplitSpec) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("cannot scan %T into split spec", src)
+	}
+}
+
+// SplitParticipant is one person's part of a SplitSpec. Which field is used
+// depends on the mode: Amount for exact, Percentage for percentage and Shares
+// for shares; equal splits only need the user ID.
+type SplitParticipant struct {
+	UserID     int          `json:"user_id"`
+	Amount     money.Amount `json:"amount,omitempty"`
+	Percentage float64      `json:"percentage,omitempty"`
+	Shares     int64        `json:"shares,omitempty"`
+}
+
+// ExpenseUpdateRequest changes an expense. Fields left out keep their current
+// value; a category ID of 0 removes the category. When the amount changes
+// without a new split spec, the existing splits are recomputed with the
+// expense's split mode.
+type ExpenseUpdateRequest struct {
+	Amount      *money.Amount `json:"amount"`
+	PaidByID    int           `json:"paid_by_id"`
+	CategoryID  *int          `json:"category_id"`
+	Description *string       `json:"description"`
+	Split       *SplitSpec    `json:"split"`
 }
 
 type ExpenseResponse struct {
-	ID          int       `json:"id"`
-	GroupID     int       `json:"group_id"`
-	PaidByID    int       `json:"paid_by_id"`
-	PaidByName  string    `json:"paid_by_name"`
-	Amount      float64   `json:"amount"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID           int          `json:"id"`
+	GroupID      int          `json:"group_id"`
+	PaidByID     int          `json:"paid_by_id"`
+	PaidByName   string       `json:"paid_by_name"`
+	Amount       money.Amount `json:"amount"`
+	Currency     string       `json:"currency"`
+	FXRate       money.Rate   `json:"fx_rate"`
+	BaseAmount   money.Amount `json:"base_amount"`
+	BaseCurrency string       `json:"base_currency"`
+	SplitMode    string       `json:"split_mode"`
+	CategoryID   *int         `json:"category_id"`
+	Description  string       `json:"description"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// ExpenseListResponse is a page of expenses. CategoryTotals sums every expense
+// matching the filters, not only the ones in the page.
+type ExpenseListResponse struct {
+	Expenses       []*ExpenseResponse `json:"expenses"`
+	NextCursor     string             `json:"next_cursor"`
+	CategoryTotals []*CategoryTotal   `json:"category_totals"`
 }
@@ -1,25 +1,40 @@
 package model
 
-import "time"
+import (
+	"time"
 
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+// ExpenseSplit.Amount is in the expense currency; BaseAmount is the share of the
+// expense's converted amount, in the group currency. Weight is the participant's
+// input for the expense's split mode: 1 for equal, the amount in cents for exact,
+// basis points for percentage and the number of shares for shares.
 type ExpenseSplit struct {
-	ID        int       `json:"id"`
-	ExpenseID int       `json:"expense_id"`
-	UserID    int       `json:"user_id"`
-	Amount    float64   `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         int          `json:"id"`
+	ExpenseID  int          `json:"expense_id"`
+	UserID     int          `json:"user_id"`
+	Amount     money.Amount `json:"amount"`
+	BaseAmount money.Amount `json:"base_amount"`
+	Weight     int64        `json:"weight"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
 }
 
 type ExpenseSplitRequest struct {
-	ExpenseID int     `json:"expense_id" binding:"required"`
-	UserID    int     `json:"user_id" binding:"required"`
-	Amount    float64 `json:"amount" binding:"required"`
+	ExpenseID int          `json:"expense_id" binding:"required"`
+	UserID    int          `json:"user_id" binding:"required"`
+	Amount    money.Amount `json:"amount" binding:"required"`
+}
+
+type ExpenseSplitUpdateRequest struct {
+	Amount money.Amount `json:"amount"`
 }
 
 type ExpenseSplitResponse struct {
-	ID        int     `json:"id"`
-	ExpenseID int     `json:"expense_id"`
-	UserID    int     `json:"user_id"`
-	Amount    float64 `json:"amount"`
+	ID         int          `json:"id"`
+	ExpenseID  int          `json:"expense_id"`
+	UserID     int          `json:"user_id"`
+	Amount     money.Amount `json:"amount"`
+	BaseAmount money.Amount `json:"base_amount"`
 }
@@ -2,10 +2,21 @@ package model
 
 import "time"
 
+// Member roles, from most to least privileged. Owners and admins manage the
+// group and its members, members record expenses and settlements, and viewers
+// can only read.
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+	RoleViewer = "viewer"
+)
+
 type GroupMember struct {
 	ID        int       `json:"id"`
 	GroupID   int       `json:"group_id"`
 	UserID    int       `json:"user_id"`
+	Role      string    `json:"role"`
 	AddedAt   time.Time `json:"added_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -13,13 +24,28 @@ type GroupMember struct {
 type GroupMemberRequest struct {
 	GroupID int    `json:"group_id" binding:"required"`
 	Email   string `json:"email" binding:"required"`
+	Role    string `json:"role"`
+}
+
+// PlaceholderRequest adds a member who has no account yet. The email is
+// optional; registering with it later turns the placeholder into that account.
+type PlaceholderRequest struct {
+	GroupID int    `json:"group_id" binding:"required"`
+	Name    string `json:"name" binding:"required"`
+	Email   string `json:"email"`
+}
+
+type GroupMemberRoleRequest struct {
+	Role string `json:"role" binding:"required"`
 }
 
 type GroupMemberResponse struct {
-	ID       int       `json:"id"`
-	GroupID  int       `json:"group_id"`
-	UserID   int       `json:"user_id"`
-	UserName string    `json:"username"`
-	Email    string    `json:"email"`
-	AddedAt  time.Time `json:"added_at"`
+	ID          int       `json:"id"`
+	GroupID     int       `json:"group_id"`
+	UserID      int       `json:"user_id"`
+	UserName    string    `json:"username"`
+	Email       string    `json:"email"`
+	Placeholder bool      `json:"placeholder"`
+	Role        string    `json:"role"`
+	AddedAt     time.Time `json:"added_at"`
 }
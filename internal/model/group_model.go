@@ -3,18 +3,20 @@ package model
 import "time"
 
 type Group struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name" binding:"required"`
-	Description string    `json:"description"`
-	CreatorID   int       `json:"creator_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int        `json:"id"`
+	Name        string     `json:"name" binding:"required"`
+	Description string     `json:"description"`
+	CreatorID   int        `json:"creator_id"`
+	Currency    string     `json:"currency"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
 }
 
 type GroupRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
-	CreatorID   int    `json:"creator_id" binding:"required"`
+	Currency    string `json:"currency"`
 }
 
 type GroupResponse struct {
@@ -22,5 +24,6 @@ type GroupResponse struct {
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	CreatorID   int       `json:"creator_id"`
+	Currency    string    `json:"currency"`
 	CreatedAt   time.Time `json:"created_at"`
 }
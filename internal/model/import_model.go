@@ -0,0 +1,92 @@
+package model
+
+import "github.com/shreyansh/expense-go-collab-backend/internal/money"
+
+// Formats expenses can be imported from
+const (
+	ImportFormatCSV   = "csv"
+	ImportFormatJSONL = "jsonl"
+)
+
+// MaxImportRows caps the number of expenses a single import can hold
+const MaxImportRows = 5000
+
+// ImportRow is one expense to import. People are identified by email, since
+// imported files come from outside the app. An empty date means today; an
+// empty currency the group currency; no split an equal split among all members.
+type ImportRow struct {
+	PayerEmail  string       `json:"payer_email"`
+	Amount      money.Amount `json:"amount"`
+	Currency    string       `json:"currency"`
+	Date        string       `json:"date"`
+	Description string       `json:"description"`
+	CategoryID  *int         `json:"category_id"`
+	Split       *ImportSplit `json:"split"`
+}
+
+// ImportSplit is a SplitSpec with participants identified by email
+type ImportSplit struct {
+	Mode         string                   `json:"mode"`
+	Participants []ImportSplitParticipant `json:"participants"`
+}
+
+type ImportSplitParticipant struct {
+	Email      string       `json:"email"`
+	Amount     money.Amount `json:"amount,omitempty"`
+	Percentage float64      `json:"percentage,omitempty"`
+	Shares     int64        `json:"shares,omitempty"`
+}
+
+// ImportRowResult reports on one row of an import. Line is the line of the row
+// in the uploaded file. A Splitwise row becomes a settlement when it is a
+// payment, and one expense per payer otherwise; rows that move no money are
+// skipped.
+type ImportRowResult struct {
+	Line         int      `json:"line"`
+	ExpenseID    int      `json:"expense_id,omitempty"`
+	ExpenseIDs   []int    `json:"expense_ids,omitempty"`
+	SettlementID int      `json:"settlement_id,omitempty"`
+	Skipped      bool     `json:"skipped,omitempty"`
+	Errors       []string `json:"errors,omitempty"`
+}
+
+// ImportResponse reports on every row of an import. Nothing is applied when a
+// single row is invalid, or in a dry run.
+type ImportResponse struct {
+	DryRun  bool               `json:"dry_run"`
+	Applied bool               `json:"applied"`
+	Total   int                `json:"total"`
+	Valid   int                `json:"valid"`
+	Invalid int                `json:"invalid"`
+	Rows    []*ImportRowResult `json:"rows"`
+}
+
+// Special values of a Splitwise group export. Payments are rows of the Payment
+// category, and the export ends with a Total balance row per currency.
+const (
+	SplitwisePaymentCategory = "Payment"
+	SplitwiseTotalBalance    = "Total balance"
+)
+
+// SplitwiseImportOptions says how to import a Splitwise export. Members maps
+// person columns to the emails of group members; other columns are matched to
+// members by name. Unless Placeholders is set, a column matching no member is
+// an error; otherwise a placeholder member is created for it.
+type SplitwiseImportOptions struct {
+	Members      map[string]string
+	Placeholders bool
+	DryRun       bool
+}
+
+// SplitwisePerson reports which member a person column was mapped to. The
+// user ID of a placeholder is only known once the import is applied.
+type SplitwisePerson struct {
+	Name        string `json:"name"`
+	UserID      int    `json:"user_id,omitempty"`
+	Placeholder bool   `json:"placeholder"`
+}
+
+type SplitwiseImportResponse struct {
+	ImportResponse
+	People []*SplitwisePerson `json:"people"`
+}
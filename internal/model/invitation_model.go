@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// Invitation statuses. An invitation is pending until it is accepted, declined
+// or revoked; a pending invitation past its expiry is reported as expired.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationDeclined = "declined"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+// InvitationTTL is how long an invitation can be answered
+const InvitationTTL = 7 * 24 * time.Hour
+
+// Invitation asks someone, by email, to join a group with a role. Only a hash
+// of its token is stored; the token itself is only sent in the email.
+type Invitation struct {
+	ID          int        `json:"id"`
+	GroupID     int        `json:"group_id"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	InvitedByID int        `json:"invited_by_id"`
+	TokenHash   string     `json:"-"`
+	Status      string     `json:"status"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Expired reports whether a pending invitation can no longer be answered
+func (i *Invitation) Expired(now time.Time) bool {
+	return i.Status == InvitationPending && !now.Before(i.ExpiresAt)
+}
+
+// InvitationRequest invites an email to a group. The role defaults to member.
+type InvitationRequest struct {
+	GroupID int    `json:"group_id" binding:"required"`
+	Email   string `json:"email" binding:"required"`
+	Role    string `json:"role"`
+}
@@ -0,0 +1,160 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+// Sort orders of a list
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// Fields a list can be sorted by
+const (
+	SortByCreatedAt   = "created_at"
+	SortByAmount      = "amount"
+	SortByDescription = "description"
+	SortByName        = "name"
+	SortByEmail       = "email"
+)
+
+// Fields each list endpoint can be sorted by
+var (
+	ExpenseSortFields    = []string{SortByCreatedAt, SortByAmount, SortByDescription}
+	SettlementSortFields = []string{SortByCreatedAt, SortByAmount}
+	UserSortFields       = []string{SortByCreatedAt, SortByName, SortByEmail}
+	ActivitySortFields   = []string{SortByCreatedAt}
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+)
+
+// ListQuery filters, sorts and pages a list. Zero values leave a filter unset.
+// GroupID and UserID scope the list and are set by the service, not the client.
+type ListQuery struct {
+	GroupID int
+	UserID  int
+
+	// After is the decoded cursor: the list continues after this row
+	After *Cursor
+	Limit int
+
+	// Rows created in [From, To)
+	From *time.Time
+	To   *time.Time
+
+	// Amounts in [MinAmount, MaxAmount], in the currency they were paid in
+	MinAmount *money.Amount
+	MaxAmount *money.Amount
+
+	// PayerID is who paid the expense or sent the settlement. ParticipantID is
+	// someone with a split on the expense, or either side of the settlement.
+	PayerID       int
+	ParticipantID int
+
+	// Description matches descriptions containing it, ignoring case
+	Description string
+
+	// CategoryID matches expenses in the category or any of its subcategories
+	CategoryID int
+
+	SortField string
+	SortOrder string
+}
+
+// Descending reports whether the list is sorted in descending order
+func (q *ListQuery) Descending() bool {
+	return q.SortOrder == SortDesc
+}
+
+// HasLedgerFilters reports whether the query filters on amounts, payers,
+// participants, descriptions or categories, which only expenses and
+// settlements have
+func (q *ListQuery) HasLedgerFilters() bool {
+	return q.MinAmount != nil || q.MaxAmount != nil || q.PayerID != 0 || q.ParticipantID != 0 || q.Description != "" || q.CategoryID != 0
+}
+
+// Cursor marks the last row of a page: its sort value and ID, along with the
+// sort the page was read with
+type Cursor struct {
+	SortField string `json:"f"`
+	SortOrder string `json:"o"`
+	Value     string `json:"v"`
+	ID        int    `json:"id"`
+}
+
+// NextCursor returns the encoded cursor of the page following a row with the
+// given sort value and ID
+func (q *ListQuery) NextCursor(value string, id int) string {
+	cursor := Cursor{SortField: q.SortField, SortOrder: q.SortOrder, Value: value, ID: id}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor returned as next_cursor
+func DecodeCursor(encoded string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &cursor, nil
+}
+
+// Sort values as stored in cursors
+
+func TimeSortValue(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func AmountSortValue(amount money.Amount) string {
+	return strconv.FormatInt(int64(amount), 10)
+}
+
+// ExpenseSortValue returns the value of the field an expense list is sorted by
+func ExpenseSortValue(expense *Expense, field string) string {
+	switch field {
+	case SortByAmount:
+		return AmountSortValue(expense.Amount)
+	case SortByDescription:
+		return expense.Description
+	default:
+		return TimeSortValue(expense.CreatedAt)
+	}
+}
+
+// SettlementSortValue returns the value of the field a settlement list is sorted by
+func SettlementSortValue(settlement *Settlement, field string) string {
+	switch field {
+	case SortByAmount:
+		return AmountSortValue(settlement.Amount)
+	default:
+		return TimeSortValue(settlement.CreatedAt)
+	}
+}
+
+// UserSortValue returns the value of the field a user list is sorted by
+func UserSortValue(user *User, field string) string {
+	switch field {
+	case SortByName:
+		return user.Name
+	case SortByEmail:
+		return user.Email
+	default:
+		return TimeSortValue(user.CreatedAt)
+	}
+}
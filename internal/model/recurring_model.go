@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+// RecurringExpense is a template the scheduler creates expenses from. It
+// repeats daily, weekly or monthly every Interval periods from StartDate, or
+// follows the five-field cron expression in Cron, in UTC. NextRunAt is the
+// next occurrence to create, nil once the template has ended. A template that
+// fails to create an occurrence is paused with the reason in LastError.
+type RecurringExpense struct {
+	ID          int          `json:"id"`
+	GroupID     int          `json:"group_id"`
+	CreatedByID int          `json:"created_by_id"`
+	PaidByID    int          `json:"paid_by_id"`
+	Amount      money.Amount `json:"amount"`
+	Currency    string       `json:"currency"`
+	CategoryID  *int         `json:"category_id"`
+	Description string       `json:"description"`
+	Split       *SplitSpec   `json:"split"`
+	Frequency   string       `json:"frequency"`
+	Interval    int          `json:"interval"`
+	Cron        string       `json:"cron,omitempty"`
+	StartDate   time.Time    `json:"start_date"`
+	EndDate     *time.Time   `json:"end_date"`
+	NextRunAt   *time.Time   `json:"next_run_at"`
+	LastRunAt   *time.Time   `json:"last_run_at"`
+	Paused      bool         `json:"paused"`
+	LastError   string       `json:"last_error,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// RecurringExpenseRequest creates a recurring expense, or replaces one when
+// editing, in which case the group ID is ignored. The interval defaults to 1
+// and the start date to now. Edits only apply to occurrences not created yet.
+type RecurringExpenseRequest struct {
+	GroupID     int          `json:"group_id"`
+	PaidByID    int          `json:"paid_by_id" binding:"required"`
+	Amount      money.Amount `json:"amount" binding:"required"`
+	Currency    string       `json:"currency"`
+	CategoryID  *int         `json:"category_id"`
+	Description string       `json:"description"`
+	Split       *SplitSpec   `json:"split"`
+	Frequency   string       `json:"frequency" binding:"required"`
+	Interval    int          `json:"interval"`
+	Cron        string       `json:"cron"`
+	StartDate   *time.Time   `json:"start_date"`
+	EndDate     *time.Time   `json:"end_date"`
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+// Time buckets a report can be broken down by. Weeks start on Monday.
+const (
+	ReportBucketDay   = "day"
+	ReportBucketWeek  = "week"
+	ReportBucketMonth = "month"
+)
+
+var ReportBuckets = []string{ReportBucketDay, ReportBucketWeek, ReportBucketMonth}
+
+// Dimensions report entries can be grouped by
+const (
+	ReportByPeriod   = "period"
+	ReportByUser     = "user"
+	ReportByGroup    = "group"
+	ReportByCategory = "category"
+)
+
+// ReportQuery selects what a report covers. GroupID and UserID scope it and are
+// set by the service, not the client.
+type ReportQuery struct {
+	GroupID int
+	UserID  int
+
+	// Expenses and settlements created in [From, To)
+	From *time.Time
+	To   *time.Time
+
+	Bucket string
+}
+
+// ReportEntry sums the ledger for one combination of the dimensions a report
+// section is grouped by; the other dimensions are left out. Amounts are in the
+// currency of the group they were recorded in.
+//
+// Paid is what was paid for expenses, Consumed the splits owed for them, and
+// SettlementsSent and SettlementsReceived the settlements paid and received.
+// Entries grouped by user count only what that user paid, consumed, sent or
+// received. Entries grouped by category leave settlements out; an entry
+// without a category ID sums the uncategorized expenses.
+type ReportEntry struct {
+	PeriodStart  *time.Time `json:"period_start,omitempty"`
+	UserID       *int       `json:"user_id,omitempty"`
+	UserName     string     `json:"user_name,omitempty"`
+	GroupID      *int       `json:"group_id,omitempty"`
+	GroupName    string     `json:"group_name,omitempty"`
+	CategoryID   *int       `json:"category_id,omitempty"`
+	CategoryName string     `json:"category_name,omitempty"`
+	Currency     string     `json:"currency"`
+
+	Paid                money.Amount `json:"paid"`
+	Consumed            money.Amount `json:"consumed"`
+	SettlementsSent     money.Amount `json:"settlements_sent"`
+	SettlementsReceived money.Amount `json:"settlements_received"`
+}
+
+// ReportResponse is the spending report of a group or of a user across their
+// groups. Totals holds one entry per currency.
+type ReportResponse struct {
+	GroupID int        `json:"group_id,omitempty"`
+	UserID  int        `json:"user_id,omitempty"`
+	Bucket  string     `json:"bucket"`
+	From    *time.Time `json:"from,omitempty"`
+	To      *time.Time `json:"to,omitempty"`
+
+	Totals     []*ReportEntry `json:"totals"`
+	ByPeriod   []*ReportEntry `json:"by_period"`
+	ByCategory []*ReportEntry `json:"by_category"`
+
+	// Group reports only: per member, and per member and period
+	ByUser          []*ReportEntry `json:"by_user,omitempty"`
+	ByUserAndPeriod []*ReportEntry `json:"by_user_and_period,omitempty"`
+
+	// User reports only: per group
+	ByGroup []*ReportEntry `json:"by_group,omitempty"`
+}
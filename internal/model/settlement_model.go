@@ -1,43 +1,65 @@
 package model
 
-import "time"
+import (
+	"time"
 
-// Settlement represents a payment between two users to settle expenses
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+// Settlement represents a payment between two users to settle expenses.
+// Like expenses, it keeps the paid amount and its conversion to the group currency.
 type Settlement struct {
-	ID          int       `json:"id"`
-	GroupID     int       `json:"group_id"`
-	FromUserID  int       `json:"from_user_id"`
-	ToUserID    int       `json:"to_user_id"`
-	Amount      float64   `json:"amount"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int          `json:"id"`
+	GroupID     int          `json:"group_id"`
+	FromUserID  int          `json:"from_user_id"`
+	ToUserID    int          `json:"to_user_id"`
+	Amount      money.Amount `json:"amount"`
+	Currency    string       `json:"currency"`
+	FXRate      money.Rate   `json:"fx_rate"`
+	BaseAmount  money.Amount `json:"base_amount"`
+	Description string       `json:"description"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	DeletedAt   *time.Time   `json:"deleted_at,omitempty"`
 }
 
 // SettlementRequest is the request body for creating a settlement
 type SettlementRequest struct {
-	GroupID     int     `json:"group_id"`
-	FromUserID  int     `json:"from_user_id"`
-	ToUserID    int     `json:"to_user_id"`
-	Amount      float64 `json:"amount"`
-	Description string  `json:"description"`
+	GroupID     int          `json:"group_id"`
+	FromUserID  int          `json:"from_user_id"`
+	ToUserID    int          `json:"to_user_id"`
+	Amount      money.Amount `json:"amount"`
+	Currency    string       `json:"currency,omitempty"`
+	Description string       `json:"description"`
 }
 
 // SettlementResponse is the response body for settlement operations
 type SettlementResponse struct {
-	ID           int       `json:"id"`
-	GroupID      int       `json:"group_id"`
-	FromUserID   int       `json:"from_user_id"`
-	FromUserName string    `json:"from_user_name"`
-	ToUserID     int       `json:"to_user_id"`
-	ToUserName   string    `json:"to_user_name"`
-	Amount       float64   `json:"amount"`
-	Description  string    `json:"description"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID           int          `json:"id"`
+	GroupID      int          `json:"group_id"`
+	FromUserID   int          `json:"from_user_id"`
+	FromUserName string       `json:"from_user_name"`
+	ToUserID     int          `json:"to_user_id"`
+	ToUserName   string       `json:"to_user_name"`
+	Amount       money.Amount `json:"amount"`
+	Currency     string       `json:"currency"`
+	FXRate       money.Rate   `json:"fx_rate"`
+	BaseAmount   money.Amount `json:"base_amount"`
+	BaseCurrency string       `json:"base_currency"`
+	Description  string       `json:"description"`
+	CreatedAt    time.Time    `json:"created_at"`
 }
 
-// GroupSettlementResponse shows all settlements in a group
+// GroupSettlementResponse shows a page of the settlements in a group.
+// TotalAmount sums the settlements in the page.
 type GroupSettlementResponse struct {
 	Settlements []SettlementResponse `json:"settlements"`
-	TotalAmount float64              `json:"total_amount"`
+	TotalAmount money.Amount         `json:"total_amount"`
+	Currency    string               `json:"currency"`
+	NextCursor  string               `json:"next_cursor"`
+}
+
+// SettlementBatchRequest is the request body for recording several settlements at once
+type SettlementBatchRequest struct {
+	Settlements []SettlementRequest `json:"settlements" binding:"required"`
 }
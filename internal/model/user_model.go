@@ -1,23 +1,41 @@
 package model
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
+// User is a person using the app. A placeholder user stands for someone who
+// has no account yet: they take part in expenses but cannot log in.
 type User struct {
-	ID        int       `json:"id"`
-	Email     string    `json:"email" binding:"required"`
-	Name      string    `json:"name" binding:"required"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           int       `json:"id"`
+	Email        string    `json:"email" binding:"required"`
+	Name         string    `json:"name" binding:"required"`
+	PasswordHash string    `json:"-"`
+	Placeholder  bool      `json:"placeholder"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 type UserRequest struct {
-	Email string `json:"email" binding:"required"`
-	Name  string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	// InvitationToken is required to register with the email of a placeholder
+	// member, to show the email is yours
+	InvitationToken string `json:"invitation_token"`
 }
 
 type UserResponse struct {
-	ID        int       `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          int       `json:"id"`
+	Email       string    `json:"email"`
+	Name        string    `json:"name"`
+	Placeholder bool      `json:"placeholder"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// NormalizeEmail returns the form emails are stored and looked up in, so that
+// addresses differing only in case or surrounding space are the same account
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
@@ -0,0 +1,78 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// SplitEvenly divides total into n parts that differ by at most one cent and sum
+// exactly to total. Leftover cents go to the first parts, so callers should pass
+// participants in a stable order.
+func SplitEvenly(total Amount, n int) ([]Amount, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("cannot split between %d participants", n)
+	}
+
+	weights := make([]int64, n)
+	for i := range weights {
+		weights[i] = 1
+	}
+
+	return Allocate(total, weights)
+}
+
+// Allocate divides total proportionally to weights using the largest remainder
+// method: every part gets the floor of its exact share, then the remaining cents
+// are handed out one by one to the parts with the largest fractional remainders,
+// ties going to the earlier part. The result always sums exactly to total.
+func Allocate(total Amount, weights []int64) ([]Amount, error) {
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("no weights to allocate between")
+	}
+	if total < 0 {
+		return nil, fmt.Errorf("cannot allocate a negative amount")
+	}
+
+	var weightSum int64
+	for _, w := range weights {
+		if w < 0 {
+			return nil, fmt.Errorf("weights must not be negative")
+		}
+		weightSum += w
+	}
+	if weightSum == 0 {
+		return nil, fmt.Errorf("weights must not all be zero")
+	}
+
+	// big.Int keeps total*weight from overflowing for large amounts or weights
+	bigTotal := big.NewInt(int64(total))
+	bigSum := big.NewInt(weightSum)
+
+	parts := make([]Amount, len(weights))
+	remainders := make([]*big.Int, len(weights))
+	var allocated Amount
+
+	for i, w := range weights {
+		product := new(big.Int).Mul(bigTotal, big.NewInt(w))
+		quotient, remainder := new(big.Int).QuoRem(product, bigSum, new(big.Int))
+		parts[i] = Amount(quotient.Int64())
+		remainders[i] = remainder
+		allocated += parts[i]
+	}
+
+	for left := total - allocated; left > 0; left-- {
+		best := -1
+		for i, r := range remainders {
+			if r.Sign() == 0 {
+				continue
+			}
+			if best == -1 || r.Cmp(remainders[best]) > 0 {
+				best = i
+			}
+		}
+		parts[best]++
+		remainders[best].SetInt64(0)
+	}
+
+	return parts, nil
+}
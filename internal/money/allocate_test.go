@@ -0,0 +1,86 @@
+package money
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   Amount
+		weights []int64
+		want    []Amount
+		wantErr bool
+	}{
+		{name: "even", total: 900, weights: []int64{1, 1, 1}, want: []Amount{300, 300, 300}},
+		{name: "leftover cent goes to the earlier part on ties", total: 1000, weights: []int64{1, 1, 1}, want: []Amount{334, 333, 333}},
+		{name: "two leftover cents", total: 1001, weights: []int64{1, 1, 1}, want: []Amount{334, 334, 333}},
+		{name: "largest remainder wins", total: 100, weights: []int64{1, 2, 3}, want: []Amount{17, 33, 50}},
+		{name: "proportional", total: 10000, weights: []int64{2500, 7500}, want: []Amount{2500, 7500}},
+		{name: "zero weight gets nothing", total: 101, weights: []int64{0, 1, 1}, want: []Amount{0, 51, 50}},
+		{name: "zero total", total: 0, weights: []int64{1, 2}, want: []Amount{0, 0}},
+		{name: "single part", total: 12345, weights: []int64{7}, want: []Amount{12345}},
+		{name: "large amounts do not overflow", total: 1 << 60, weights: []int64{1 << 40, 1 << 40}, want: []Amount{1 << 59, 1 << 59}},
+		{name: "no weights", total: 100, weights: nil, wantErr: true},
+		{name: "negative total", total: -100, weights: []int64{1}, wantErr: true},
+		{name: "negative weight", total: 100, weights: []int64{1, -1}, wantErr: true},
+		{name: "all zero weights", total: 100, weights: []int64{0, 0}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Allocate(tt.total, tt.weights)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Allocate(%d, %v) = %v, want an error", tt.total, tt.weights, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Allocate(%d, %v) failed: %v", tt.total, tt.weights, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allocate(%d, %v) = %v, want %v", tt.total, tt.weights, got, tt.want)
+			}
+
+			var sum Amount
+			for _, part := range got {
+				sum += part
+			}
+			if sum != tt.total {
+				t.Errorf("parts add up to %d, want %d", sum, tt.total)
+			}
+		})
+	}
+}
+
+func TestSplitEvenly(t *testing.T) {
+	tests := []struct {
+		total   Amount
+		n       int
+		want    []Amount
+		wantErr bool
+	}{
+		{total: 100, n: 3, want: []Amount{34, 33, 33}},
+		{total: 2, n: 3, want: []Amount{1, 1, 0}},
+		{total: 100, n: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := SplitEvenly(tt.total, tt.n)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("SplitEvenly(%d, %d) = %v, want an error", tt.total, tt.n, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SplitEvenly(%d, %d) failed: %v", tt.total, tt.n, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitEvenly(%d, %d) = %v, want %v", tt.total, tt.n, got, tt.want)
+		}
+	}
+}
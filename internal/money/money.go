@@ -0,0 +1,140 @@
+package money
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the ISO 4217 code used when no currency is specified
+const DefaultCurrency = "USD"
+
+// minorUnits is the number of minor units (cents) in one major unit
+const minorUnits = 100
+
+// Amount is a monetary value stored as an integer number of minor units (cents).
+// It is encoded in JSON as a decimal number with two fractional digits, e.g. 12.34,
+// so API clients keep working with major units while no float arithmetic is involved.
+type Amount int64
+
+// ParseAmount parses a decimal string such as "12", "12.3" or "-12.34" into an Amount.
+// More than two fractional digits are rejected rather than silently rounded.
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("invalid amount: empty")
+	}
+
+	input := s
+	negative := false
+	if s[0] == '-' || s[0] == '+' {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if whole == "" && (!hasFrac || frac == "") {
+		return 0, fmt.Errorf("invalid amount: %q", input)
+	}
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("invalid amount %q: at most 2 decimal places are allowed", input)
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("invalid amount: %q", input)
+	}
+
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	major, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %v", input, err)
+	}
+	minor, _ := strconv.ParseInt(frac, 10, 64)
+
+	if major > (1<<63-1-minor)/minorUnits {
+		return 0, fmt.Errorf("invalid amount %q: out of range", input)
+	}
+
+	cents := major*minorUnits + minor
+	if negative {
+		cents = -cents
+	}
+
+	return Amount(cents), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount in major units with two decimal places
+func (a Amount) String() string {
+	sign := ""
+	cents := int64(a)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/minorUnits, cents%minorUnits)
+}
+
+// Abs returns the absolute value of the amount
+func (a Amount) Abs() Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// MarshalJSON encodes the amount as a JSON number in major units
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts either a JSON number or a quoted decimal string
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		data = data[1 : len(data)-1]
+	}
+
+	parsed, err := ParseAmount(string(data))
+	if err != nil {
+		return err
+	}
+
+	*a = parsed
+	return nil
+}
+
+// NormalizeCurrency upper-cases a currency code and checks that it looks like an
+// ISO 4217 code. An empty code yields fallback.
+func NormalizeCurrency(code, fallback string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return fallback, nil
+	}
+	if len(code) != 3 {
+		return "", fmt.Errorf("invalid currency code: %q", code)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("invalid currency code: %q", code)
+		}
+	}
+	return code, nil
+}
@@ -0,0 +1,133 @@
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// rateScale is the number of decimal places a rate is stored with (NUMERIC(20, 10))
+const rateScale = 10
+
+// Rate is an exact decimal exchange rate: one unit of the source currency is worth
+// Rate units of the target currency. The zero value is not a valid rate; use
+// OneRate or ParseRate.
+type Rate struct {
+	r *big.Rat
+}
+
+// OneRate is the identity rate used when no conversion is needed
+func OneRate() Rate {
+	return Rate{r: big.NewRat(1, 1)}
+}
+
+// ParseRate parses a positive decimal string such as "1.0845" into a Rate
+func ParseRate(s string) (Rate, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid exchange rate: %q", s)
+	}
+	if r.Sign() <= 0 {
+		return Rate{}, fmt.Errorf("exchange rate must be positive: %q", s)
+	}
+	return Rate{r: r}, nil
+}
+
+// IsZero reports whether the rate is unset
+func (r Rate) IsZero() bool {
+	return r.r == nil
+}
+
+// Inverse returns the rate for converting in the opposite direction, rounded to
+// the precision rates are stored with so the snapshotted rate is the one applied
+func (r Rate) Inverse() Rate {
+	inverse, _ := new(big.Rat).SetString(new(big.Rat).Inv(r.r).FloatString(rateScale))
+	return Rate{r: inverse}
+}
+
+// Convert multiplies the amount by the rate, rounding half away from zero to the
+// nearest minor unit
+func (r Rate) Convert(a Amount) Amount {
+	product := new(big.Rat).Mul(big.NewRat(int64(a), 1), r.r)
+
+	num := new(big.Int).Abs(product.Num())
+	den := product.Denom()
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(remainder, big.NewInt(2)).Cmp(den) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if product.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+
+	return Amount(quotient.Int64())
+}
+
+// String formats the rate with up to ten decimal places and no trailing zeros
+func (r Rate) String() string {
+	if r.r == nil {
+		return "0"
+	}
+	s := r.r.FloatString(rateScale)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// Value stores the rate in a NUMERIC column
+func (r Rate) Value() (driver.Value, error) {
+	if r.r == nil {
+		return nil, fmt.Errorf("exchange rate is not set")
+	}
+	return r.r.FloatString(rateScale), nil
+}
+
+// Scan reads the rate from a NUMERIC column
+func (r *Rate) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case float64:
+		s = fmt.Sprintf("%.10f", v)
+	case int64:
+		s = fmt.Sprintf("%d", v)
+	default:
+		return fmt.Errorf("cannot scan %T into exchange rate", src)
+	}
+
+	parsed, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// MarshalJSON encodes the rate as a JSON number
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON accepts either a JSON number or a quoted decimal string
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		data = data[1 : len(data)-1]
+	}
+
+	parsed, err := ParseRate(string(data))
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
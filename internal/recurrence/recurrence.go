@@ -0,0 +1,142 @@
+// Package recurrence computes when a recurring expense is due. Rules repeat
+// every N days, weeks or months from a start time, or follow a cron
+// expression. All times are in UTC.
+package recurrence
+
+import (
+	"fmt"
+	"time"
+)
+
+// Frequencies a rule can repeat with
+const (
+	Daily   = "daily"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+	Cron    = "cron"
+)
+
+// Rule describes when occurrences fall. Occurrences are never before Start nor
+// after End, when End is set. Interval applies to daily, weekly and monthly
+// rules; Cron only to cron rules.
+type Rule struct {
+	Frequency string
+	Interval  int
+	Cron      string
+	Start     time.Time
+	End       *time.Time
+}
+
+// Validate reports whether the rule can be scheduled
+func (r *Rule) Validate() error {
+	switch r.Frequency {
+	case Daily, Weekly, Monthly:
+		if r.Interval < 1 {
+			return fmt.Errorf("interval must be at least 1")
+		}
+	case Cron:
+		if _, err := ParseCron(r.Cron); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("frequency must be one of daily, weekly, monthly or cron")
+	}
+
+	if r.Start.IsZero() {
+		return fmt.Errorf("start date is required")
+	}
+	if r.End != nil && r.End.Before(r.Start) {
+		return fmt.Errorf("end date must not be before the start date")
+	}
+	return nil
+}
+
+// First returns the first occurrence of the rule. ok is false when the rule
+// has none.
+func (r *Rule) First() (time.Time, bool, error) {
+	return r.Next(r.Start.Add(-time.Nanosecond))
+}
+
+// Next returns the first occurrence strictly after after. ok is false once the
+// rule has ended.
+func (r *Rule) Next(after time.Time) (time.Time, bool, error) {
+	start := r.Start.UTC()
+	after = after.UTC()
+
+	var next time.Time
+	switch r.Frequency {
+	case Daily:
+		next = nextByStep(start, after, time.Duration(r.Interval)*24*time.Hour)
+	case Weekly:
+		next = nextByStep(start, after, time.Duration(r.Interval)*7*24*time.Hour)
+	case Monthly:
+		next = nextMonthly(start, after, r.Interval)
+	case Cron:
+		schedule, err := ParseCron(r.Cron)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		// A cron rule only has occurrences from its start on
+		if after.Before(start) {
+			after = start.Add(-time.Nanosecond)
+		}
+		var found bool
+		next, found = schedule.Next(after)
+		if !found {
+			return time.Time{}, false, nil
+		}
+	default:
+		return time.Time{}, false, fmt.Errorf("unknown frequency %q", r.Frequency)
+	}
+
+	if r.End != nil && next.After(r.End.UTC()) {
+		return time.Time{}, false, nil
+	}
+	return next, true, nil
+}
+
+// nextByStep returns the first of start, start+step, start+2*step... after after
+func nextByStep(start, after time.Time, step time.Duration) time.Time {
+	if after.Before(start) {
+		return start
+	}
+	steps := after.Sub(start)/step + 1
+	return start.Add(steps * step)
+}
+
+// nextMonthly returns the first occurrence after after of a rule repeating
+// every interval months on the start's day of the month. In shorter months the
+// occurrence falls on the last day instead, without moving later occurrences.
+func nextMonthly(start, after time.Time, interval int) time.Time {
+	if after.Before(start) {
+		return start
+	}
+
+	// Begin a step before the month of after, since the day of the month can
+	// put that month's occurrence either side of it
+	months := (after.Year()-start.Year())*12 + int(after.Month()-start.Month())
+	k := months/interval - 1
+	if k < 0 {
+		k = 0
+	}
+	for {
+		next := monthlyOccurrence(start, k*interval)
+		if next.After(after) {
+			return next
+		}
+		k++
+	}
+}
+
+// monthlyOccurrence returns start moved forward by months, clamped to the end
+// of the month
+func monthlyOccurrence(start time.Time, months int) time.Time {
+	year, month := start.Year(), start.Month()+time.Month(months)
+	firstOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	day := start.Day()
+	if last := firstOfMonth.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day,
+		start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), time.UTC)
+}
@@ -0,0 +1,162 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestRuleNext(t *testing.T) {
+	end := date(2026, time.March, 31, 0, 0)
+
+	tests := []struct {
+		name  string
+		rule  Rule
+		after time.Time
+		want  time.Time
+		// ended means the rule has no occurrence after after
+		ended bool
+	}{
+		{
+			name:  "daily before the start is the start",
+			rule:  Rule{Frequency: Daily, Interval: 1, Start: date(2026, time.January, 10, 9, 0)},
+			after: date(2026, time.January, 1, 0, 0),
+			want:  date(2026, time.January, 10, 9, 0),
+		},
+		{
+			name:  "daily is strictly after",
+			rule:  Rule{Frequency: Daily, Interval: 1, Start: date(2026, time.January, 10, 9, 0)},
+			after: date(2026, time.January, 10, 9, 0),
+			want:  date(2026, time.January, 11, 9, 0),
+		},
+		{
+			name:  "every third day",
+			rule:  Rule{Frequency: Daily, Interval: 3, Start: date(2026, time.January, 1, 0, 0)},
+			after: date(2026, time.January, 5, 12, 0),
+			want:  date(2026, time.January, 7, 0, 0),
+		},
+		{
+			name:  "every other week",
+			rule:  Rule{Frequency: Weekly, Interval: 2, Start: date(2026, time.January, 5, 8, 0)},
+			after: date(2026, time.January, 6, 0, 0),
+			want:  date(2026, time.January, 19, 8, 0),
+		},
+		{
+			name:  "monthly on the 31st falls on the last day of shorter months",
+			rule:  Rule{Frequency: Monthly, Interval: 1, Start: date(2026, time.January, 31, 10, 0)},
+			after: date(2026, time.February, 1, 0, 0),
+			want:  date(2026, time.February, 28, 10, 0),
+		},
+		{
+			name:  "monthly goes back to the 31st after a short month",
+			rule:  Rule{Frequency: Monthly, Interval: 1, Start: date(2026, time.January, 31, 10, 0)},
+			after: date(2026, time.February, 28, 10, 0),
+			want:  date(2026, time.March, 31, 10, 0),
+		},
+		{
+			name:  "monthly in a leap year",
+			rule:  Rule{Frequency: Monthly, Interval: 1, Start: date(2028, time.January, 30, 0, 0)},
+			after: date(2028, time.February, 1, 0, 0),
+			want:  date(2028, time.February, 29, 0, 0),
+		},
+		{
+			name:  "quarterly across a year",
+			rule:  Rule{Frequency: Monthly, Interval: 3, Start: date(2025, time.November, 15, 0, 0)},
+			after: date(2026, time.January, 1, 0, 0),
+			want:  date(2026, time.February, 15, 0, 0),
+		},
+		{
+			name:  "monthly up to the end date",
+			rule:  Rule{Frequency: Monthly, Interval: 1, Start: date(2026, time.January, 31, 0, 0), End: &end},
+			after: date(2026, time.March, 1, 0, 0),
+			want:  date(2026, time.March, 31, 0, 0),
+		},
+		{
+			name:  "nothing after the end date",
+			rule:  Rule{Frequency: Monthly, Interval: 1, Start: date(2026, time.January, 31, 0, 0), End: &end},
+			after: date(2026, time.March, 31, 0, 0),
+			ended: true,
+		},
+		{
+			name: "cron on weekdays",
+			rule: Rule{Frequency: Cron, Cron: "0 9 * * 1-5", Start: date(2026, time.January, 1, 0, 0)},
+			// Friday evening, so the next weekday is Monday
+			after: date(2026, time.January, 2, 18, 0),
+			want:  date(2026, time.January, 5, 9, 0),
+		},
+		{
+			name:  "cron starts at the start",
+			rule:  Rule{Frequency: Cron, Cron: "*/15 * * * *", Start: date(2026, time.January, 1, 10, 7)},
+			after: date(2025, time.December, 1, 0, 0),
+			want:  date(2026, time.January, 1, 10, 15),
+		},
+		{
+			name: "cron with both day fields matches either",
+			rule: Rule{Frequency: Cron, Cron: "0 0 13 * 5", Start: date(2026, time.January, 1, 0, 0)},
+			// Friday January 2nd comes before the 13th
+			after: date(2026, time.January, 1, 0, 0),
+			want:  date(2026, time.January, 2, 0, 0),
+		},
+		{
+			name:  "cron that never matches",
+			rule:  Rule{Frequency: Cron, Cron: "0 0 30 2 *", Start: date(2026, time.January, 1, 0, 0)},
+			after: date(2026, time.January, 1, 0, 0),
+			ended: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.Validate(); err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+			got, ok, err := tt.rule.Next(tt.after)
+			if err != nil {
+				t.Fatalf("Next failed: %v", err)
+			}
+			if ok == tt.ended {
+				t.Fatalf("Next(%s) ok = %v, want %v", tt.after, ok, !tt.ended)
+			}
+			if ok && !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleFirst(t *testing.T) {
+	rule := Rule{Frequency: Weekly, Interval: 1, Start: date(2026, time.January, 5, 8, 0)}
+	got, ok, err := rule.First()
+	if err != nil || !ok {
+		t.Fatalf("First() = %s, %v, %v", got, ok, err)
+	}
+	if want := rule.Start; !got.Equal(want) {
+		t.Errorf("First() = %s, want %s", got, want)
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	start := date(2026, time.January, 1, 0, 0)
+	before := date(2025, time.December, 31, 0, 0)
+
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{name: "unknown frequency", rule: Rule{Frequency: "yearly", Interval: 1, Start: start}},
+		{name: "zero interval", rule: Rule{Frequency: Daily, Start: start}},
+		{name: "no start", rule: Rule{Frequency: Daily, Interval: 1}},
+		{name: "end before start", rule: Rule{Frequency: Daily, Interval: 1, Start: start, End: &before}},
+		{name: "bad cron", rule: Rule{Frequency: Cron, Cron: "0 9 * *", Start: start}},
+		{name: "cron value out of range", rule: Rule{Frequency: Cron, Cron: "60 9 * * *", Start: start}},
+	}
+
+	for _, tt := range tests {
+		if err := tt.rule.Validate(); err == nil {
+			t.Errorf("%s: Validate succeeded, want an error", tt.name)
+		}
+	}
+}
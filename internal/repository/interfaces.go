@@ -1,23 +1,57 @@
 package repository
 
-import "github.com/shreyansh/expense-go-collab-backend/internal/model"
+import (
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+// Iterator streams query results one row at a time, so that large results are
+// never held in memory at once. Next returns nil once every row has been read;
+// Close must always be called.
+type Iterator[T any] interface {
+	Next() (*T, error)
+	Close() error
+}
 
 type UserRepository interface {
 	CreateUser(user *model.User) (*model.User, error)
 	GetUserByEmail(email string) (*model.User, error)
 	GetUserByID(id int) (*model.User, error)
 	GetAllUsers() ([]*model.User, error)
+	// ListUsers returns one page of users and the cursor of the next page.
+	// query.UserID limits it to that user and the users sharing a group with them.
+	ListUsers(query *model.ListQuery) ([]*model.User, string, error)
 	UpdateUser(user *model.User) (*model.User, error)
+	// RegisterPlaceholder gives a placeholder user a name and password, keeping
+	// their history
+	RegisterPlaceholder(user *model.User) (*model.User, error)
+	// MergeUser moves the history and memberships of fromID to intoID and
+	// deletes fromID. Settlements between the two become payments to oneself.
+	// The balances of the groups fromID has ledger rows in must be rebuilt
+	// after.
+	MergeUser(fromID, intoID int) error
 	DeleteUser(id int) error
 }
 
+// GroupRepository reads and writes groups. Deleted groups are kept until
+// purged but every method except GetDeletedGroupByID and RestoreGroup ignores
+// them.
 type GroupRepository interface {
 	CreateGroup(group *model.Group) (*model.Group, error)
 	GetGroupByID(id int) (*model.Group, error)
 	GetAllGroups() ([]*model.Group, error)
 	GetGroupsByUserID(userID int) ([]*model.Group, error)
 	UpdateGroup(group *model.Group) (*model.Group, error)
-	DeleteGroup(id int) error
+	// DeleteGroup marks a group deleted at deletedAt. Its expenses and
+	// settlements are left as they are.
+	DeleteGroup(id int, deletedAt time.Time) error
+	GetDeletedGroupByID(id int) (*model.Group, error)
+	RestoreGroup(id int) error
+	// PurgeDeletedGroups removes the groups deleted before the given time with
+	// everything in them, and returns how many there were
+	PurgeDeletedGroups(before time.Time) (int64, error)
 }
 
 type GroupMemberRepository interface {
@@ -27,6 +61,10 @@ type GroupMemberRepository interface {
 	GetGroupMembersWithDetails(groupID int) ([]*model.GroupMemberResponse, error)
 	GetUserGroups(userID int) ([]*model.GroupMember, error)
 	IsMember(groupID, userID int) (bool, error)
+	// SharesGroup reports whether the two users are members of a same group
+	SharesGroup(userID, otherID int) (bool, error)
+	GetMember(groupID, userID int) (*model.GroupMember, error)
+	UpdateMemberRole(groupID, userID int, role string) (*model.GroupMember, error)
 }
 
 type ExpenseRepository interface {
@@ -34,23 +72,72 @@ type ExpenseRepository interface {
 	GetExpenseByID(id int) (*model.Expense, error)
 	GetExpensesByGroupID(groupID int) ([]*model.Expense, error)
 	GetExpensesByUserID(userID int) ([]*model.Expense, error)
+	// ListExpenses returns one page of expenses and the cursor of the next page
+	ListExpenses(query *model.ListQuery) ([]*model.Expense, string, error)
+	// SumExpensesByCategory totals the expenses matching the query's filters
+	// per category and currency, across all pages
+	SumExpensesByCategory(query *model.ListQuery) ([]*model.CategoryTotal, error)
+	// IterateExpensesByGroupID streams the group's expenses, oldest first. It
+	// must run in a transaction.
+	IterateExpensesByGroupID(groupID int) (Iterator[model.Expense], error)
+	// LockExpense reads an expense, deleted or not, and locks it until the
+	// transaction ends, waiting for other transactions holding the lock; it
+	// must run in a transaction
+	LockExpense(id int) (*model.Expense, error)
 	UpdateExpense(expense *model.Expense) (*model.Expense, error)
-	DeleteExpense(id int) error
+	// DeleteExpense marks an expense deleted at deletedAt, keeping its splits.
+	// Deleted expenses are ignored by every method except
+	// GetDeletedExpenseByID and the restore methods.
+	DeleteExpense(id int, deletedAt time.Time) error
+	// DeleteGroupExpenses marks every expense of the group deleted at deletedAt
+	DeleteGroupExpenses(groupID int, deletedAt time.Time) error
+	GetDeletedExpenseByID(id int) (*model.Expense, error)
+	RestoreExpense(id int) error
+	// RestoreGroupExpenses restores the group's expenses deleted at deletedAt
+	RestoreGroupExpenses(groupID int, deletedAt time.Time) error
+	// PurgeDeletedExpenses removes the expenses deleted before the given time
+	// with their splits, and returns how many there were
+	PurgeDeletedExpenses(before time.Time) (int64, error)
 }
 
 type ExpenseSplitRepository interface {
 	CreateSplit(split *model.ExpenseSplit) (*model.ExpenseSplit, error)
+	GetSplitByID(id int) (*model.ExpenseSplit, error)
 	GetSplitsByExpenseID(expenseID int) ([]*model.ExpenseSplit, error)
 	GetSplitsByUserID(userID int) ([]*model.ExpenseSplit, error)
+	// IterateSplitsByGroupID streams the splits of the group's expenses, in the
+	// order of IterateExpensesByGroupID and by user within an expense. It must
+	// run in a transaction.
+	IterateSplitsByGroupID(groupID int) (Iterator[model.ExpenseSplit], error)
 	DeleteSplitsByExpenseID(expenseID int) error
+	// UpdateSplit saves the amount, base amount and weight of a split
 	UpdateSplit(split *model.ExpenseSplit) (*model.ExpenseSplit, error)
 }
 
+// CategoryRepository stores global categories and the custom ones of groups
+type CategoryRepository interface {
+	CreateCategory(category *model.Category) (*model.Category, error)
+	GetCategoryByID(id int) (*model.Category, error)
+	// GetCategoriesForGroup returns the global categories and the group's own
+	GetCategoriesForGroup(groupID int) ([]*model.Category, error)
+	UpdateCategory(category *model.Category) (*model.Category, error)
+	DeleteCategory(id int) error
+}
+
+// BalanceRepository reads and maintains the materialized pairwise balances.
+// Every ledger change must adjust them in the same transaction.
 type BalanceRepository interface {
-	GetBalance(userID, groupID int) (float64, error)
-	GetUserBalanceInGroup(userID, groupID int) (float64, error)
-	GetGroupBalances(groupID int) (map[int]float64, error)
+	GetBalance(userID, groupID int) (money.Amount, error)
+	GetUserBalanceInGroup(userID, groupID int) (money.Amount, error)
+	GetGroupBalances(groupID int) (map[int]money.Amount, error)
+	GetPairwiseBalances(groupID int) ([]*model.PairwiseBalance, error)
+	// AdjustBalance adds amount to what fromUserID owes toUserID in the group
+	AdjustBalance(groupID, fromUserID, toUserID int, amount money.Amount) error
+	// CalculateBalances rebuilds the group's balances from its ledger
 	CalculateBalances(groupID int) error
+	// GetLedgerPairwiseBalances computes the pairwise balances from the ledger,
+	// ignoring the materialized ones
+	GetLedgerPairwiseBalances(groupID int) ([]*model.PairwiseBalance, error)
 }
 
 type SettlementRepository interface {
@@ -59,4 +146,136 @@ type SettlementRepository interface {
 	GetSettlementsByGroupID(groupID int) ([]*model.Settlement, error)
 	GetSettlementsByUserID(userID int) ([]*model.Settlement, error)
 	GetAllSettlements() ([]*model.Settlement, error)
+	// IterateSettlementsByGroupID streams the group's settlements, oldest
+	// first. It must run in a transaction.
+	IterateSettlementsByGroupID(groupID int) (Iterator[model.Settlement], error)
+	// ListSettlements returns one page of settlements and the cursor of the next page
+	ListSettlements(query *model.ListQuery) ([]*model.Settlement, string, error)
+	// LockSettlement reads a settlement, deleted or not, and locks it until the
+	// transaction ends; it must run in a transaction
+	LockSettlement(id int) (*model.Settlement, error)
+	// DeleteSettlement marks a settlement deleted at deletedAt. Deleted
+	// settlements are ignored by every method except GetDeletedSettlementByID
+	// and the restore methods.
+	DeleteSettlement(id int, deletedAt time.Time) error
+	// DeleteGroupSettlements marks every settlement of the group deleted at deletedAt
+	DeleteGroupSettlements(groupID int, deletedAt time.Time) error
+	GetDeletedSettlementByID(id int) (*model.Settlement, error)
+	RestoreSettlement(id int) error
+	// RestoreGroupSettlements restores the group's settlements deleted at deletedAt
+	RestoreGroupSettlements(groupID int, deletedAt time.Time) error
+	// PurgeDeletedSettlements removes the settlements deleted before the given
+	// time, and returns how many there were
+	PurgeDeletedSettlements(before time.Time) (int64, error)
+}
+
+// ReportRepository aggregates the ledger for spending reports
+type ReportRepository interface {
+	// Aggregate sums expenses, splits and settlements matching the query,
+	// grouped by the given model.ReportBy* dimensions and by currency
+	Aggregate(query *model.ReportQuery, dimensions []string) ([]*model.ReportEntry, error)
+}
+
+type ExchangeRateRepository interface {
+	UpsertRate(rate *model.ExchangeRate) error
+	GetRate(base, quote string, on time.Time) (*model.ExchangeRate, error)
+	GetAllRates() ([]*model.ExchangeRate, error)
+}
+
+type RefreshTokenRepository interface {
+	CreateRefreshToken(token *model.RefreshToken) (*model.RefreshToken, error)
+	GetRefreshTokenByHash(tokenHash string) (*model.RefreshToken, error)
+	RevokeRefreshToken(id int) error
+	RevokeUserRefreshTokens(userID int) error
+}
+
+// InvitationRepository stores invitations to join groups
+type InvitationRepository interface {
+	CreateInvitation(invitation *model.Invitation) (*model.Invitation, error)
+	GetInvitationByID(id int) (*model.Invitation, error)
+	GetInvitationByTokenHash(tokenHash string) (*model.Invitation, error)
+	// GetInvitationsByGroupID returns the group's invitations, newest first
+	GetInvitationsByGroupID(groupID int) ([]*model.Invitation, error)
+	// RespondToInvitation moves a pending invitation to status. It fails when
+	// the invitation is no longer pending, so a token can only be used once.
+	RespondToInvitation(id int, status string) error
+}
+
+// RecurringExpenseRepository stores the templates recurring expenses are
+// created from
+type RecurringExpenseRepository interface {
+	CreateRecurringExpense(recurring *model.RecurringExpense) (*model.RecurringExpense, error)
+	GetRecurringExpenseByID(id int) (*model.RecurringExpense, error)
+	GetRecurringExpensesByGroupID(groupID int) ([]*model.RecurringExpense, error)
+	// GetDueRecurringExpenses returns up to limit templates that are not paused
+	// and whose next run is at or before now, soonest first
+	GetDueRecurringExpenses(now time.Time, limit int) ([]*model.RecurringExpense, error)
+	// LockRecurringExpense reads a template and locks it until the transaction
+	// ends. It returns nil without waiting when another transaction holds the
+	// lock; it must run in a transaction.
+	LockRecurringExpense(id int) (*model.RecurringExpense, error)
+	// UpdateRecurringExpense saves every field of the template, its schedule
+	// included
+	UpdateRecurringExpense(recurring *model.RecurringExpense) (*model.RecurringExpense, error)
+	DeleteRecurringExpense(id int) error
+}
+
+// AttachmentRepository stores the metadata of files attached to expenses
+type AttachmentRepository interface {
+	CreateAttachment(attachment *model.Attachment) (*model.Attachment, error)
+	GetAttachmentByID(id int) (*model.Attachment, error)
+	// GetAttachmentsByExpenseID returns the expense's attachments, oldest first
+	GetAttachmentsByExpenseID(expenseID int) ([]*model.Attachment, error)
+	// GetExpiredAttachments returns the attachments of the expenses that
+	// PurgeDeletedGroups and PurgeDeletedExpenses would remove for the same
+	// time, because they or their group were deleted before it
+	GetExpiredAttachments(before time.Time) ([]*model.Attachment, error)
+	DeleteAttachment(id int) error
+}
+
+// CommentRepository stores the comments on expenses and settlements. Comments
+// are read with their author's name.
+type CommentRepository interface {
+	CreateComment(comment *model.Comment) (*model.Comment, error)
+	GetCommentByID(id int) (*model.Comment, error)
+	// GetCommentsByExpenseID returns the expense's comments, oldest first
+	GetCommentsByExpenseID(expenseID int) ([]*model.Comment, error)
+	// GetCommentsBySettlementID returns the settlement's comments, oldest first
+	GetCommentsBySettlementID(settlementID int) ([]*model.Comment, error)
+	// UpdateComment changes the body of a comment
+	UpdateComment(comment *model.Comment) (*model.Comment, error)
+	DeleteComment(id int) error
+}
+
+// ActivityRepository stores the activity log of groups. Entries are only ever
+// added; they go with their group. Activities are read with their actor's name.
+type ActivityRepository interface {
+	CreateActivity(activity *model.Activity) (*model.Activity, error)
+	// ListActivities pages through the activities of query.GroupID, filtered
+	// by date
+	ListActivities(query *model.ListQuery) ([]*model.Activity, string, error)
+}
+
+// Tx exposes repositories whose statements all run inside a single transaction
+type Tx interface {
+	Users() UserRepository
+	Groups() GroupRepository
+	Members() GroupMemberRepository
+	Expenses() ExpenseRepository
+	Splits() ExpenseSplitRepository
+	Settlements() SettlementRepository
+	Balances() BalanceRepository
+	Invitations() InvitationRepository
+	RecurringExpenses() RecurringExpenseRepository
+	Activities() ActivityRepository
+	Categories() CategoryRepository
+}
+
+// UnitOfWork runs fn in a transaction. The transaction is committed when fn
+// returns nil and rolled back when it returns an error or panics.
+type UnitOfWork interface {
+	Do(fn func(tx Tx) error) error
+	// View runs fn in a read-only transaction in which every read sees the
+	// same snapshot of the data
+	View(fn func(tx Tx) error) error
 }
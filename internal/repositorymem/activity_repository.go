@@ -0,0 +1,72 @@
+package repositorymem
+
+import (
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type ActivityRepositoryMem struct {
+	db accessor
+}
+
+func NewActivityRepositoryMem(store *Store) *ActivityRepositoryMem {
+	return &ActivityRepositoryMem{db: store}
+}
+
+var activitySortKinds = map[string]sortKind{
+	model.SortByCreatedAt: sortTime,
+}
+
+func (r *ActivityRepositoryMem) CreateActivity(activity *model.Activity) (*model.Activity, error) {
+	err := r.db.write(func(d *data) error {
+		if err := d.requireGroup(activity.GroupID); err != nil {
+			return err
+		}
+		if activity.ActorID != nil {
+			if err := d.requireUser(*activity.ActorID); err != nil {
+				return err
+			}
+		}
+		if activity.Changes == nil {
+			activity.Changes = []byte("{}")
+		}
+
+		activity.ID = d.nextID("activity_log")
+		activity.CreatedAt = time.Now()
+		d.activities[activity.ID] = copyOf(activity)
+		activity.ActorName = d.userName(activity.ActorID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return activity, nil
+}
+
+func (r *ActivityRepositoryMem) ListActivities(query *model.ListQuery) ([]*model.Activity, string, error) {
+	var activities []*model.Activity
+	var next string
+	err := r.db.read(func(d *data) error {
+		var err error
+		activities, next, err = listPage(d.activities, func(a *model.Activity) bool {
+			if a.GroupID != query.GroupID {
+				return false
+			}
+			if query.From != nil && a.CreatedAt.Before(*query.From) {
+				return false
+			}
+			return query.To == nil || a.CreatedAt.Before(*query.To)
+		}, query, activitySortKinds, model.ActivitySortValue, func(a *model.Activity) int { return a.ID })
+		for _, activity := range activities {
+			activity.ActorName = d.userName(activity.ActorID)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return activities, next, nil
+}
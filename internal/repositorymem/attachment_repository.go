@@ -0,0 +1,106 @@
+package repositorymem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type AttachmentRepositoryMem struct {
+	db accessor
+}
+
+func NewAttachmentRepositoryMem(store *Store) *AttachmentRepositoryMem {
+	return &AttachmentRepositoryMem{db: store}
+}
+
+func (r *AttachmentRepositoryMem) CreateAttachment(attachment *model.Attachment) (*model.Attachment, error) {
+	err := r.db.write(func(d *data) error {
+		if err := d.requireExpense(attachment.ExpenseID); err != nil {
+			return err
+		}
+		if attachment.UploadedByID != nil {
+			if err := d.requireUser(*attachment.UploadedByID); err != nil {
+				return err
+			}
+		}
+		for _, existing := range d.attachments {
+			if existing.StorageKey == attachment.StorageKey {
+				return fmt.Errorf("attachment storage key already exists")
+			}
+		}
+
+		attachment.ID = d.nextID("expense_attachments")
+		attachment.CreatedAt = time.Now()
+		d.attachments[attachment.ID] = copyOf(attachment)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (r *AttachmentRepositoryMem) GetAttachmentByID(id int) (*model.Attachment, error) {
+	var attachment *model.Attachment
+	err := r.db.read(func(d *data) error {
+		existing, ok := d.attachments[id]
+		if !ok {
+			return fmt.Errorf("attachment not found")
+		}
+		attachment = copyOf(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (r *AttachmentRepositoryMem) GetAttachmentsByExpenseID(expenseID int) ([]*model.Attachment, error) {
+	var attachments []*model.Attachment
+	r.db.read(func(d *data) error {
+		attachments = collect(d.attachments, func(a *model.Attachment) bool { return a.ExpenseID == expenseID }, func(a, b *model.Attachment) bool {
+			return newestFirst(b.CreatedAt, a.CreatedAt, b.ID, a.ID)
+		})
+		return nil
+	})
+
+	return attachments, nil
+}
+
+// GetExpiredAttachments returns the attachments of expenses deleted before the
+// given time, directly or with their group
+func (r *AttachmentRepositoryMem) GetExpiredAttachments(before time.Time) ([]*model.Attachment, error) {
+	expired := func(deletedAt *time.Time) bool { return deletedAt != nil && deletedAt.Before(before) }
+
+	var attachments []*model.Attachment
+	r.db.read(func(d *data) error {
+		attachments = collect(d.attachments, func(a *model.Attachment) bool {
+			expense, ok := d.expenses[a.ExpenseID]
+			if !ok {
+				return false
+			}
+			group, ok := d.groups[expense.GroupID]
+			return expired(expense.DeletedAt) || ok && expired(group.DeletedAt)
+		}, func(a, b *model.Attachment) bool {
+			return a.ID < b.ID
+		})
+		return nil
+	})
+
+	return attachments, nil
+}
+
+func (r *AttachmentRepositoryMem) DeleteAttachment(id int) error {
+	return r.db.write(func(d *data) error {
+		if _, ok := d.attachments[id]; !ok {
+			return fmt.Errorf("attachment not found")
+		}
+		delete(d.attachments, id)
+		return nil
+	})
+}
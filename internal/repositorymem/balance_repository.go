@@ -0,0 +1,194 @@
+package repositorymem
+
+import (
+	"sort"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+type BalanceRepositoryMem struct {
+	db accessor
+}
+
+func NewBalanceRepositoryMem(store *Store) *BalanceRepositoryMem {
+	return &BalanceRepositoryMem{db: store}
+}
+
+func (r *BalanceRepositoryMem) GetBalance(userID, groupID int) (money.Amount, error) {
+	return r.GetUserBalanceInGroup(userID, groupID)
+}
+
+// GetUserBalanceInGroup returns the user's net balance in the group (positive =
+// owed by the group)
+func (r *BalanceRepositoryMem) GetUserBalanceInGroup(userID, groupID int) (money.Amount, error) {
+	var balance money.Amount
+	r.db.read(func(d *data) error {
+		for key, amount := range d.balances {
+			if key.groupID != groupID {
+				continue
+			}
+			switch userID {
+			case key.high:
+				balance += amount
+			case key.low:
+				balance -= amount
+			}
+		}
+		return nil
+	})
+
+	return balance, nil
+}
+
+// GetGroupBalances returns the net balance of every current member of the
+// group, plus any former member who still has balances in it.
+func (r *BalanceRepositoryMem) GetGroupBalances(groupID int) (map[int]money.Amount, error) {
+	balances := make(map[int]money.Amount)
+	r.db.read(func(d *data) error {
+		for key, amount := range d.balances {
+			if key.groupID == groupID {
+				balances[key.high] += amount
+				balances[key.low] -= amount
+			}
+		}
+		for _, member := range d.members {
+			if member.GroupID == groupID {
+				balances[member.UserID] += 0
+			}
+		}
+		return nil
+	})
+
+	return balances, nil
+}
+
+// GetPairwiseBalances returns the unsimplified debts between each pair of users
+// in the group. Pairs that net to zero are omitted.
+func (r *BalanceRepositoryMem) GetPairwiseBalances(groupID int) ([]*model.PairwiseBalance, error) {
+	var balances []*model.PairwiseBalance
+	r.db.read(func(d *data) error {
+		pairs := make(map[pairKey]money.Amount)
+		for key, amount := range d.balances {
+			if key.groupID == groupID {
+				pairs[key] = amount
+			}
+		}
+		balances = pairwiseBalances(groupID, pairs)
+		return nil
+	})
+
+	return balances, nil
+}
+
+// GetLedgerPairwiseBalances computes the same pairs as GetPairwiseBalances from
+// the expenses, splits and settlements of the group
+func (r *BalanceRepositoryMem) GetLedgerPairwiseBalances(groupID int) ([]*model.PairwiseBalance, error) {
+	var balances []*model.PairwiseBalance
+	r.db.read(func(d *data) error {
+		balances = pairwiseBalances(groupID, d.ledgerPairs(groupID))
+		return nil
+	})
+
+	return balances, nil
+}
+
+// AdjustBalance adds amount to what fromUserID owes toUserID in the group
+func (r *BalanceRepositoryMem) AdjustBalance(groupID, fromUserID, toUserID int, amount money.Amount) error {
+	if fromUserID == toUserID || amount == 0 {
+		return nil
+	}
+
+	return r.db.write(func(d *data) error {
+		if err := d.requireGroup(groupID); err != nil {
+			return err
+		}
+		if err := d.requireUser(fromUserID); err != nil {
+			return err
+		}
+		if err := d.requireUser(toUserID); err != nil {
+			return err
+		}
+
+		d.adjustBalance(groupID, fromUserID, toUserID, amount)
+		return nil
+	})
+}
+
+// CalculateBalances replaces the group's balances with ones recomputed from its
+// ledger
+func (r *BalanceRepositoryMem) CalculateBalances(groupID int) error {
+	return r.db.write(func(d *data) error {
+		for key := range d.balances {
+			if key.groupID == groupID {
+				delete(d.balances, key)
+			}
+		}
+		for key, amount := range d.ledgerPairs(groupID) {
+			d.balances[key] = amount
+		}
+		return nil
+	})
+}
+
+func (d *data) adjustBalance(groupID, fromUserID, toUserID int, amount money.Amount) {
+	switch {
+	case fromUserID < toUserID:
+		d.balances[pairKey{groupID, fromUserID, toUserID}] += amount
+	case fromUserID > toUserID:
+		d.balances[pairKey{groupID, toUserID, fromUserID}] -= amount
+	}
+}
+
+// ledgerPairs sums, per pair of users, every split owed to another member's
+// expense, reduced by the settlements paid between the same two people,
+// leaving out soft-deleted rows
+func (d *data) ledgerPairs(groupID int) map[pairKey]money.Amount {
+	ledger := &data{balances: make(map[pairKey]money.Amount)}
+	for _, split := range d.splits {
+		expense, ok := d.expenses[split.ExpenseID]
+		if ok && expense.DeletedAt == nil && expense.GroupID == groupID {
+			ledger.adjustBalance(groupID, split.UserID, expense.PaidByID, split.BaseAmount)
+		}
+	}
+	for _, settlement := range d.settlements {
+		if settlement.DeletedAt == nil && settlement.GroupID == groupID {
+			ledger.adjustBalance(groupID, settlement.ToUserID, settlement.FromUserID, settlement.BaseAmount)
+		}
+	}
+
+	for key, amount := range ledger.balances {
+		if amount == 0 {
+			delete(ledger.balances, key)
+		}
+	}
+	return ledger.balances
+}
+
+// pairwiseBalances turns balances table rows into positive debts, ordered by
+// pair like the SQL implementation. Pairs that net to zero are omitted.
+func pairwiseBalances(groupID int, pairs map[pairKey]money.Amount) []*model.PairwiseBalance {
+	keys := make([]pairKey, 0, len(pairs))
+	for key, amount := range pairs {
+		if amount != 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].low != keys[j].low {
+			return keys[i].low < keys[j].low
+		}
+		return keys[i].high < keys[j].high
+	})
+
+	var balances []*model.PairwiseBalance
+	for _, key := range keys {
+		amount := pairs[key]
+		balance := &model.PairwiseBalance{GroupID: groupID, FromUserID: key.low, ToUserID: key.high, Amount: amount}
+		if amount < 0 {
+			balance.FromUserID, balance.ToUserID, balance.Amount = key.high, key.low, -amount
+		}
+		balances = append(balances, balance)
+	}
+	return balances
+}
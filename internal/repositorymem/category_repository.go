@@ -0,0 +1,206 @@
+package repositorymem
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type CategoryRepositoryMem struct {
+	db accessor
+}
+
+func NewCategoryRepositoryMem(store *Store) *CategoryRepositoryMem {
+	return &CategoryRepositoryMem{db: store}
+}
+
+func (r *CategoryRepositoryMem) CreateCategory(category *model.Category) (*model.Category, error) {
+	err := r.db.write(func(d *data) error {
+		if category.GroupID != nil {
+			if err := d.requireGroup(*category.GroupID); err != nil {
+				return err
+			}
+		}
+		if err := d.requireCategory(category.ParentID); err != nil {
+			return err
+		}
+		if err := d.checkSiblingName(category); err != nil {
+			return err
+		}
+
+		category.ID = d.nextID("categories")
+		category.CreatedAt = time.Now()
+		category.UpdatedAt = time.Now()
+		d.categories[category.ID] = copyOf(category)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (r *CategoryRepositoryMem) GetCategoryByID(id int) (*model.Category, error) {
+	var category *model.Category
+	err := r.db.read(func(d *data) error {
+		existing, ok := d.categories[id]
+		if !ok {
+			return fmt.Errorf("category not found")
+		}
+		category = copyOf(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// GetCategoriesForGroup returns the global categories followed by the group's
+// own, each ordered by name
+func (r *CategoryRepositoryMem) GetCategoriesForGroup(groupID int) ([]*model.Category, error) {
+	var categories []*model.Category
+	r.db.read(func(d *data) error {
+		categories = collect(d.categories, func(c *model.Category) bool {
+			return c.GroupID == nil || *c.GroupID == groupID
+		}, func(a, b *model.Category) bool {
+			if (a.GroupID == nil) != (b.GroupID == nil) {
+				return a.GroupID == nil
+			}
+			if c := strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)); c != 0 {
+				return c < 0
+			}
+			return a.ID < b.ID
+		})
+		return nil
+	})
+
+	return categories, nil
+}
+
+// UpdateCategory changes the name and parent, like the SQL implementation
+func (r *CategoryRepositoryMem) UpdateCategory(category *model.Category) (*model.Category, error) {
+	err := r.db.write(func(d *data) error {
+		existing, ok := d.categories[category.ID]
+		if !ok {
+			return fmt.Errorf("category not found")
+		}
+		if err := d.requireCategory(category.ParentID); err != nil {
+			return err
+		}
+
+		updated := copyOf(existing)
+		updated.ParentID = category.ParentID
+		updated.Name = category.Name
+		if err := d.checkSiblingName(updated); err != nil {
+			return err
+		}
+		updated.UpdatedAt = time.Now()
+		d.categories[category.ID] = updated
+		*category = *copyOf(updated)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// DeleteCategory deletes a category without subcategories. Its expenses become
+// uncategorized.
+func (r *CategoryRepositoryMem) DeleteCategory(id int) error {
+	return r.db.write(func(d *data) error {
+		if _, ok := d.categories[id]; !ok {
+			return fmt.Errorf("category not found")
+		}
+		for _, category := range d.categories {
+			if category.ParentID != nil && *category.ParentID == id {
+				return fmt.Errorf("category %d still has subcategories", id)
+			}
+		}
+
+		d.deleteCategory(id)
+		return nil
+	})
+}
+
+// deleteCategory removes a category and clears it from its expenses and
+// recurring expenses
+func (d *data) deleteCategory(id int) {
+	delete(d.categories, id)
+	for expenseID, expense := range d.expenses {
+		if expense.CategoryID != nil && *expense.CategoryID == id {
+			updated := copyOf(expense)
+			updated.CategoryID = nil
+			d.expenses[expenseID] = updated
+		}
+	}
+	for recurringID, recurring := range d.recurring {
+		if recurring.CategoryID != nil && *recurring.CategoryID == id {
+			updated := copyOf(recurring)
+			updated.CategoryID = nil
+			d.recurring[recurringID] = updated
+		}
+	}
+}
+
+// checkSiblingName enforces the unique index on group, parent and lower-cased
+// name
+func (d *data) checkSiblingName(category *model.Category) error {
+	for _, other := range d.categories {
+		if other.ID != category.ID &&
+			sameID(other.GroupID, category.GroupID) &&
+			sameID(other.ParentID, category.ParentID) &&
+			strings.EqualFold(other.Name, category.Name) {
+			return fmt.Errorf("category %q already exists", category.Name)
+		}
+	}
+	return nil
+}
+
+// categoryTree returns the IDs of a category and all its subcategories
+func (d *data) categoryTree(id int) map[int]bool {
+	tree := map[int]bool{id: true}
+	for grew := true; grew; {
+		grew = false
+		for _, category := range d.categories {
+			if category.ParentID != nil && tree[*category.ParentID] && !tree[category.ID] {
+				tree[category.ID] = true
+				grew = true
+			}
+		}
+	}
+	return tree
+}
+
+// seedCategories inserts model.DefaultCategories, like the categories
+// migration: every top level category first, then the subcategories
+func (d *data) seedCategories() {
+	now := time.Now()
+	parentIDs := make([]int, len(model.DefaultCategories))
+	for i, def := range model.DefaultCategories {
+		parent := &model.Category{ID: d.nextID("categories"), Name: def.Name, CreatedAt: now, UpdatedAt: now}
+		d.categories[parent.ID] = parent
+		parentIDs[i] = parent.ID
+	}
+	for i, def := range model.DefaultCategories {
+		for _, name := range def.Children {
+			child := &model.Category{ID: d.nextID("categories"), ParentID: &parentIDs[i], Name: name, CreatedAt: now, UpdatedAt: now}
+			d.categories[child.ID] = child
+		}
+	}
+}
+
+// sameID compares nullable IDs, treating two nils as equal like the COALESCE in
+// the unique index
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,141 @@
+package repositorymem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type CommentRepositoryMem struct {
+	db accessor
+}
+
+func NewCommentRepositoryMem(store *Store) *CommentRepositoryMem {
+	return &CommentRepositoryMem{db: store}
+}
+
+func (r *CommentRepositoryMem) CreateComment(comment *model.Comment) (*model.Comment, error) {
+	err := r.db.write(func(d *data) error {
+		if (comment.ExpenseID == nil) == (comment.SettlementID == nil) {
+			return fmt.Errorf("a comment belongs to exactly one expense or settlement")
+		}
+		if comment.ExpenseID != nil {
+			if err := d.requireExpense(*comment.ExpenseID); err != nil {
+				return err
+			}
+		}
+		if comment.SettlementID != nil {
+			if _, ok := d.settlements[*comment.SettlementID]; !ok {
+				return fmt.Errorf("settlement %d does not exist", *comment.SettlementID)
+			}
+		}
+		if comment.AuthorID != nil {
+			if err := d.requireUser(*comment.AuthorID); err != nil {
+				return err
+			}
+		}
+
+		comment.ID = d.nextID("comments")
+		comment.CreatedAt = time.Now()
+		comment.UpdatedAt = comment.CreatedAt
+		d.comments[comment.ID] = copyOf(comment)
+		comment.AuthorName = d.userName(comment.AuthorID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+func (r *CommentRepositoryMem) GetCommentByID(id int) (*model.Comment, error) {
+	var comment *model.Comment
+	err := r.db.read(func(d *data) error {
+		existing, ok := d.comments[id]
+		if !ok {
+			return fmt.Errorf("comment not found")
+		}
+		comment = copyOf(existing)
+		comment.AuthorName = d.userName(comment.AuthorID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+func (r *CommentRepositoryMem) GetCommentsByExpenseID(expenseID int) ([]*model.Comment, error) {
+	return r.thread(func(c *model.Comment) bool {
+		return c.ExpenseID != nil && *c.ExpenseID == expenseID
+	}), nil
+}
+
+func (r *CommentRepositoryMem) GetCommentsBySettlementID(settlementID int) ([]*model.Comment, error) {
+	return r.thread(func(c *model.Comment) bool {
+		return c.SettlementID != nil && *c.SettlementID == settlementID
+	}), nil
+}
+
+// thread returns the comments that match keep, oldest first
+func (r *CommentRepositoryMem) thread(keep func(c *model.Comment) bool) []*model.Comment {
+	var comments []*model.Comment
+	r.db.read(func(d *data) error {
+		comments = collect(d.comments, keep, func(a, b *model.Comment) bool {
+			return newestFirst(b.CreatedAt, a.CreatedAt, b.ID, a.ID)
+		})
+		for _, comment := range comments {
+			comment.AuthorName = d.userName(comment.AuthorID)
+		}
+		return nil
+	})
+
+	return comments
+}
+
+func (r *CommentRepositoryMem) UpdateComment(comment *model.Comment) (*model.Comment, error) {
+	err := r.db.write(func(d *data) error {
+		existing, ok := d.comments[comment.ID]
+		if !ok {
+			return fmt.Errorf("comment not found")
+		}
+
+		updated := copyOf(existing)
+		updated.Body = comment.Body
+		updated.UpdatedAt = time.Now()
+		d.comments[comment.ID] = updated
+		*comment = *copyOf(updated)
+		comment.AuthorName = d.userName(comment.AuthorID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+func (r *CommentRepositoryMem) DeleteComment(id int) error {
+	return r.db.write(func(d *data) error {
+		if _, ok := d.comments[id]; !ok {
+			return fmt.Errorf("comment not found")
+		}
+		delete(d.comments, id)
+		return nil
+	})
+}
+
+// userName looks up the name of a comment's author or an activity's actor,
+// like the join on users in SQL
+func (d *data) userName(userID *int) string {
+	if userID == nil {
+		return ""
+	}
+	if user, ok := d.users[*userID]; ok {
+		return user.Name
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package repositorymem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type ExchangeRateRepositoryMem struct {
+	db accessor
+}
+
+func NewExchangeRateRepositoryMem(store *Store) *ExchangeRateRepositoryMem {
+	return &ExchangeRateRepositoryMem{db: store}
+}
+
+// UpsertRate stores a rate, replacing any rate already known for the same
+// currency pair and effective date
+func (r *ExchangeRateRepositoryMem) UpsertRate(rate *model.ExchangeRate) error {
+	return r.db.write(func(d *data) error {
+		if rate.Rate.IsZero() {
+			return fmt.Errorf("exchange rate must be positive")
+		}
+
+		stored := copyOf(rate)
+		stored.EffectiveDate = day(rate.EffectiveDate)
+		d.rates[rateKey{base: stored.BaseCurrency, quote: stored.QuoteCurrency, date: stored.EffectiveDate}] = stored
+		return nil
+	})
+}
+
+// GetRate returns the most recent rate converting base into quote that was in
+// effect on the given day. A stored rate for the opposite direction is inverted.
+func (r *ExchangeRateRepositoryMem) GetRate(base, quote string, on time.Time) (*model.ExchangeRate, error) {
+	var rate *model.ExchangeRate
+	err := r.db.read(func(d *data) error {
+		for key, candidate := range d.rates {
+			direct := key.base == base && key.quote == quote
+			reverse := key.base == quote && key.quote == base
+			if !direct && !reverse || key.date.After(on) {
+				continue
+			}
+
+			// Prefer the latest date, and the stored direction on a tie
+			if rate == nil || key.date.After(rate.EffectiveDate) ||
+				key.date.Equal(rate.EffectiveDate) && direct && rate.BaseCurrency != base {
+				rate = copyOf(candidate)
+			}
+		}
+
+		if rate == nil {
+			return fmt.Errorf("exchange rate not found")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if rate.BaseCurrency != base {
+		rate.BaseCurrency, rate.QuoteCurrency = base, quote
+		rate.Rate = rate.Rate.Inverse()
+	}
+
+	return rate, nil
+}
+
+func (r *ExchangeRateRepositoryMem) GetAllRates() ([]*model.ExchangeRate, error) {
+	var rates []*model.ExchangeRate
+	r.db.read(func(d *data) error {
+		rates = collect(d.rates, nil, func(a, b *model.ExchangeRate) bool {
+			if a.BaseCurrency != b.BaseCurrency {
+				return a.BaseCurrency < b.BaseCurrency
+			}
+			if a.QuoteCurrency != b.QuoteCurrency {
+				return a.QuoteCurrency < b.QuoteCurrency
+			}
+			return a.EffectiveDate.After(b.EffectiveDate)
+		})
+		return nil
+	})
+
+	return rates, nil
+}
+
+// day truncates a time to its date, as the DATE column does
+func day(t time.Time) time.Time {
+	year, month, date := t.Date()
+	return time.Date(year, month, date, 0, 0, 0, 0, time.UTC)
+}
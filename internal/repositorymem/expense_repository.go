@@ -0,0 +1,380 @@
+package repositorymem
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+type ExpenseRepositoryMem struct {
+	db accessor
+}
+
+func NewExpenseRepositoryMem(store *Store) *ExpenseRepositoryMem {
+	return &ExpenseRepositoryMem{db: store}
+}
+
+func (r *ExpenseRepositoryMem) CreateExpense(expense *model.Expense) (*model.Expense, error) {
+	err := r.db.write(func(d *data) error {
+		if err := d.requireGroup(expense.GroupID); err != nil {
+			return err
+		}
+		if err := d.requireUser(expense.PaidByID); err != nil {
+			return err
+		}
+		if err := d.requireCategory(expense.CategoryID); err != nil {
+			return err
+		}
+
+		expense.ID = d.nextID("expenses")
+		// Imported expenses keep the date they were originally recorded on
+		if expense.CreatedAt.IsZero() {
+			expense.CreatedAt = time.Now()
+		}
+		expense.UpdatedAt = time.Now()
+		d.expenses[expense.ID] = copyOf(expense)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return expense, nil
+}
+
+func (r *ExpenseRepositoryMem) GetExpenseByID(id int) (*model.Expense, error) {
+	var expense *model.Expense
+	err := r.db.read(func(d *data) error {
+		existing, ok := d.expenses[id]
+		if !ok || existing.DeletedAt != nil {
+			return fmt.Errorf("expense not found")
+		}
+		expense = copyOf(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return expense, nil
+}
+
+// LockExpense reads an expense, deleted or not. Transactions on the store are
+// serialized, so there is nothing to lock.
+func (r *ExpenseRepositoryMem) LockExpense(id int) (*model.Expense, error) {
+	var expense *model.Expense
+	err := r.db.read(func(d *data) error {
+		existing, ok := d.expenses[id]
+		if !ok {
+			return fmt.Errorf("expense not found")
+		}
+		expense = copyOf(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return expense, nil
+}
+
+func (r *ExpenseRepositoryMem) GetExpensesByGroupID(groupID int) ([]*model.Expense, error) {
+	var expenses []*model.Expense
+	r.db.read(func(d *data) error {
+		expenses = collect(d.expenses, func(e *model.Expense) bool { return e.DeletedAt == nil && e.GroupID == groupID }, newestExpenseFirst)
+		return nil
+	})
+
+	return expenses, nil
+}
+
+// GetExpensesByUserID returns the expenses the user paid
+func (r *ExpenseRepositoryMem) GetExpensesByUserID(userID int) ([]*model.Expense, error) {
+	var expenses []*model.Expense
+	r.db.read(func(d *data) error {
+		expenses = collect(d.expenses, func(e *model.Expense) bool { return e.DeletedAt == nil && e.PaidByID == userID }, newestExpenseFirst)
+		return nil
+	})
+
+	return expenses, nil
+}
+
+// UpdateExpense changes the payer, amounts, split mode, category and
+// description, like the SQL implementation
+func (r *ExpenseRepositoryMem) UpdateExpense(expense *model.Expense) (*model.Expense, error) {
+	err := r.db.write(func(d *data) error {
+		existing, ok := d.expenses[expense.ID]
+		if !ok || existing.DeletedAt != nil {
+			return fmt.Errorf("expense not found")
+		}
+		if err := d.requireUser(expense.PaidByID); err != nil {
+			return err
+		}
+		if err := d.requireCategory(expense.CategoryID); err != nil {
+			return err
+		}
+
+		updated := copyOf(existing)
+		updated.PaidByID = expense.PaidByID
+		updated.Amount = expense.Amount
+		updated.BaseAmount = expense.BaseAmount
+		updated.SplitMode = expense.SplitMode
+		updated.CategoryID = expense.CategoryID
+		updated.Description = expense.Description
+		updated.UpdatedAt = time.Now()
+		d.expenses[expense.ID] = updated
+		*expense = *copyOf(updated)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return expense, nil
+}
+
+// DeleteExpense soft-deletes an expense, keeping its splits, attachments and
+// comments for a restore
+func (r *ExpenseRepositoryMem) DeleteExpense(id int, deletedAt time.Time) error {
+	return r.db.write(func(d *data) error {
+		existing, ok := d.expenses[id]
+		if !ok || existing.DeletedAt != nil {
+			return fmt.Errorf("expense not found")
+		}
+
+		deleted := copyOf(existing)
+		deleted.DeletedAt = &deletedAt
+		d.expenses[id] = deleted
+		return nil
+	})
+}
+
+// DeleteGroupExpenses soft-deletes every expense of the group that is not
+// deleted yet
+func (r *ExpenseRepositoryMem) DeleteGroupExpenses(groupID int, deletedAt time.Time) error {
+	return r.db.write(func(d *data) error {
+		for id, expense := range d.expenses {
+			if expense.GroupID == groupID && expense.DeletedAt == nil {
+				deleted := copyOf(expense)
+				deleted.DeletedAt = &deletedAt
+				d.expenses[id] = deleted
+			}
+		}
+		return nil
+	})
+}
+
+func (r *ExpenseRepositoryMem) GetDeletedExpenseByID(id int) (*model.Expense, error) {
+	var expense *model.Expense
+	err := r.db.read(func(d *data) error {
+		existing, ok := d.expenses[id]
+		if !ok || existing.DeletedAt == nil {
+			return fmt.Errorf("deleted expense not found")
+		}
+		expense = copyOf(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return expense, nil
+}
+
+func (r *ExpenseRepositoryMem) RestoreExpense(id int) error {
+	return r.db.write(func(d *data) error {
+		existing, ok := d.expenses[id]
+		if !ok || existing.DeletedAt == nil {
+			return fmt.Errorf("deleted expense not found")
+		}
+
+		restored := copyOf(existing)
+		restored.DeletedAt = nil
+		d.expenses[id] = restored
+		return nil
+	})
+}
+
+// RestoreGroupExpenses restores the group's expenses deleted at deletedAt,
+// leaving the ones deleted on their own before the group
+func (r *ExpenseRepositoryMem) RestoreGroupExpenses(groupID int, deletedAt time.Time) error {
+	return r.db.write(func(d *data) error {
+		for id, expense := range d.expenses {
+			if expense.GroupID == groupID && expense.DeletedAt != nil && expense.DeletedAt.Equal(deletedAt) {
+				restored := copyOf(expense)
+				restored.DeletedAt = nil
+				d.expenses[id] = restored
+			}
+		}
+		return nil
+	})
+}
+
+// PurgeDeletedExpenses removes the expenses deleted before the given time,
+// together with their splits, attachments and comments
+func (r *ExpenseRepositoryMem) PurgeDeletedExpenses(before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.write(func(d *data) error {
+		for id, expense := range d.expenses {
+			if expense.DeletedAt != nil && expense.DeletedAt.Before(before) {
+				d.deleteExpense(id)
+				purged++
+			}
+		}
+		return nil
+	})
+
+	return purged, err
+}
+
+// deleteExpense removes an expense and cascades to its splits, attachments and
+// comments
+func (d *data) deleteExpense(id int) {
+	delete(d.expenses, id)
+	for splitID, split := range d.splits {
+		if split.ExpenseID == id {
+			delete(d.splits, splitID)
+		}
+	}
+	for attachmentID, attachment := range d.attachments {
+		if attachment.ExpenseID == id {
+			delete(d.attachments, attachmentID)
+		}
+	}
+	for commentID, comment := range d.comments {
+		if comment.ExpenseID != nil && *comment.ExpenseID == id {
+			delete(d.comments, commentID)
+		}
+	}
+}
+
+// IterateExpensesByGroupID streams the group's expenses, oldest first
+func (r *ExpenseRepositoryMem) IterateExpensesByGroupID(groupID int) (repository.Iterator[model.Expense], error) {
+	var expenses []*model.Expense
+	r.db.read(func(d *data) error {
+		expenses = collect(d.expenses, func(e *model.Expense) bool { return e.DeletedAt == nil && e.GroupID == groupID }, oldestExpenseFirst)
+		return nil
+	})
+
+	return &sliceIterator[model.Expense]{rows: expenses}, nil
+}
+
+func oldestExpenseFirst(a, b *model.Expense) bool {
+	return newestFirst(b.CreatedAt, a.CreatedAt, b.ID, a.ID)
+}
+
+func newestExpenseFirst(a, b *model.Expense) bool {
+	return newestFirst(a.CreatedAt, b.CreatedAt, a.ID, b.ID)
+}
+
+var expenseSortKinds = map[string]sortKind{
+	model.SortByCreatedAt:   sortTime,
+	model.SortByAmount:      sortInt,
+	model.SortByDescription: sortText,
+}
+
+// ListExpenses returns one page of expenses. query.GroupID limits it to a group
+// and query.UserID to the expenses that user paid.
+func (r *ExpenseRepositoryMem) ListExpenses(query *model.ListQuery) ([]*model.Expense, string, error) {
+	var expenses []*model.Expense
+	var next string
+	err := r.db.read(func(d *data) error {
+		var err error
+		expenses, next, err = listPage(d.expenses, d.expenseFilter(query), query, expenseSortKinds, model.ExpenseSortValue, func(e *model.Expense) int { return e.ID })
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return expenses, next, nil
+}
+
+// expenseFilter returns whether an expense matches the filters of a list query
+func (d *data) expenseFilter(query *model.ListQuery) func(e *model.Expense) bool {
+	var categories map[int]bool
+	if query.CategoryID != 0 {
+		categories = d.categoryTree(query.CategoryID)
+	}
+
+	return func(e *model.Expense) bool {
+		if e.DeletedAt != nil {
+			return false
+		}
+		if query.GroupID != 0 && e.GroupID != query.GroupID ||
+			query.UserID != 0 && e.PaidByID != query.UserID ||
+			query.PayerID != 0 && e.PaidByID != query.PayerID {
+			return false
+		}
+		if query.ParticipantID != 0 && !d.hasSplit(e.ID, query.ParticipantID) {
+			return false
+		}
+		if categories != nil && (e.CategoryID == nil || !categories[*e.CategoryID]) {
+			return false
+		}
+		return matchesLedgerFilters(query, e.CreatedAt, e.Amount, e.Description)
+	}
+}
+
+// SumExpensesByCategory totals every expense matching the query's filters per
+// category and currency, ignoring its cursor and limit. Amounts are in the
+// currency of each expense's group.
+func (r *ExpenseRepositoryMem) SumExpensesByCategory(query *model.ListQuery) ([]*model.CategoryTotal, error) {
+	type totalKey struct {
+		categoryID int
+		currency   string
+	}
+
+	var totals []*model.CategoryTotal
+	r.db.read(func(d *data) error {
+		keep := d.expenseFilter(query)
+		byKey := make(map[totalKey]*model.CategoryTotal)
+		for _, expense := range d.expenses {
+			if !keep(expense) {
+				continue
+			}
+
+			key := totalKey{currency: d.groups[expense.GroupID].Currency}
+			if expense.CategoryID != nil {
+				key.categoryID = *expense.CategoryID
+			}
+			total, ok := byKey[key]
+			if !ok {
+				total = &model.CategoryTotal{CategoryID: expense.CategoryID, Currency: key.currency}
+				if expense.CategoryID != nil {
+					total.CategoryName = d.categories[*expense.CategoryID].Name
+				}
+				byKey[key] = total
+				totals = append(totals, total)
+			}
+			total.Total += expense.BaseAmount
+			total.Count++
+		}
+
+		sort.Slice(totals, func(i, j int) bool {
+			a, b := totals[i], totals[j]
+			if a.Currency != b.Currency {
+				return a.Currency < b.Currency
+			}
+			if (a.CategoryID == nil) != (b.CategoryID == nil) {
+				return b.CategoryID == nil
+			}
+			return a.CategoryName < b.CategoryName
+		})
+		return nil
+	})
+
+	return totals, nil
+}
+
+func (d *data) hasSplit(expenseID, userID int) bool {
+	for _, split := range d.splits {
+		if split.ExpenseID == expenseID && split.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package repositorymem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+type ExpenseSplitRepositoryMem struct {
+	db accessor
+}
+
+func NewExpenseSplitRepositoryMem(store *Store) *ExpenseSplitRepositoryMem {
+	return &ExpenseSplitRepositoryMem{db: store}
+}
+
+func (r *ExpenseSplitRepositoryMem) CreateSplit(split *model.ExpenseSplit) (*model.ExpenseSplit, error) {
+	err := r.db.write(func(d *data) error {
+		if err := d.requireExpense(split.ExpenseID); err != nil {
+			return err
+		}
+		if err := d.requireUser(split.UserID); err != nil {
+			return err
+		}
+
+		split.ID = d.nextID("expense_splits")
+		split.CreatedAt = time.Now()
+		split.UpdatedAt = time.Now()
+		d.splits[split.ID] = copyOf(split)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return split, nil
+}
+
+func (r *ExpenseSplitRepositoryMem) GetSplitByID(id int) (*model.ExpenseSplit, error) {
+	var split *model.ExpenseSplit
+	err := r.db.read(func(d *data) error {
+		existing, ok := d.splits[id]
+		if !ok {
+			return fmt.Errorf("split not found")
+		}
+		split = copyOf(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return split, nil
+}
+
+func (r *ExpenseSplitRepositoryMem) GetSplitsByExpenseID(expenseID int) ([]*model.ExpenseSplit, error) {
+	var splits []*model.ExpenseSplit
+	r.db.read(func(d *data) error {
+		splits = collect(d.splits, func(s *model.ExpenseSplit) bool { return s.ExpenseID == expenseID }, newestSplitFirst)
+		return nil
+	})
+
+	return splits, nil
+}
+
+func (r *ExpenseSplitRepositoryMem) GetSplitsByUserID(userID int) ([]*model.ExpenseSplit, error) {
+	var splits []*model.ExpenseSplit
+	r.db.read(func(d *data) error {
+		splits = collect(d.splits, func(s *model.ExpenseSplit) bool {
+			expense, ok := d.expenses[s.ExpenseID]
+			return ok && expense.DeletedAt == nil && s.UserID == userID
+		}, newestSplitFirst)
+		return nil
+	})
+
+	return splits, nil
+}
+
+func (r *ExpenseSplitRepositoryMem) DeleteSplitsByExpenseID(expenseID int) error {
+	return r.db.write(func(d *data) error {
+		for splitID, split := range d.splits {
+			if split.ExpenseID == expenseID {
+				delete(d.splits, splitID)
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateSplit only changes the amounts and the weight, like the SQL
+// implementation
+func (r *ExpenseSplitRepositoryMem) UpdateSplit(split *model.ExpenseSplit) (*model.ExpenseSplit, error) {
+	err := r.db.write(func(d *data) error {
+		existing, ok := d.splits[split.ID]
+		if !ok {
+			return fmt.Errorf("split not found")
+		}
+
+		updated := copyOf(existing)
+		updated.Amount = split.Amount
+		updated.BaseAmount = split.BaseAmount
+		updated.Weight = split.Weight
+		updated.UpdatedAt = time.Now()
+		d.splits[split.ID] = updated
+		*split = *copyOf(updated)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return split, nil
+}
+
+// IterateSplitsByGroupID streams the splits of the group's expenses, in the
+// order of IterateExpensesByGroupID and by user within an expense
+func (r *ExpenseSplitRepositoryMem) IterateSplitsByGroupID(groupID int) (repository.Iterator[model.ExpenseSplit], error) {
+	var splits []*model.ExpenseSplit
+	r.db.read(func(d *data) error {
+		splits = collect(d.splits, func(s *model.ExpenseSplit) bool {
+			expense, ok := d.expenses[s.ExpenseID]
+			return ok && expense.DeletedAt == nil && expense.GroupID == groupID
+		}, func(a, b *model.ExpenseSplit) bool {
+			if a.ExpenseID != b.ExpenseID {
+				return oldestExpenseFirst(d.expenses[a.ExpenseID], d.expenses[b.ExpenseID])
+			}
+			return a.UserID < b.UserID
+		})
+		return nil
+	})
+
+	return &sliceIterator[model.ExpenseSplit]{rows: splits}, nil
+}
+
+func newestSplitFirst(a, b *model.ExpenseSplit) bool {
+	return newestFirst(a.CreatedAt, b.CreatedAt, a.ID, b.ID)
+}
@@ -0,0 +1,189 @@
+package repositorymem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type GroupMemberRepositoryMem struct {
+	db accessor
+}
+
+func NewGroupMemberRepositoryMem(store *Store) *GroupMemberRepositoryMem {
+	return &GroupMemberRepositoryMem{db: store}
+}
+
+func (r *GroupMemberRepositoryMem) AddMember(member *model.GroupMember) (*model.GroupMember, error) {
+	err := r.db.write(func(d *data) error {
+		if err := d.requireGroup(member.GroupID); err != nil {
+			return err
+		}
+		if err := d.requireUser(member.UserID); err != nil {
+			return err
+		}
+		if d.findMember(member.GroupID, member.UserID) != nil {
+			return fmt.Errorf("user %d is already a member of group %d", member.UserID, member.GroupID)
+		}
+
+		member.ID = d.nextID("group_members")
+		member.AddedAt = time.Now()
+		member.UpdatedAt = time.Now()
+		d.members[member.ID] = copyOf(member)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+func (r *GroupMemberRepositoryMem) RemoveMember(groupID, userID int) error {
+	return r.db.write(func(d *data) error {
+		member := d.findMember(groupID, userID)
+		if member == nil {
+			return fmt.Errorf("member not found")
+		}
+
+		delete(d.members, member.ID)
+		return nil
+	})
+}
+
+func (r *GroupMemberRepositoryMem) GetGroupMembers(groupID int) ([]*model.GroupMember, error) {
+	var members []*model.GroupMember
+	r.db.read(func(d *data) error {
+		members = collect(d.members, func(m *model.GroupMember) bool { return m.GroupID == groupID }, newestMemberFirst)
+		return nil
+	})
+
+	return members, nil
+}
+
+// GetGroupMembersWithDetails returns group members with user details (name and email)
+func (r *GroupMemberRepositoryMem) GetGroupMembersWithDetails(groupID int) ([]*model.GroupMemberResponse, error) {
+	var members []*model.GroupMemberResponse
+	r.db.read(func(d *data) error {
+		for _, member := range collect(d.members, func(m *model.GroupMember) bool { return m.GroupID == groupID }, newestMemberFirst) {
+			user, ok := d.users[member.UserID]
+			if !ok {
+				continue
+			}
+			members = append(members, &model.GroupMemberResponse{
+				ID:          member.ID,
+				GroupID:     member.GroupID,
+				UserID:      member.UserID,
+				UserName:    user.Name,
+				Email:       user.Email,
+				Placeholder: user.Placeholder,
+				Role:        member.Role,
+				AddedAt:     member.AddedAt,
+			})
+		}
+		return nil
+	})
+
+	return members, nil
+}
+
+func (r *GroupMemberRepositoryMem) GetUserGroups(userID int) ([]*model.GroupMember, error) {
+	var members []*model.GroupMember
+	r.db.read(func(d *data) error {
+		members = collect(d.members, func(m *model.GroupMember) bool { return m.UserID == userID }, newestMemberFirst)
+		return nil
+	})
+
+	return members, nil
+}
+
+func (r *GroupMemberRepositoryMem) IsMember(groupID, userID int) (bool, error) {
+	var isMember bool
+	r.db.read(func(d *data) error {
+		isMember = d.findMember(groupID, userID) != nil
+		return nil
+	})
+
+	return isMember, nil
+}
+
+// SharesGroup reports whether the two users are members of a same group
+func (r *GroupMemberRepositoryMem) SharesGroup(userID, otherID int) (bool, error) {
+	var shares bool
+	r.db.read(func(d *data) error {
+		groups := d.userGroupIDs(userID)
+		for _, member := range d.members {
+			if member.UserID == otherID && groups[member.GroupID] {
+				shares = true
+			}
+		}
+		return nil
+	})
+
+	return shares, nil
+}
+
+// GetMember returns a user's membership of a group, including their role
+func (r *GroupMemberRepositoryMem) GetMember(groupID, userID int) (*model.GroupMember, error) {
+	var member *model.GroupMember
+	err := r.db.read(func(d *data) error {
+		existing := d.findMember(groupID, userID)
+		if existing == nil {
+			return fmt.Errorf("member not found")
+		}
+		member = copyOf(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+func (r *GroupMemberRepositoryMem) UpdateMemberRole(groupID, userID int, role string) (*model.GroupMember, error) {
+	var member *model.GroupMember
+	err := r.db.write(func(d *data) error {
+		existing := d.findMember(groupID, userID)
+		if existing == nil {
+			return fmt.Errorf("member not found")
+		}
+
+		updated := copyOf(existing)
+		updated.Role = role
+		updated.UpdatedAt = time.Now()
+		d.members[updated.ID] = updated
+		member = copyOf(updated)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+func (d *data) findMember(groupID, userID int) *model.GroupMember {
+	for _, member := range d.members {
+		if member.GroupID == groupID && member.UserID == userID {
+			return member
+		}
+	}
+	return nil
+}
+
+// userGroupIDs returns the IDs of the groups the user is a member of
+func (d *data) userGroupIDs(userID int) map[int]bool {
+	groups := make(map[int]bool)
+	for _, member := range d.members {
+		if member.UserID == userID {
+			groups[member.GroupID] = true
+		}
+	}
+	return groups
+}
+
+func newestMemberFirst(a, b *model.GroupMember) bool {
+	return newestFirst(a.AddedAt, b.AddedAt, a.ID, b.ID)
+}
@@ -0,0 +1,216 @@
+package repositorymem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type GroupRepositoryMem struct {
+	db accessor
+}
+
+func NewGroupRepositoryMem(store *Store) *GroupRepositoryMem {
+	return &GroupRepositoryMem{db: store}
+}
+
+func (r *GroupRepositoryMem) CreateGroup(group *model.Group) (*model.Group, error) {
+	err := r.db.write(func(d *data) error {
+		if err := d.requireUser(group.CreatorID); err != nil {
+			return err
+		}
+
+		group.ID = d.nextID("groups")
+		group.CreatedAt = time.Now()
+		group.UpdatedAt = time.Now()
+		d.groups[group.ID] = copyOf(group)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (r *GroupRepositoryMem) GetGroupByID(id int) (*model.Group, error) {
+	var group *model.Group
+	err := r.db.read(func(d *data) error {
+		existing, ok := d.groups[id]
+		if !ok || existing.DeletedAt != nil {
+			return fmt.Errorf("group not found")
+		}
+		group = copyOf(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (r *GroupRepositoryMem) GetAllGroups() ([]*model.Group, error) {
+	var groups []*model.Group
+	r.db.read(func(d *data) error {
+		groups = collect(d.groups, func(g *model.Group) bool { return g.DeletedAt == nil }, newestGroupFirst)
+		return nil
+	})
+
+	return groups, nil
+}
+
+// GetGroupsByUserID returns the groups the user is a member of
+func (r *GroupRepositoryMem) GetGroupsByUserID(userID int) ([]*model.Group, error) {
+	var groups []*model.Group
+	r.db.read(func(d *data) error {
+		groupIDs := make(map[int]bool)
+		for _, member := range d.members {
+			if member.UserID == userID {
+				groupIDs[member.GroupID] = true
+			}
+		}
+
+		groups = collect(d.groups, func(g *model.Group) bool { return g.DeletedAt == nil && groupIDs[g.ID] }, newestGroupFirst)
+		return nil
+	})
+
+	return groups, nil
+}
+
+// UpdateGroup only changes the name and description, like the SQL implementation
+func (r *GroupRepositoryMem) UpdateGroup(group *model.Group) (*model.Group, error) {
+	err := r.db.write(func(d *data) error {
+		existing, ok := d.groups[group.ID]
+		if !ok || existing.DeletedAt != nil {
+			return fmt.Errorf("group not found")
+		}
+
+		updated := copyOf(existing)
+		updated.Name = group.Name
+		updated.Description = group.Description
+		updated.UpdatedAt = time.Now()
+		d.groups[group.ID] = updated
+		*group = *copyOf(updated)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// DeleteGroup soft-deletes a group. Its expenses and settlements are
+// soft-deleted separately, at the same time.
+func (r *GroupRepositoryMem) DeleteGroup(id int, deletedAt time.Time) error {
+	return r.db.write(func(d *data) error {
+		existing, ok := d.groups[id]
+		if !ok || existing.DeletedAt != nil {
+			return fmt.Errorf("group not found")
+		}
+
+		deleted := copyOf(existing)
+		deleted.DeletedAt = &deletedAt
+		d.groups[id] = deleted
+		return nil
+	})
+}
+
+func (r *GroupRepositoryMem) GetDeletedGroupByID(id int) (*model.Group, error) {
+	var group *model.Group
+	err := r.db.read(func(d *data) error {
+		existing, ok := d.groups[id]
+		if !ok || existing.DeletedAt == nil {
+			return fmt.Errorf("deleted group not found")
+		}
+		group = copyOf(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (r *GroupRepositoryMem) RestoreGroup(id int) error {
+	return r.db.write(func(d *data) error {
+		existing, ok := d.groups[id]
+		if !ok || existing.DeletedAt == nil {
+			return fmt.Errorf("deleted group not found")
+		}
+
+		restored := copyOf(existing)
+		restored.DeletedAt = nil
+		d.groups[id] = restored
+		return nil
+	})
+}
+
+// PurgeDeletedGroups removes the groups deleted before the given time
+func (r *GroupRepositoryMem) PurgeDeletedGroups(before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.write(func(d *data) error {
+		for id, group := range d.groups {
+			if group.DeletedAt != nil && group.DeletedAt.Before(before) {
+				d.deleteGroup(id)
+				purged++
+			}
+		}
+		return nil
+	})
+
+	return purged, err
+}
+
+// deleteGroup removes a group together with its members, expenses, splits,
+// settlements and balances, as ON DELETE CASCADE does in the schema
+func (d *data) deleteGroup(id int) {
+	delete(d.groups, id)
+	for memberID, member := range d.members {
+		if member.GroupID == id {
+			delete(d.members, memberID)
+		}
+	}
+	for expenseID, expense := range d.expenses {
+		if expense.GroupID == id {
+			d.deleteExpense(expenseID)
+		}
+	}
+	for settlementID, settlement := range d.settlements {
+		if settlement.GroupID == id {
+			d.deleteSettlement(settlementID)
+		}
+	}
+	for key := range d.balances {
+		if key.groupID == id {
+			delete(d.balances, key)
+		}
+	}
+	for categoryID, category := range d.categories {
+		if category.GroupID != nil && *category.GroupID == id {
+			d.deleteCategory(categoryID)
+		}
+	}
+	for invitationID, invitation := range d.invitations {
+		if invitation.GroupID == id {
+			delete(d.invitations, invitationID)
+		}
+	}
+	for recurringID, recurring := range d.recurring {
+		if recurring.GroupID == id {
+			delete(d.recurring, recurringID)
+		}
+	}
+	for activityID, activity := range d.activities {
+		if activity.GroupID == id {
+			delete(d.activities, activityID)
+		}
+	}
+}
+
+func newestGroupFirst(a, b *model.Group) bool {
+	return newestFirst(a.CreatedAt, b.CreatedAt, a.ID, b.ID)
+}
@@ -0,0 +1,107 @@
+package repositorymem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type InvitationRepositoryMem struct {
+	db accessor
+}
+
+func NewInvitationRepositoryMem(store *Store) *InvitationRepositoryMem {
+	return &InvitationRepositoryMem{db: store}
+}
+
+func (r *InvitationRepositoryMem) CreateInvitation(invitation *model.Invitation) (*model.Invitation, error) {
+	err := r.db.write(func(d *data) error {
+		if err := d.requireGroup(invitation.GroupID); err != nil {
+			return err
+		}
+		if err := d.requireUser(invitation.InvitedByID); err != nil {
+			return err
+		}
+		for _, existing := range d.invitations {
+			if existing.TokenHash == invitation.TokenHash {
+				return fmt.Errorf("invitation token already exists")
+			}
+		}
+
+		invitation.ID = d.nextID("group_invitations")
+		invitation.CreatedAt = time.Now()
+		d.invitations[invitation.ID] = copyOf(invitation)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (r *InvitationRepositoryMem) GetInvitationByID(id int) (*model.Invitation, error) {
+	var invitation *model.Invitation
+	err := r.db.read(func(d *data) error {
+		existing, ok := d.invitations[id]
+		if !ok {
+			return fmt.Errorf("invitation not found")
+		}
+		invitation = copyOf(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (r *InvitationRepositoryMem) GetInvitationByTokenHash(tokenHash string) (*model.Invitation, error) {
+	var invitation *model.Invitation
+	err := r.db.read(func(d *data) error {
+		for _, existing := range d.invitations {
+			if existing.TokenHash == tokenHash {
+				invitation = copyOf(existing)
+				return nil
+			}
+		}
+		return fmt.Errorf("invitation not found")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (r *InvitationRepositoryMem) GetInvitationsByGroupID(groupID int) ([]*model.Invitation, error) {
+	var invitations []*model.Invitation
+	r.db.read(func(d *data) error {
+		invitations = collect(d.invitations, func(i *model.Invitation) bool { return i.GroupID == groupID }, func(a, b *model.Invitation) bool {
+			return newestFirst(a.CreatedAt, b.CreatedAt, a.ID, b.ID)
+		})
+		return nil
+	})
+
+	return invitations, nil
+}
+
+// RespondToInvitation moves a pending invitation to status. It fails when the
+// invitation is no longer pending, so a token can only be used once.
+func (r *InvitationRepositoryMem) RespondToInvitation(id int, status string) error {
+	return r.db.write(func(d *data) error {
+		existing, ok := d.invitations[id]
+		if !ok || existing.Status != model.InvitationPending {
+			return fmt.Errorf("invitation is no longer pending")
+		}
+
+		now := time.Now()
+		updated := copyOf(existing)
+		updated.Status = status
+		updated.RespondedAt = &now
+		d.invitations[id] = updated
+		return nil
+	})
+}
@@ -0,0 +1,22 @@
+package repositorymem
+
+// sliceIterator hands out rows copied from the store when the iterator was
+// created, so that no lock is held while they are read
+type sliceIterator[T any] struct {
+	rows []*T
+}
+
+// Next returns the next row, or nil once every row has been read
+func (it *sliceIterator[T]) Next() (*T, error) {
+	if len(it.rows) == 0 {
+		return nil, nil
+	}
+	row := it.rows[0]
+	it.rows = it.rows[1:]
+	return row, nil
+}
+
+func (it *sliceIterator[T]) Close() error {
+	it.rows = nil
+	return nil
+}
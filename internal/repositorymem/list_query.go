@@ -0,0 +1,114 @@
+package repositorymem
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+// sortKind is how the values of a sort field compare
+type sortKind int
+
+const (
+	sortTime sortKind = iota
+	sortInt
+	sortText
+)
+
+// compareSortValues compares two sort values, as returned by the model's
+// SortValue functions, of the given kind
+func compareSortValues(kind sortKind, a, b string) int {
+	switch kind {
+	case sortTime:
+		aTime, _ := time.Parse(time.RFC3339Nano, a)
+		bTime, _ := time.Parse(time.RFC3339Nano, b)
+		return aTime.Compare(bTime)
+	case sortInt:
+		aInt, _ := strconv.ParseInt(a, 10, 64)
+		bInt, _ := strconv.ParseInt(b, 10, 64)
+		return compareInts(aInt, bInt)
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareInts[T int | int64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// matchesLedgerFilters checks a row against the date, amount and description
+// filters of the query
+func matchesLedgerFilters(query *model.ListQuery, createdAt time.Time, amount money.Amount, description string) bool {
+	if query.From != nil && createdAt.Before(*query.From) {
+		return false
+	}
+	if query.To != nil && !createdAt.Before(*query.To) {
+		return false
+	}
+	if query.MinAmount != nil && amount < *query.MinAmount {
+		return false
+	}
+	if query.MaxAmount != nil && amount > *query.MaxAmount {
+		return false
+	}
+	if query.Description != "" && !strings.Contains(strings.ToLower(description), strings.ToLower(query.Description)) {
+		return false
+	}
+	return true
+}
+
+// listPage sorts the rows of m that match keep as the query asks, and returns
+// the page following the query's cursor with the cursor of the next page, or ""
+// on the last page
+func listPage[T any](
+	m map[int]*T,
+	keep func(row *T) bool,
+	query *model.ListQuery,
+	kinds map[string]sortKind,
+	sortValue func(row *T, field string) string,
+	id func(row *T) int,
+) ([]*T, string, error) {
+	kind, ok := kinds[query.SortField]
+	if !ok {
+		return nil, "", fmt.Errorf("cannot sort by %q", query.SortField)
+	}
+
+	// compare orders a row against a sort value and ID in the list's order
+	compare := func(row *T, value string, rowID int) int {
+		c := compareSortValues(kind, sortValue(row, query.SortField), value)
+		if c == 0 {
+			c = compareInts(id(row), rowID)
+		}
+		if query.Descending() {
+			return -c
+		}
+		return c
+	}
+
+	rows := collect(m, func(row *T) bool {
+		if keep != nil && !keep(row) {
+			return false
+		}
+		return query.After == nil || compare(row, query.After.Value, query.After.ID) > 0
+	}, func(a, b *T) bool {
+		return compare(a, sortValue(b, query.SortField), id(b)) < 0
+	})
+
+	if len(rows) <= query.Limit {
+		return rows, "", nil
+	}
+
+	rows = rows[:query.Limit]
+	last := rows[len(rows)-1]
+	return rows, query.NextCursor(sortValue(last, query.SortField), id(last)), nil
+}
@@ -0,0 +1,157 @@
+package repositorymem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type RecurringExpenseRepositoryMem struct {
+	db accessor
+}
+
+func NewRecurringExpenseRepositoryMem(store *Store) *RecurringExpenseRepositoryMem {
+	return &RecurringExpenseRepositoryMem{db: store}
+}
+
+func (r *RecurringExpenseRepositoryMem) CreateRecurringExpense(recurring *model.RecurringExpense) (*model.RecurringExpense, error) {
+	err := r.db.write(func(d *data) error {
+		if err := d.requireGroup(recurring.GroupID); err != nil {
+			return err
+		}
+		if err := d.requireUser(recurring.CreatedByID); err != nil {
+			return err
+		}
+		if err := d.requireUser(recurring.PaidByID); err != nil {
+			return err
+		}
+		if err := d.requireCategory(recurring.CategoryID); err != nil {
+			return err
+		}
+
+		recurring.ID = d.nextID("recurring_expenses")
+		recurring.CreatedAt = time.Now()
+		recurring.UpdatedAt = time.Now()
+		d.recurring[recurring.ID] = copyRecurring(recurring)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recurring, nil
+}
+
+func (r *RecurringExpenseRepositoryMem) GetRecurringExpenseByID(id int) (*model.RecurringExpense, error) {
+	var recurring *model.RecurringExpense
+	err := r.db.read(func(d *data) error {
+		existing, ok := d.recurring[id]
+		if !ok {
+			return fmt.Errorf("recurring expense not found")
+		}
+		recurring = copyRecurring(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recurring, nil
+}
+
+func (r *RecurringExpenseRepositoryMem) GetRecurringExpensesByGroupID(groupID int) ([]*model.RecurringExpense, error) {
+	var recurring []*model.RecurringExpense
+	r.db.read(func(d *data) error {
+		recurring = collect(d.recurring, func(e *model.RecurringExpense) bool { return e.GroupID == groupID }, func(a, b *model.RecurringExpense) bool {
+			return newestFirst(a.CreatedAt, b.CreatedAt, a.ID, b.ID)
+		})
+		return nil
+	})
+
+	return copyRecurrings(recurring), nil
+}
+
+func (r *RecurringExpenseRepositoryMem) GetDueRecurringExpenses(now time.Time, limit int) ([]*model.RecurringExpense, error) {
+	var due []*model.RecurringExpense
+	r.db.read(func(d *data) error {
+		due = collect(d.recurring, func(e *model.RecurringExpense) bool {
+			return !e.Paused && e.NextRunAt != nil && !e.NextRunAt.After(now) && d.groups[e.GroupID].DeletedAt == nil
+		}, func(a, b *model.RecurringExpense) bool {
+			if !a.NextRunAt.Equal(*b.NextRunAt) {
+				return a.NextRunAt.Before(*b.NextRunAt)
+			}
+			return a.ID < b.ID
+		})
+		return nil
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return copyRecurrings(due), nil
+}
+
+// LockRecurringExpense reads a template. Transactions on the store are
+// serialized, so it never has to wait for a lock.
+func (r *RecurringExpenseRepositoryMem) LockRecurringExpense(id int) (*model.RecurringExpense, error) {
+	return r.GetRecurringExpenseByID(id)
+}
+
+func (r *RecurringExpenseRepositoryMem) UpdateRecurringExpense(recurring *model.RecurringExpense) (*model.RecurringExpense, error) {
+	err := r.db.write(func(d *data) error {
+		existing, ok := d.recurring[recurring.ID]
+		if !ok {
+			return fmt.Errorf("recurring expense not found")
+		}
+		if err := d.requireUser(recurring.PaidByID); err != nil {
+			return err
+		}
+		if err := d.requireCategory(recurring.CategoryID); err != nil {
+			return err
+		}
+
+		updated := copyRecurring(recurring)
+		updated.GroupID = existing.GroupID
+		updated.CreatedByID = existing.CreatedByID
+		updated.CreatedAt = existing.CreatedAt
+		updated.UpdatedAt = time.Now()
+		d.recurring[recurring.ID] = updated
+		*recurring = *copyRecurring(updated)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recurring, nil
+}
+
+func (r *RecurringExpenseRepositoryMem) DeleteRecurringExpense(id int) error {
+	return r.db.write(func(d *data) error {
+		if _, ok := d.recurring[id]; !ok {
+			return fmt.Errorf("recurring expense not found")
+		}
+		delete(d.recurring, id)
+		return nil
+	})
+}
+
+// copyRecurring copies a template along with its split spec, which copyOf
+// would share between the copies
+func copyRecurring(recurring *model.RecurringExpense) *model.RecurringExpense {
+	c := copyOf(recurring)
+	if recurring.Split != nil {
+		split := *recurring.Split
+		split.Participants = append([]model.SplitParticipant(nil), recurring.Split.Participants...)
+		c.Split = &split
+	}
+	return c
+}
+
+func copyRecurrings(recurring []*model.RecurringExpense) []*model.RecurringExpense {
+	for i, e := range recurring {
+		recurring[i] = copyRecurring(e)
+	}
+	return recurring
+}
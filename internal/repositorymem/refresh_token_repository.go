@@ -0,0 +1,89 @@
+package repositorymem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type RefreshTokenRepositoryMem struct {
+	db accessor
+}
+
+func NewRefreshTokenRepositoryMem(store *Store) *RefreshTokenRepositoryMem {
+	return &RefreshTokenRepositoryMem{db: store}
+}
+
+func (r *RefreshTokenRepositoryMem) CreateRefreshToken(token *model.RefreshToken) (*model.RefreshToken, error) {
+	err := r.db.write(func(d *data) error {
+		if err := d.requireUser(token.UserID); err != nil {
+			return err
+		}
+		for _, existing := range d.refreshTokens {
+			if existing.TokenHash == token.TokenHash {
+				return fmt.Errorf("refresh token already exists")
+			}
+		}
+
+		token.ID = d.nextID("refresh_tokens")
+		token.CreatedAt = time.Now()
+		d.refreshTokens[token.ID] = copyOf(token)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (r *RefreshTokenRepositoryMem) GetRefreshTokenByHash(tokenHash string) (*model.RefreshToken, error) {
+	var token *model.RefreshToken
+	err := r.db.read(func(d *data) error {
+		for _, existing := range d.refreshTokens {
+			if existing.TokenHash == tokenHash {
+				token = copyOf(existing)
+				return nil
+			}
+		}
+		return fmt.Errorf("refresh token not found")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// RevokeRefreshToken marks a token as revoked. Revoking an already revoked token
+// is an error, so a token can only be used once.
+func (r *RefreshTokenRepositoryMem) RevokeRefreshToken(id int) error {
+	return r.db.write(func(d *data) error {
+		existing, ok := d.refreshTokens[id]
+		if !ok || existing.RevokedAt != nil {
+			return fmt.Errorf("refresh token already revoked")
+		}
+
+		d.refreshTokens[id] = revoked(existing)
+		return nil
+	})
+}
+
+func (r *RefreshTokenRepositoryMem) RevokeUserRefreshTokens(userID int) error {
+	return r.db.write(func(d *data) error {
+		for id, token := range d.refreshTokens {
+			if token.UserID == userID && token.RevokedAt == nil {
+				d.refreshTokens[id] = revoked(token)
+			}
+		}
+		return nil
+	})
+}
+
+func revoked(token *model.RefreshToken) *model.RefreshToken {
+	now := time.Now()
+	updated := copyOf(token)
+	updated.RevokedAt = &now
+	return updated
+}
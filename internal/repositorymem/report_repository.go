@@ -0,0 +1,216 @@
+package repositorymem
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+type ReportRepositoryMem struct {
+	db accessor
+}
+
+func NewReportRepositoryMem(store *Store) *ReportRepositoryMem {
+	return &ReportRepositoryMem{db: store}
+}
+
+// reportLine is one amount a user paid, consumed, sent or received, like a row
+// of the entries the SQL implementation groups
+type reportLine struct {
+	settlement bool
+	groupID    int
+	at         time.Time
+	userID     int
+	categoryID *int
+
+	paid, consumed, sent, received money.Amount
+}
+
+// Aggregate sums expenses, splits and settlements matching the query, grouped by
+// the given dimensions and by currency, in the order of the dimensions
+func (r *ReportRepositoryMem) Aggregate(query *model.ReportQuery, dimensions []string) ([]*model.ReportEntry, error) {
+	for _, dimension := range dimensions {
+		switch dimension {
+		case model.ReportByPeriod:
+			if _, err := periodStart(time.Time{}, query.Bucket); err != nil {
+				return nil, err
+			}
+		case model.ReportByUser, model.ReportByGroup, model.ReportByCategory:
+		default:
+			return nil, fmt.Errorf("cannot group a report by %q", dimension)
+		}
+	}
+
+	var entries []*model.ReportEntry
+	r.db.read(func(d *data) error {
+		byKey := make(map[string]*model.ReportEntry)
+		for _, line := range d.reportLines() {
+			if !matchesReport(query, dimensions, line) {
+				continue
+			}
+
+			entry := &model.ReportEntry{Currency: d.groups[line.groupID].Currency}
+			for _, dimension := range dimensions {
+				switch dimension {
+				case model.ReportByPeriod:
+					start, _ := periodStart(line.at, query.Bucket)
+					entry.PeriodStart = &start
+				case model.ReportByUser:
+					userID := line.userID
+					entry.UserID = &userID
+					if user, ok := d.users[userID]; ok {
+						entry.UserName = user.Name
+					}
+				case model.ReportByGroup:
+					groupID := line.groupID
+					entry.GroupID = &groupID
+					entry.GroupName = d.groups[groupID].Name
+				case model.ReportByCategory:
+					entry.CategoryID = line.categoryID
+					if line.categoryID != nil {
+						entry.CategoryName = d.categories[*line.categoryID].Name
+					}
+				}
+			}
+
+			key := reportKey(entry)
+			if existing, ok := byKey[key]; ok {
+				entry = existing
+			} else {
+				byKey[key] = entry
+				entries = append(entries, entry)
+			}
+			entry.Paid += line.paid
+			entry.Consumed += line.consumed
+			entry.SettlementsSent += line.sent
+			entry.SettlementsReceived += line.received
+		}
+		return nil
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		return compareReportEntries(entries[i], entries[j], dimensions) < 0
+	})
+
+	return entries, nil
+}
+
+func (d *data) reportLines() []reportLine {
+	var lines []reportLine
+	for _, expense := range d.expenses {
+		if expense.DeletedAt != nil {
+			continue
+		}
+		lines = append(lines, reportLine{
+			groupID: expense.GroupID, at: expense.CreatedAt, userID: expense.PaidByID,
+			categoryID: expense.CategoryID, paid: expense.BaseAmount,
+		})
+	}
+	for _, split := range d.splits {
+		expense, ok := d.expenses[split.ExpenseID]
+		if !ok || expense.DeletedAt != nil {
+			continue
+		}
+		lines = append(lines, reportLine{
+			groupID: expense.GroupID, at: expense.CreatedAt, userID: split.UserID,
+			categoryID: expense.CategoryID, consumed: split.BaseAmount,
+		})
+	}
+	for _, settlement := range d.settlements {
+		if settlement.DeletedAt != nil {
+			continue
+		}
+		lines = append(lines,
+			reportLine{settlement: true, groupID: settlement.GroupID, at: settlement.CreatedAt, userID: settlement.FromUserID, sent: settlement.BaseAmount},
+			reportLine{settlement: true, groupID: settlement.GroupID, at: settlement.CreatedAt, userID: settlement.ToUserID, received: settlement.BaseAmount},
+		)
+	}
+	return lines
+}
+
+func matchesReport(query *model.ReportQuery, dimensions []string, line reportLine) bool {
+	if query.GroupID != 0 && line.groupID != query.GroupID ||
+		query.UserID != 0 && line.userID != query.UserID {
+		return false
+	}
+	if query.From != nil && line.at.Before(*query.From) ||
+		query.To != nil && !line.at.Before(*query.To) {
+		return false
+	}
+	for _, dimension := range dimensions {
+		if dimension == model.ReportByCategory && line.settlement {
+			return false
+		}
+	}
+	return true
+}
+
+// periodStart truncates t to the start of its bucket, like date_trunc. Weeks
+// start on Monday.
+func periodStart(t time.Time, bucket string) (time.Time, error) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch bucket {
+	case model.ReportBucketDay:
+		return day, nil
+	case model.ReportBucketWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)), nil
+	case model.ReportBucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid bucket %q", bucket)
+}
+
+func reportKey(entry *model.ReportEntry) string {
+	var key strings.Builder
+	if entry.PeriodStart != nil {
+		fmt.Fprintf(&key, "p%d;", entry.PeriodStart.Unix())
+	}
+	for _, id := range []*int{entry.UserID, entry.GroupID, entry.CategoryID} {
+		if id != nil {
+			fmt.Fprintf(&key, "%d;", *id)
+		} else {
+			key.WriteString("-;")
+		}
+	}
+	key.WriteString(entry.Currency)
+	return key.String()
+}
+
+// compareReportEntries orders entries by their dimensions and then currency,
+// with uncategorized expenses last like NULLs in SQL
+func compareReportEntries(a, b *model.ReportEntry, dimensions []string) int {
+	for _, dimension := range dimensions {
+		var c int
+		switch dimension {
+		case model.ReportByPeriod:
+			c = a.PeriodStart.Compare(*b.PeriodStart)
+		case model.ReportByUser:
+			c = compareInts(*a.UserID, *b.UserID)
+		case model.ReportByGroup:
+			c = compareInts(*a.GroupID, *b.GroupID)
+		case model.ReportByCategory:
+			c = compareNullableIDs(a.CategoryID, b.CategoryID)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.Currency, b.Currency)
+}
+
+func compareNullableIDs(a, b *int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return compareInts(*a, *b)
+}
@@ -0,0 +1,269 @@
+package repositorymem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+type SettlementRepositoryMem struct {
+	db accessor
+}
+
+func NewSettlementRepositoryMem(store *Store) *SettlementRepositoryMem {
+	return &SettlementRepositoryMem{db: store}
+}
+
+func (r *SettlementRepositoryMem) CreateSettlement(settlement *model.Settlement) (*model.Settlement, error) {
+	err := r.db.write(func(d *data) error {
+		if err := d.requireGroup(settlement.GroupID); err != nil {
+			return err
+		}
+		if err := d.requireUser(settlement.FromUserID); err != nil {
+			return err
+		}
+		if err := d.requireUser(settlement.ToUserID); err != nil {
+			return err
+		}
+
+		settlement.ID = d.nextID("settlements")
+		// Imported settlements keep the date they were originally recorded on
+		if settlement.CreatedAt.IsZero() {
+			settlement.CreatedAt = time.Now()
+		}
+		settlement.UpdatedAt = time.Now()
+		d.settlements[settlement.ID] = copyOf(settlement)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create settlement: %v", err)
+	}
+
+	return settlement, nil
+}
+
+func (r *SettlementRepositoryMem) GetSettlementByID(id int) (*model.Settlement, error) {
+	var settlement *model.Settlement
+	err := r.db.read(func(d *data) error {
+		existing, ok := d.settlements[id]
+		if !ok || existing.DeletedAt != nil {
+			return fmt.Errorf("settlement not found")
+		}
+		settlement = copyOf(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return settlement, nil
+}
+
+// LockSettlement reads a settlement, deleted or not. Transactions on the store
+// are serialized, so there is nothing to lock.
+func (r *SettlementRepositoryMem) LockSettlement(id int) (*model.Settlement, error) {
+	var settlement *model.Settlement
+	err := r.db.read(func(d *data) error {
+		existing, ok := d.settlements[id]
+		if !ok {
+			return fmt.Errorf("settlement not found")
+		}
+		settlement = copyOf(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return settlement, nil
+}
+
+func (r *SettlementRepositoryMem) GetSettlementsByGroupID(groupID int) ([]*model.Settlement, error) {
+	var settlements []*model.Settlement
+	r.db.read(func(d *data) error {
+		settlements = collect(d.settlements, func(s *model.Settlement) bool { return s.DeletedAt == nil && s.GroupID == groupID }, newestSettlementFirst)
+		return nil
+	})
+
+	return settlements, nil
+}
+
+// GetSettlementsByUserID returns the settlements the user sent or received
+func (r *SettlementRepositoryMem) GetSettlementsByUserID(userID int) ([]*model.Settlement, error) {
+	var settlements []*model.Settlement
+	r.db.read(func(d *data) error {
+		settlements = collect(d.settlements, func(s *model.Settlement) bool {
+			return s.DeletedAt == nil && (s.FromUserID == userID || s.ToUserID == userID)
+		}, newestSettlementFirst)
+		return nil
+	})
+
+	return settlements, nil
+}
+
+func (r *SettlementRepositoryMem) GetAllSettlements() ([]*model.Settlement, error) {
+	var settlements []*model.Settlement
+	r.db.read(func(d *data) error {
+		settlements = collect(d.settlements, func(s *model.Settlement) bool { return s.DeletedAt == nil }, newestSettlementFirst)
+		return nil
+	})
+
+	return settlements, nil
+}
+
+// IterateSettlementsByGroupID streams the group's settlements, oldest first
+func (r *SettlementRepositoryMem) IterateSettlementsByGroupID(groupID int) (repository.Iterator[model.Settlement], error) {
+	var settlements []*model.Settlement
+	r.db.read(func(d *data) error {
+		settlements = collect(d.settlements, func(s *model.Settlement) bool { return s.DeletedAt == nil && s.GroupID == groupID }, func(a, b *model.Settlement) bool {
+			return newestFirst(b.CreatedAt, a.CreatedAt, b.ID, a.ID)
+		})
+		return nil
+	})
+
+	return &sliceIterator[model.Settlement]{rows: settlements}, nil
+}
+
+// DeleteSettlement soft-deletes a settlement, keeping its comments for a restore
+func (r *SettlementRepositoryMem) DeleteSettlement(id int, deletedAt time.Time) error {
+	return r.db.write(func(d *data) error {
+		existing, ok := d.settlements[id]
+		if !ok || existing.DeletedAt != nil {
+			return fmt.Errorf("settlement not found")
+		}
+
+		deleted := copyOf(existing)
+		deleted.DeletedAt = &deletedAt
+		d.settlements[id] = deleted
+		return nil
+	})
+}
+
+// DeleteGroupSettlements soft-deletes every settlement of the group that is
+// not deleted yet
+func (r *SettlementRepositoryMem) DeleteGroupSettlements(groupID int, deletedAt time.Time) error {
+	return r.db.write(func(d *data) error {
+		for id, settlement := range d.settlements {
+			if settlement.GroupID == groupID && settlement.DeletedAt == nil {
+				deleted := copyOf(settlement)
+				deleted.DeletedAt = &deletedAt
+				d.settlements[id] = deleted
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SettlementRepositoryMem) GetDeletedSettlementByID(id int) (*model.Settlement, error) {
+	var settlement *model.Settlement
+	err := r.db.read(func(d *data) error {
+		existing, ok := d.settlements[id]
+		if !ok || existing.DeletedAt == nil {
+			return fmt.Errorf("deleted settlement not found")
+		}
+		settlement = copyOf(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return settlement, nil
+}
+
+func (r *SettlementRepositoryMem) RestoreSettlement(id int) error {
+	return r.db.write(func(d *data) error {
+		existing, ok := d.settlements[id]
+		if !ok || existing.DeletedAt == nil {
+			return fmt.Errorf("deleted settlement not found")
+		}
+
+		restored := copyOf(existing)
+		restored.DeletedAt = nil
+		d.settlements[id] = restored
+		return nil
+	})
+}
+
+// RestoreGroupSettlements restores the group's settlements deleted at
+// deletedAt, leaving the ones deleted on their own before the group
+func (r *SettlementRepositoryMem) RestoreGroupSettlements(groupID int, deletedAt time.Time) error {
+	return r.db.write(func(d *data) error {
+		for id, settlement := range d.settlements {
+			if settlement.GroupID == groupID && settlement.DeletedAt != nil && settlement.DeletedAt.Equal(deletedAt) {
+				restored := copyOf(settlement)
+				restored.DeletedAt = nil
+				d.settlements[id] = restored
+			}
+		}
+		return nil
+	})
+}
+
+// PurgeDeletedSettlements removes the settlements deleted before the given
+// time, together with their comments
+func (r *SettlementRepositoryMem) PurgeDeletedSettlements(before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.write(func(d *data) error {
+		for id, settlement := range d.settlements {
+			if settlement.DeletedAt != nil && settlement.DeletedAt.Before(before) {
+				d.deleteSettlement(id)
+				purged++
+			}
+		}
+		return nil
+	})
+
+	return purged, err
+}
+
+// deleteSettlement removes a settlement and cascades to its comments
+func (d *data) deleteSettlement(id int) {
+	delete(d.settlements, id)
+	for commentID, comment := range d.comments {
+		if comment.SettlementID != nil && *comment.SettlementID == id {
+			delete(d.comments, commentID)
+		}
+	}
+}
+
+func newestSettlementFirst(a, b *model.Settlement) bool {
+	return newestFirst(a.CreatedAt, b.CreatedAt, a.ID, b.ID)
+}
+
+var settlementSortKinds = map[string]sortKind{
+	model.SortByCreatedAt: sortTime,
+	model.SortByAmount:    sortInt,
+}
+
+// ListSettlements returns one page of settlements. query.GroupID limits it to a
+// group and query.UserID to the settlements that user sent or received.
+func (r *SettlementRepositoryMem) ListSettlements(query *model.ListQuery) ([]*model.Settlement, string, error) {
+	involves := func(s *model.Settlement, userID int) bool {
+		return s.FromUserID == userID || s.ToUserID == userID
+	}
+
+	var settlements []*model.Settlement
+	var next string
+	err := r.db.read(func(d *data) error {
+		var err error
+		settlements, next, err = listPage(d.settlements, func(s *model.Settlement) bool {
+			if s.DeletedAt != nil ||
+				query.GroupID != 0 && s.GroupID != query.GroupID ||
+				query.UserID != 0 && !involves(s, query.UserID) ||
+				query.PayerID != 0 && s.FromUserID != query.PayerID ||
+				query.ParticipantID != 0 && !involves(s, query.ParticipantID) {
+				return false
+			}
+			return matchesLedgerFilters(query, s.CreatedAt, s.Amount, s.Description)
+		}, query, settlementSortKinds, model.SettlementSortValue, func(s *model.Settlement) int { return s.ID })
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return settlements, next, nil
+}
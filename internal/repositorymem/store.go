@@ -0,0 +1,227 @@
+// Package repositorymem implements the repository interfaces in memory, so the
+// API can run without Postgres. It mirrors the behaviour of repositorypg: the
+// same orderings, the same "not found" errors, the same unique constraints and
+// the same cascading deletes as the SQL schema.
+package repositorymem
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+// rateKey identifies a stored exchange rate, like the unique constraint on the
+// exchange_rates table
+type rateKey struct {
+	base, quote string
+	date        time.Time
+}
+
+// pairKey identifies a row of the balances table: a pair of users in a group,
+// lower user ID first
+type pairKey struct {
+	groupID, low, high int
+}
+
+// data holds every table. Stored values are never modified in place: writes
+// replace them with a fresh copy, so that cloning the maps is enough to
+// snapshot the data for a transaction.
+type data struct {
+	users         map[int]*model.User
+	groups        map[int]*model.Group
+	members       map[int]*model.GroupMember
+	expenses      map[int]*model.Expense
+	splits        map[int]*model.ExpenseSplit
+	settlements   map[int]*model.Settlement
+	categories    map[int]*model.Category
+	rates         map[rateKey]*model.ExchangeRate
+	refreshTokens map[int]*model.RefreshToken
+	invitations   map[int]*model.Invitation
+	recurring     map[int]*model.RecurringExpense
+	attachments   map[int]*model.Attachment
+	comments      map[int]*model.Comment
+	activities    map[int]*model.Activity
+
+	// balances is what the pair's low user owes its high user, negative when
+	// the debt runs the other way
+	balances map[pairKey]money.Amount
+
+	// lastID is the last ID handed out per table, like a SERIAL sequence
+	lastID map[string]int
+}
+
+func newData() *data {
+	return &data{
+		users:         make(map[int]*model.User),
+		groups:        make(map[int]*model.Group),
+		members:       make(map[int]*model.GroupMember),
+		expenses:      make(map[int]*model.Expense),
+		splits:        make(map[int]*model.ExpenseSplit),
+		settlements:   make(map[int]*model.Settlement),
+		categories:    make(map[int]*model.Category),
+		rates:         make(map[rateKey]*model.ExchangeRate),
+		refreshTokens: make(map[int]*model.RefreshToken),
+		invitations:   make(map[int]*model.Invitation),
+		recurring:     make(map[int]*model.RecurringExpense),
+		attachments:   make(map[int]*model.Attachment),
+		comments:      make(map[int]*model.Comment),
+		activities:    make(map[int]*model.Activity),
+		balances:      make(map[pairKey]money.Amount),
+		lastID:        make(map[string]int),
+	}
+}
+
+func (d *data) clone() *data {
+	return &data{
+		users:         cloneMap(d.users),
+		groups:        cloneMap(d.groups),
+		members:       cloneMap(d.members),
+		expenses:      cloneMap(d.expenses),
+		splits:        cloneMap(d.splits),
+		settlements:   cloneMap(d.settlements),
+		categories:    cloneMap(d.categories),
+		rates:         cloneMap(d.rates),
+		refreshTokens: cloneMap(d.refreshTokens),
+		invitations:   cloneMap(d.invitations),
+		recurring:     cloneMap(d.recurring),
+		attachments:   cloneMap(d.attachments),
+		comments:      cloneMap(d.comments),
+		activities:    cloneMap(d.activities),
+		balances:      cloneMap(d.balances),
+		lastID:        cloneMap(d.lastID),
+	}
+}
+
+func (d *data) nextID(table string) int {
+	d.lastID[table]++
+	return d.lastID[table]
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	clone := make(map[K]V, len(m))
+	for k, v := range m {
+		clone[k] = v
+	}
+	return clone
+}
+
+// accessor gives repositories access to the data. The store takes its lock for
+// each call; inside a transaction the lock is already held for the whole
+// transaction.
+type accessor interface {
+	read(fn func(d *data) error) error
+	write(fn func(d *data) error) error
+}
+
+// Store is an in-memory database shared by the repositories created from it.
+// It is safe for concurrent use.
+type Store struct {
+	mu   sync.RWMutex
+	data *data
+}
+
+// NewStore returns a store holding the default global categories, like a
+// freshly migrated database
+func NewStore() *Store {
+	d := newData()
+	d.seedCategories()
+	return &Store{data: d}
+}
+
+func (s *Store) read(fn func(d *data) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(s.data)
+}
+
+func (s *Store) write(fn func(d *data) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Work on a copy so that a failed write leaves nothing behind, like a
+	// single SQL statement
+	d := s.data.clone()
+	if err := fn(d); err != nil {
+		return err
+	}
+	s.data = d
+	return nil
+}
+
+// txData is the copy of the data a transaction works on
+type txData struct {
+	data *data
+}
+
+func (t *txData) read(fn func(d *data) error) error {
+	return fn(t.data)
+}
+
+func (t *txData) write(fn func(d *data) error) error {
+	return fn(t.data)
+}
+
+// copyOf returns a copy of v, so callers cannot modify stored values
+func copyOf[T any](v *T) *T {
+	c := *v
+	return &c
+}
+
+// collect returns copies of the values in m that match keep, sorted by less
+func collect[K comparable, T any](m map[K]*T, keep func(v *T) bool, less func(a, b *T) bool) []*T {
+	var values []*T
+	for _, v := range m {
+		if keep == nil || keep(v) {
+			values = append(values, copyOf(v))
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return less(values[i], values[j]) })
+	return values
+}
+
+// newestFirst orders rows by a timestamp, most recent first. Rows created at the
+// same moment are ordered by ID so that results are stable.
+func newestFirst(aTime, bTime time.Time, aID, bID int) bool {
+	if !aTime.Equal(bTime) {
+		return aTime.After(bTime)
+	}
+	return aID > bID
+}
+
+// Foreign key checks, matching the REFERENCES constraints in the schema
+
+func (d *data) requireUser(id int) error {
+	if _, ok := d.users[id]; !ok {
+		return fmt.Errorf("user %d does not exist", id)
+	}
+	return nil
+}
+
+func (d *data) requireGroup(id int) error {
+	if _, ok := d.groups[id]; !ok {
+		return fmt.Errorf("group %d does not exist", id)
+	}
+	return nil
+}
+
+func (d *data) requireExpense(id int) error {
+	if _, ok := d.expenses[id]; !ok {
+		return fmt.Errorf("expense %d does not exist", id)
+	}
+	return nil
+}
+
+// requireCategory accepts a nil category ID, like a nullable foreign key
+func (d *data) requireCategory(id *int) error {
+	if id == nil {
+		return nil
+	}
+	if _, ok := d.categories[*id]; !ok {
+		return fmt.Errorf("category %d does not exist", *id)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package repositorymem
+
+import (
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+type UnitOfWorkMem struct {
+	Store *Store
+}
+
+func NewUnitOfWorkMem(store *Store) *UnitOfWorkMem {
+	return &UnitOfWorkMem{Store: store}
+}
+
+// Do runs fn against a copy of the data while holding the store's write lock,
+// and keeps the copy only when fn succeeds. Transactions are therefore
+// serialized; repositories created outside fn must not be used inside it.
+func (u *UnitOfWorkMem) Do(fn func(tx repository.Tx) error) error {
+	u.Store.mu.Lock()
+	defer u.Store.mu.Unlock()
+
+	tx := &txMem{db: &txData{data: u.Store.data.clone()}}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	u.Store.data = tx.db.data
+	return nil
+}
+
+// View runs fn against a copy of the data taken under the store's read lock.
+// The lock is released before fn runs, so a slow reader does not hold up
+// writers; whatever fn writes is thrown away.
+func (u *UnitOfWorkMem) View(fn func(tx repository.Tx) error) error {
+	u.Store.mu.RLock()
+	snapshot := u.Store.data.clone()
+	u.Store.mu.RUnlock()
+
+	return fn(&txMem{db: &txData{data: snapshot}})
+}
+
+// txMem hands out repositories bound to one transaction's copy of the data
+type txMem struct {
+	db *txData
+}
+
+func (t *txMem) Users() repository.UserRepository {
+	return &UserRepositoryMem{db: t.db}
+}
+
+func (t *txMem) Groups() repository.GroupRepository {
+	return &GroupRepositoryMem{db: t.db}
+}
+
+func (t *txMem) Members() repository.GroupMemberRepository {
+	return &GroupMemberRepositoryMem{db: t.db}
+}
+
+func (t *txMem) Expenses() repository.ExpenseRepository {
+	return &ExpenseRepositoryMem{db: t.db}
+}
+
+func (t *txMem) Splits() repository.ExpenseSplitRepository {
+	return &ExpenseSplitRepositoryMem{db: t.db}
+}
+
+func (t *txMem) Settlements() repository.SettlementRepository {
+	return &SettlementRepositoryMem{db: t.db}
+}
+
+func (t *txMem) Balances() repository.BalanceRepository {
+	return &BalanceRepositoryMem{db: t.db}
+}
+
+func (t *txMem) Invitations() repository.InvitationRepository {
+	return &InvitationRepositoryMem{db: t.db}
+}
+
+func (t *txMem) RecurringExpenses() repository.RecurringExpenseRepository {
+	return &RecurringExpenseRepositoryMem{db: t.db}
+}
+
+func (t *txMem) Activities() repository.ActivityRepository {
+	return &ActivityRepositoryMem{db: t.db}
+}
+
+func (t *txMem) Categories() repository.CategoryRepository {
+	return &CategoryRepositoryMem{db: t.db}
+}
@@ -0,0 +1,407 @@
+package repositorymem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type UserRepositoryMem struct {
+	db accessor
+}
+
+func NewUserRepositoryMem(store *Store) *UserRepositoryMem {
+	return &UserRepositoryMem{db: store}
+}
+
+func (r *UserRepositoryMem) CreateUser(user *model.User) (*model.User, error) {
+	user.Email = model.NormalizeEmail(user.Email)
+	err := r.db.write(func(d *data) error {
+		for _, existing := range d.users {
+			if existing.Email == user.Email {
+				return fmt.Errorf("user with email already exists")
+			}
+		}
+
+		user.ID = d.nextID("users")
+		user.CreatedAt = time.Now()
+		user.UpdatedAt = time.Now()
+		d.users[user.ID] = copyOf(user)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *UserRepositoryMem) GetUserByEmail(email string) (*model.User, error) {
+	email = model.NormalizeEmail(email)
+	var user *model.User
+	err := r.db.read(func(d *data) error {
+		for _, existing := range d.users {
+			if existing.Email == email {
+				user = copyOf(existing)
+				return nil
+			}
+		}
+		return fmt.Errorf("user not found")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *UserRepositoryMem) GetUserByID(id int) (*model.User, error) {
+	var user *model.User
+	err := r.db.read(func(d *data) error {
+		existing, ok := d.users[id]
+		if !ok {
+			return fmt.Errorf("user not found")
+		}
+		user = copyOf(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *UserRepositoryMem) GetAllUsers() ([]*model.User, error) {
+	var users []*model.User
+	r.db.read(func(d *data) error {
+		users = collect(d.users, nil, func(a, b *model.User) bool {
+			return newestFirst(a.CreatedAt, b.CreatedAt, a.ID, b.ID)
+		})
+		return nil
+	})
+
+	return users, nil
+}
+
+// UpdateUser only changes the user's name, like the SQL implementation
+func (r *UserRepositoryMem) UpdateUser(user *model.User) (*model.User, error) {
+	err := r.db.write(func(d *data) error {
+		existing, ok := d.users[user.ID]
+		if !ok {
+			return fmt.Errorf("user not found")
+		}
+
+		updated := copyOf(existing)
+		updated.Name = user.Name
+		updated.UpdatedAt = time.Now()
+		d.users[user.ID] = updated
+		*user = *copyOf(updated)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RegisterPlaceholder gives a placeholder user a name and password, keeping
+// their history
+func (r *UserRepositoryMem) RegisterPlaceholder(user *model.User) (*model.User, error) {
+	err := r.db.write(func(d *data) error {
+		existing, ok := d.users[user.ID]
+		if !ok || !existing.Placeholder {
+			return fmt.Errorf("placeholder not found")
+		}
+
+		updated := copyOf(existing)
+		updated.Name = user.Name
+		updated.PasswordHash = user.PasswordHash
+		updated.Placeholder = false
+		updated.UpdatedAt = time.Now()
+		d.users[user.ID] = updated
+		*user = *copyOf(updated)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// MergeUser moves the history and memberships of fromID to intoID and deletes
+// fromID, like the SQL implementation: splits of an expense both users share
+// are added up, settlements between the two become payments to oneself, and
+// memberships of a group both belong to keep the role of intoID. The balances
+// of the groups fromID has ledger rows in are left stale, to be rebuilt from
+// the ledger.
+func (r *UserRepositoryMem) MergeUser(fromID, intoID int) error {
+	return r.db.write(func(d *data) error {
+		if err := d.requireUser(fromID); err != nil {
+			return err
+		}
+		if err := d.requireUser(intoID); err != nil {
+			return err
+		}
+
+		kept := make(map[int]*model.ExpenseSplit)
+		for _, split := range d.splits {
+			if split.UserID == intoID {
+				kept[split.ExpenseID] = split
+			}
+		}
+		for splitID, split := range d.splits {
+			if split.UserID != fromID {
+				continue
+			}
+			if into, ok := kept[split.ExpenseID]; ok {
+				merged := copyOf(into)
+				merged.Amount += split.Amount
+				merged.BaseAmount += split.BaseAmount
+				merged.Weight += split.Weight
+				merged.UpdatedAt = time.Now()
+				d.splits[merged.ID] = merged
+				delete(d.splits, splitID)
+				continue
+			}
+			moved := copyOf(split)
+			moved.UserID = intoID
+			moved.UpdatedAt = time.Now()
+			d.splits[splitID] = moved
+		}
+
+		for expenseID, expense := range d.expenses {
+			if expense.PaidByID == fromID {
+				moved := copyOf(expense)
+				moved.PaidByID = intoID
+				moved.UpdatedAt = time.Now()
+				d.expenses[expenseID] = moved
+			}
+		}
+
+		for settlementID, settlement := range d.settlements {
+			if settlement.FromUserID != fromID && settlement.ToUserID != fromID {
+				continue
+			}
+			moved := copyOf(settlement)
+			if moved.FromUserID == fromID {
+				moved.FromUserID = intoID
+			}
+			if moved.ToUserID == fromID {
+				moved.ToUserID = intoID
+			}
+			moved.UpdatedAt = time.Now()
+			d.settlements[settlementID] = moved
+		}
+
+		for groupID, group := range d.groups {
+			if group.CreatorID == fromID {
+				moved := copyOf(group)
+				moved.CreatorID = intoID
+				moved.UpdatedAt = time.Now()
+				d.groups[groupID] = moved
+			}
+		}
+
+		for memberID, member := range d.members {
+			if member.UserID != fromID {
+				continue
+			}
+			if d.findMember(member.GroupID, intoID) != nil {
+				delete(d.members, memberID)
+				continue
+			}
+			moved := copyOf(member)
+			moved.UserID = intoID
+			moved.UpdatedAt = time.Now()
+			d.members[memberID] = moved
+		}
+
+		for invitationID, invitation := range d.invitations {
+			if invitation.InvitedByID == fromID {
+				moved := copyOf(invitation)
+				moved.InvitedByID = intoID
+				d.invitations[invitationID] = moved
+			}
+		}
+
+		for recurringID, recurring := range d.recurring {
+			if recurring.PaidByID != fromID && recurring.CreatedByID != fromID {
+				continue
+			}
+			moved := copyOf(recurring)
+			if moved.PaidByID == fromID {
+				moved.PaidByID = intoID
+			}
+			if moved.CreatedByID == fromID {
+				moved.CreatedByID = intoID
+			}
+			moved.UpdatedAt = time.Now()
+			d.recurring[recurringID] = moved
+		}
+
+		for attachmentID, attachment := range d.attachments {
+			if attachment.UploadedByID != nil && *attachment.UploadedByID == fromID {
+				moved := copyOf(attachment)
+				moved.UploadedByID = &intoID
+				d.attachments[attachmentID] = moved
+			}
+		}
+
+		for commentID, comment := range d.comments {
+			if comment.AuthorID != nil && *comment.AuthorID == fromID {
+				moved := copyOf(comment)
+				moved.AuthorID = &intoID
+				d.comments[commentID] = moved
+			}
+		}
+
+		for activityID, activity := range d.activities {
+			if activity.ActorID != nil && *activity.ActorID == fromID {
+				moved := copyOf(activity)
+				moved.ActorID = &intoID
+				d.activities[activityID] = moved
+			}
+		}
+
+		for tokenID, token := range d.refreshTokens {
+			if token.UserID == fromID {
+				delete(d.refreshTokens, tokenID)
+			}
+		}
+		for key := range d.balances {
+			if key.low == fromID || key.high == fromID {
+				delete(d.balances, key)
+			}
+		}
+		delete(d.users, fromID)
+		return nil
+	})
+}
+
+// DeleteUser removes a user with their memberships, settlements, refresh tokens,
+// the invitations they sent, their recurring expenses and balances. Like the foreign keys in the schema,
+// it refuses to delete a user who created a group, paid an expense or has a
+// split.
+func (r *UserRepositoryMem) DeleteUser(id int) error {
+	return r.db.write(func(d *data) error {
+		if _, ok := d.users[id]; !ok {
+			return fmt.Errorf("user not found")
+		}
+
+		for _, group := range d.groups {
+			if group.CreatorID == id {
+				return fmt.Errorf("user is still referenced by group %d", group.ID)
+			}
+		}
+		for _, expense := range d.expenses {
+			if expense.PaidByID == id {
+				return fmt.Errorf("user is still referenced by expense %d", expense.ID)
+			}
+		}
+		for _, split := range d.splits {
+			if split.UserID == id {
+				return fmt.Errorf("user is still referenced by split %d", split.ID)
+			}
+		}
+
+		delete(d.users, id)
+		for memberID, member := range d.members {
+			if member.UserID == id {
+				delete(d.members, memberID)
+			}
+		}
+		for settlementID, settlement := range d.settlements {
+			if settlement.FromUserID == id || settlement.ToUserID == id {
+				d.deleteSettlement(settlementID)
+			}
+		}
+		for tokenID, token := range d.refreshTokens {
+			if token.UserID == id {
+				delete(d.refreshTokens, tokenID)
+			}
+		}
+		for invitationID, invitation := range d.invitations {
+			if invitation.InvitedByID == id {
+				delete(d.invitations, invitationID)
+			}
+		}
+		for recurringID, recurring := range d.recurring {
+			if recurring.PaidByID == id || recurring.CreatedByID == id {
+				delete(d.recurring, recurringID)
+			}
+		}
+		for attachmentID, attachment := range d.attachments {
+			if attachment.UploadedByID != nil && *attachment.UploadedByID == id {
+				kept := copyOf(attachment)
+				kept.UploadedByID = nil
+				d.attachments[attachmentID] = kept
+			}
+		}
+		for commentID, comment := range d.comments {
+			if comment.AuthorID != nil && *comment.AuthorID == id {
+				kept := copyOf(comment)
+				kept.AuthorID = nil
+				d.comments[commentID] = kept
+			}
+		}
+		for activityID, activity := range d.activities {
+			if activity.ActorID != nil && *activity.ActorID == id {
+				kept := copyOf(activity)
+				kept.ActorID = nil
+				d.activities[activityID] = kept
+			}
+		}
+		for key := range d.balances {
+			if key.low == id || key.high == id {
+				delete(d.balances, key)
+			}
+		}
+		return nil
+	})
+}
+
+var userSortKinds = map[string]sortKind{
+	model.SortByCreatedAt: sortTime,
+	model.SortByName:      sortText,
+	model.SortByEmail:     sortText,
+}
+
+// ListUsers returns one page of users. Only query.UserID and the date range of
+// the query apply.
+func (r *UserRepositoryMem) ListUsers(query *model.ListQuery) ([]*model.User, string, error) {
+	var users []*model.User
+	var next string
+	err := r.db.read(func(d *data) error {
+		visible := make(map[int]bool)
+		if query.UserID != 0 {
+			visible[query.UserID] = true
+			groups := d.userGroupIDs(query.UserID)
+			for _, member := range d.members {
+				if groups[member.GroupID] {
+					visible[member.UserID] = true
+				}
+			}
+		}
+
+		var err error
+		users, next, err = listPage(d.users, func(u *model.User) bool {
+			if query.UserID != 0 && !visible[u.ID] {
+				return false
+			}
+			if query.From != nil && u.CreatedAt.Before(*query.From) {
+				return false
+			}
+			return query.To == nil || u.CreatedAt.Before(*query.To)
+		}, query, userSortKinds, model.UserSortValue, func(u *model.User) int { return u.ID })
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return users, next, nil
+}
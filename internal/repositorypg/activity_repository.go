@@ -0,0 +1,110 @@
+package repositorypg
+
+import (
+	"log"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type ActivityRepositoryPG struct {
+	DB DBTX
+}
+
+func NewActivityRepositoryPG(db DBTX) *ActivityRepositoryPG {
+	return &ActivityRepositoryPG{DB: db}
+}
+
+var activitySortColumns = map[string]sortColumn{
+	model.SortByCreatedAt: {expr: "a.created_at", parse: parseTimeValue},
+}
+
+func (r *ActivityRepositoryPG) CreateActivity(activity *model.Activity) (*model.Activity, error) {
+	query := `
+		INSERT INTO activity_log (group_id, actor_id, action, entity_type, entity_id, changes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, COALESCE((SELECT name FROM users WHERE users.id = activity_log.actor_id), '')
+	`
+
+	if activity.Changes == nil {
+		activity.Changes = []byte("{}")
+	}
+	activity.CreatedAt = time.Now()
+
+	err := r.DB.QueryRow(
+		query,
+		activity.GroupID,
+		activity.ActorID,
+		activity.Action,
+		activity.EntityType,
+		activity.EntityID,
+		string(activity.Changes),
+		activity.CreatedAt,
+	).Scan(&activity.ID, &activity.ActorName)
+
+	if err != nil {
+		log.Printf("Error creating activity: %v", err)
+		return nil, err
+	}
+
+	return activity, nil
+}
+
+func (r *ActivityRepositoryPG) ListActivities(query *model.ListQuery) ([]*model.Activity, string, error) {
+	b := &listBuilder{}
+	b.where("a.group_id = " + b.arg(query.GroupID))
+	if query.From != nil {
+		b.where("a.created_at >= " + b.arg(*query.From))
+	}
+	if query.To != nil {
+		b.where("a.created_at < " + b.arg(*query.To))
+	}
+
+	clauses, err := b.build(query, activitySortColumns, "a.id")
+	if err != nil {
+		return nil, "", err
+	}
+
+	sqlQuery := `
+		SELECT a.id, a.group_id, a.actor_id, COALESCE(u.name, ''), a.action, a.entity_type, a.entity_id, a.changes, a.created_at
+		FROM activity_log a
+		LEFT JOIN users u ON u.id = a.actor_id` + clauses
+
+	rows, err := r.DB.Query(sqlQuery, b.args...)
+	if err != nil {
+		log.Printf("Error listing activities: %v", err)
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var activities []*model.Activity
+	for rows.Next() {
+		activity := &model.Activity{}
+		var changes []byte
+		err := rows.Scan(
+			&activity.ID,
+			&activity.GroupID,
+			&activity.ActorID,
+			&activity.ActorName,
+			&activity.Action,
+			&activity.EntityType,
+			&activity.EntityID,
+			&changes,
+			&activity.CreatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning activity: %v", err)
+			return nil, "", err
+		}
+		activity.Changes = changes
+		activities = append(activities, activity)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating activities: %v", err)
+		return nil, "", err
+	}
+
+	activities, next := nextCursor(activities, query, model.ActivitySortValue, func(a *model.Activity) int { return a.ID })
+	return activities, next, nil
+}
@@ -0,0 +1,192 @@
+package repositorypg
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type AttachmentRepositoryPG struct {
+	DB DBTX
+}
+
+func NewAttachmentRepositoryPG(db DBTX) *AttachmentRepositoryPG {
+	return &AttachmentRepositoryPG{DB: db}
+}
+
+func (r *AttachmentRepositoryPG) CreateAttachment(attachment *model.Attachment) (*model.Attachment, error) {
+	query := `
+		INSERT INTO expense_attachments (expense_id, uploaded_by_id, file_name, content_type, size_bytes, storage_key, thumbnail_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, expense_id, uploaded_by_id, file_name, content_type, size_bytes, storage_key, thumbnail_key, created_at
+	`
+
+	attachment.CreatedAt = time.Now()
+
+	err := r.DB.QueryRow(
+		query,
+		attachment.ExpenseID,
+		attachment.UploadedByID,
+		attachment.FileName,
+		attachment.ContentType,
+		attachment.Size,
+		attachment.StorageKey,
+		attachment.ThumbnailKey,
+		attachment.CreatedAt,
+	).Scan(&attachment.ID, &attachment.ExpenseID, &attachment.UploadedByID, &attachment.FileName, &attachment.ContentType,
+		&attachment.Size, &attachment.StorageKey, &attachment.ThumbnailKey, &attachment.CreatedAt)
+
+	if err != nil {
+		log.Printf("Error creating attachment: %v", err)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (r *AttachmentRepositoryPG) GetAttachmentByID(id int) (*model.Attachment, error) {
+	query := `
+		SELECT id, expense_id, uploaded_by_id, file_name, content_type, size_bytes, storage_key, thumbnail_key, created_at
+		FROM expense_attachments
+		WHERE id = $1
+	`
+
+	attachment := &model.Attachment{}
+	err := r.DB.QueryRow(query, id).Scan(
+		&attachment.ID,
+		&attachment.ExpenseID,
+		&attachment.UploadedByID,
+		&attachment.FileName,
+		&attachment.ContentType,
+		&attachment.Size,
+		&attachment.StorageKey,
+		&attachment.ThumbnailKey,
+		&attachment.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		log.Printf("Error getting attachment by ID: %v", err)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (r *AttachmentRepositoryPG) GetAttachmentsByExpenseID(expenseID int) ([]*model.Attachment, error) {
+	query := `
+		SELECT id, expense_id, uploaded_by_id, file_name, content_type, size_bytes, storage_key, thumbnail_key, created_at
+		FROM expense_attachments
+		WHERE expense_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.DB.Query(query, expenseID)
+	if err != nil {
+		log.Printf("Error getting attachments: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []*model.Attachment
+	for rows.Next() {
+		attachment := &model.Attachment{}
+		err := rows.Scan(
+			&attachment.ID,
+			&attachment.ExpenseID,
+			&attachment.UploadedByID,
+			&attachment.FileName,
+			&attachment.ContentType,
+			&attachment.Size,
+			&attachment.StorageKey,
+			&attachment.ThumbnailKey,
+			&attachment.CreatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning attachment: %v", err)
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating attachments: %v", err)
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// GetExpiredAttachments returns the attachments of expenses deleted before the
+// given time, directly or with their group
+func (r *AttachmentRepositoryPG) GetExpiredAttachments(before time.Time) ([]*model.Attachment, error) {
+	query := `
+		SELECT a.id, a.expense_id, a.uploaded_by_id, a.file_name, a.content_type, a.size_bytes, a.storage_key, a.thumbnail_key, a.created_at
+		FROM expense_attachments a
+		JOIN expenses e ON e.id = a.expense_id
+		JOIN groups g ON g.id = e.group_id
+		WHERE e.deleted_at < $1 OR g.deleted_at < $1
+		ORDER BY a.id
+	`
+
+	rows, err := r.DB.Query(query, before)
+	if err != nil {
+		log.Printf("Error getting expired attachments: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []*model.Attachment
+	for rows.Next() {
+		attachment := &model.Attachment{}
+		err := rows.Scan(
+			&attachment.ID,
+			&attachment.ExpenseID,
+			&attachment.UploadedByID,
+			&attachment.FileName,
+			&attachment.ContentType,
+			&attachment.Size,
+			&attachment.StorageKey,
+			&attachment.ThumbnailKey,
+			&attachment.CreatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning attachment: %v", err)
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating expired attachments: %v", err)
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func (r *AttachmentRepositoryPG) DeleteAttachment(id int) error {
+	query := `DELETE FROM expense_attachments WHERE id = $1`
+
+	result, err := r.DB.Exec(query, id)
+	if err != nil {
+		log.Printf("Error deleting attachment: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("attachment not found")
+	}
+
+	return nil
+}
@@ -1,32 +1,63 @@
 package repositorypg
 
 import (
-	"database/sql"
 	"log"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
 )
 
+// The balances table holds one row per pair of users in a group, with the lower
+// user ID in from_user_id. amount is what from_user_id owes to_user_id in the
+// group currency, and is negative when the debt runs the other way.
+
+// ledgerPairsQuery computes the pairwise balances of group $1 from the ledger:
+// every split owed to another member's expense, reduced by the settlements paid
+// between the same two people, leaving out soft-deleted rows. Each row is a
+// pair in balances table order.
+const ledgerPairsQuery = `
+	WITH debts AS (
+		SELECT es.user_id AS from_user_id, e.paid_by_id AS to_user_id, es.base_amount AS amount
+		FROM expense_splits es
+		JOIN expenses e ON e.id = es.expense_id
+		WHERE e.group_id = $1 AND e.deleted_at IS NULL AND es.user_id <> e.paid_by_id
+		UNION ALL
+		SELECT s.to_user_id, s.from_user_id, s.base_amount
+		FROM settlements s
+		WHERE s.group_id = $1 AND s.deleted_at IS NULL
+	)
+	SELECT LEAST(from_user_id, to_user_id) AS low_id,
+		GREATEST(from_user_id, to_user_id) AS high_id,
+		SUM(CASE WHEN from_user_id < to_user_id THEN amount ELSE -amount END)::BIGINT AS net
+	FROM debts
+	WHERE from_user_id <> to_user_id
+	GROUP BY low_id, high_id
+	HAVING SUM(CASE WHEN from_user_id < to_user_id THEN amount ELSE -amount END) <> 0
+`
+
 type BalanceRepositoryPG struct {
-	DB *sql.DB
+	DB DBTX
 }
 
-func NewBalanceRepositoryPG(db *sql.DB) *BalanceRepositoryPG {
+func NewBalanceRepositoryPG(db DBTX) *BalanceRepositoryPG {
 	return &BalanceRepositoryPG{DB: db}
 }
 
-func (r *BalanceRepositoryPG) GetBalance(userID, groupID int) (float64, error) {
+func (r *BalanceRepositoryPG) GetBalance(userID, groupID int) (money.Amount, error) {
 	return r.GetUserBalanceInGroup(userID, groupID)
 }
 
-func (r *BalanceRepositoryPG) GetUserBalanceInGroup(userID, groupID int) (float64, error) {
+// GetUserBalanceInGroup returns the user's net balance in the group (positive =
+// owed by the group)
+func (r *BalanceRepositoryPG) GetUserBalanceInGroup(userID, groupID int) (money.Amount, error) {
 	query := `
-		SELECT COALESCE(SUM(es.amount), 0) - COALESCE(SUM(CASE WHEN e.paid_by_id = $1 THEN e.amount ELSE 0 END), 0)
-		FROM expenses e
-		LEFT JOIN expense_splits es ON e.id = es.expense_id
-		WHERE e.group_id = $2 AND es.user_id = $1
+		SELECT COALESCE(SUM(CASE WHEN to_user_id = $2 THEN amount ELSE -amount END), 0)::BIGINT
+		FROM balances
+		WHERE group_id = $1 AND (from_user_id = $2 OR to_user_id = $2)
 	`
 
-	var balance float64
-	err := r.DB.QueryRow(query, userID, groupID).Scan(&balance)
+	var balance money.Amount
+	err := r.DB.QueryRow(query, groupID, userID).Scan(&balance)
 	if err != nil {
 		log.Printf("Error getting balance: %v", err)
 		return 0, err
@@ -35,17 +66,19 @@ func (r *BalanceRepositoryPG) GetUserBalanceInGroup(userID, groupID int) (float6
 	return balance, nil
 }
 
-func (r *BalanceRepositoryPG) GetGroupBalances(groupID int) (map[int]float64, error) {
+// GetGroupBalances returns the net balance of every current member of the
+// group, plus any former member who still has balances in it.
+func (r *BalanceRepositoryPG) GetGroupBalances(groupID int) (map[int]money.Amount, error) {
 	query := `
-		SELECT DISTINCT u.id,
-			COALESCE(SUM(CASE WHEN e.paid_by_id != u.id THEN es.amount ELSE 0 END), 0) - 
-			COALESCE(SUM(CASE WHEN e.paid_by_id = u.id THEN e.amount ELSE 0 END), 0) as balance
-		FROM users u
-		JOIN group_members gm ON u.id = gm.user_id
-		LEFT JOIN expense_splits es ON u.id = es.user_id
-		LEFT JOIN expenses e ON es.expense_id = e.id AND e.group_id = $1
-		WHERE gm.group_id = $1
-		GROUP BY u.id
+		SELECT user_id, COALESCE(SUM(amount), 0)::BIGINT AS balance
+		FROM (
+			SELECT to_user_id AS user_id, amount FROM balances WHERE group_id = $1
+			UNION ALL
+			SELECT from_user_id, -amount FROM balances WHERE group_id = $1
+			UNION ALL
+			SELECT gm.user_id, 0 FROM group_members gm WHERE gm.group_id = $1
+		) entries
+		GROUP BY user_id
 	`
 
 	rows, err := r.DB.Query(query, groupID)
@@ -55,10 +88,10 @@ func (r *BalanceRepositoryPG) GetGroupBalances(groupID int) (map[int]float64, er
 	}
 	defer rows.Close()
 
-	balances := make(map[int]float64)
+	balances := make(map[int]money.Amount)
 	for rows.Next() {
 		var userID int
-		var balance float64
+		var balance money.Amount
 		err := rows.Scan(&userID, &balance)
 		if err != nil {
 			log.Printf("Error scanning balance: %v", err)
@@ -75,8 +108,108 @@ func (r *BalanceRepositoryPG) GetGroupBalances(groupID int) (map[int]float64, er
 	return balances, nil
 }
 
+// GetPairwiseBalances returns the unsimplified debts between each pair of users
+// in the group. Pairs that net to zero are omitted.
+func (r *BalanceRepositoryPG) GetPairwiseBalances(groupID int) ([]*model.PairwiseBalance, error) {
+	query := `
+		SELECT from_user_id, to_user_id, amount
+		FROM balances
+		WHERE group_id = $1 AND amount <> 0
+		ORDER BY from_user_id, to_user_id
+	`
+
+	return r.queryPairs(groupID, query)
+}
+
+// GetLedgerPairwiseBalances computes the same pairs as GetPairwiseBalances from
+// the expenses, splits and settlements of the group
+func (r *BalanceRepositoryPG) GetLedgerPairwiseBalances(groupID int) ([]*model.PairwiseBalance, error) {
+	return r.queryPairs(groupID, ledgerPairsQuery+` ORDER BY low_id, high_id`)
+}
+
+// queryPairs runs a query returning (low user ID, high user ID, signed amount)
+// rows and turns them into debts that are always positive
+func (r *BalanceRepositoryPG) queryPairs(groupID int, query string) ([]*model.PairwiseBalance, error) {
+	rows, err := r.DB.Query(query, groupID)
+	if err != nil {
+		log.Printf("Error getting pairwise balances: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []*model.PairwiseBalance
+	for rows.Next() {
+		var lowID, highID int
+		var net money.Amount
+		if err := rows.Scan(&lowID, &highID, &net); err != nil {
+			log.Printf("Error scanning pairwise balance: %v", err)
+			return nil, err
+		}
+
+		balance := &model.PairwiseBalance{GroupID: groupID, FromUserID: lowID, ToUserID: highID, Amount: net}
+		if net < 0 {
+			balance.FromUserID, balance.ToUserID, balance.Amount = highID, lowID, -net
+		}
+		balances = append(balances, balance)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating pairwise balances: %v", err)
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+// AdjustBalance adds amount to what fromUserID owes toUserID in the group
+func (r *BalanceRepositoryPG) AdjustBalance(groupID, fromUserID, toUserID int, amount money.Amount) error {
+	if fromUserID == toUserID || amount == 0 {
+		return nil
+	}
+	if fromUserID > toUserID {
+		fromUserID, toUserID, amount = toUserID, fromUserID, -amount
+	}
+
+	query := `
+		INSERT INTO balances (group_id, from_user_id, to_user_id, amount, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (group_id, from_user_id, to_user_id)
+		DO UPDATE SET amount = balances.amount + EXCLUDED.amount, updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.DB.Exec(query, groupID, fromUserID, toUserID, amount)
+	if err != nil {
+		log.Printf("Error adjusting balance: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// CalculateBalances replaces the group's balances with ones recomputed from its
+// ledger. It must run in a transaction: the table lock makes concurrent ledger
+// changes wait until the rebuild is committed, so none of them is lost or
+// counted twice.
 func (r *BalanceRepositoryPG) CalculateBalances(groupID int) error {
-	// This would typically involve more complex calculations
-	// For now, we're using the query-based approach above
+	if _, err := r.DB.Exec(`LOCK TABLE balances IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		log.Printf("Error locking balances: %v", err)
+		return err
+	}
+
+	if _, err := r.DB.Exec(`DELETE FROM balances WHERE group_id = $1`, groupID); err != nil {
+		log.Printf("Error clearing balances: %v", err)
+		return err
+	}
+
+	query := `
+		INSERT INTO balances (group_id, from_user_id, to_user_id, amount, created_at, updated_at)
+		SELECT $1::INTEGER, low_id, high_id, net, NOW(), NOW()
+		FROM (` + ledgerPairsQuery + `) pairs
+	`
+	if _, err := r.DB.Exec(query, groupID); err != nil {
+		log.Printf("Error rebuilding balances: %v", err)
+		return err
+	}
+
 	return nil
 }
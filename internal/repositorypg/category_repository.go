@@ -0,0 +1,168 @@
+package repositorypg
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type CategoryRepositoryPG struct {
+	DB DBTX
+}
+
+func NewCategoryRepositoryPG(db DBTX) *CategoryRepositoryPG {
+	return &CategoryRepositoryPG{DB: db}
+}
+
+func (r *CategoryRepositoryPG) CreateCategory(category *model.Category) (*model.Category, error) {
+	query := `
+		INSERT INTO categories (group_id, parent_id, name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, group_id, parent_id, name, created_at, updated_at
+	`
+
+	category.CreatedAt = time.Now()
+	category.UpdatedAt = time.Now()
+
+	err := r.DB.QueryRow(
+		query,
+		category.GroupID,
+		category.ParentID,
+		category.Name,
+		category.CreatedAt,
+		category.UpdatedAt,
+	).Scan(&category.ID, &category.GroupID, &category.ParentID, &category.Name, &category.CreatedAt, &category.UpdatedAt)
+
+	if err != nil {
+		log.Printf("Error creating category: %v", err)
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (r *CategoryRepositoryPG) GetCategoryByID(id int) (*model.Category, error) {
+	query := `
+		SELECT id, group_id, parent_id, name, created_at, updated_at
+		FROM categories
+		WHERE id = $1
+	`
+
+	category := &model.Category{}
+	err := r.DB.QueryRow(query, id).Scan(
+		&category.ID,
+		&category.GroupID,
+		&category.ParentID,
+		&category.Name,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("category not found")
+		}
+		log.Printf("Error getting category by ID: %v", err)
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// GetCategoriesForGroup returns the global categories followed by the group's
+// own, each ordered by name
+func (r *CategoryRepositoryPG) GetCategoriesForGroup(groupID int) ([]*model.Category, error) {
+	query := `
+		SELECT id, group_id, parent_id, name, created_at, updated_at
+		FROM categories
+		WHERE group_id IS NULL OR group_id = $1
+		ORDER BY group_id NULLS FIRST, LOWER(name), id
+	`
+
+	rows, err := r.DB.Query(query, groupID)
+	if err != nil {
+		log.Printf("Error getting categories: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*model.Category
+	for rows.Next() {
+		category := &model.Category{}
+		err := rows.Scan(
+			&category.ID,
+			&category.GroupID,
+			&category.ParentID,
+			&category.Name,
+			&category.CreatedAt,
+			&category.UpdatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning category: %v", err)
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating categories: %v", err)
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (r *CategoryRepositoryPG) UpdateCategory(category *model.Category) (*model.Category, error) {
+	query := `
+		UPDATE categories
+		SET parent_id = $1, name = $2, updated_at = $3
+		WHERE id = $4
+		RETURNING id, group_id, parent_id, name, created_at, updated_at
+	`
+
+	category.UpdatedAt = time.Now()
+
+	err := r.DB.QueryRow(
+		query,
+		category.ParentID,
+		category.Name,
+		category.UpdatedAt,
+		category.ID,
+	).Scan(&category.ID, &category.GroupID, &category.ParentID, &category.Name, &category.CreatedAt, &category.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("category not found")
+		}
+		log.Printf("Error updating category: %v", err)
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// DeleteCategory deletes a category without subcategories. Its expenses become
+// uncategorized.
+func (r *CategoryRepositoryPG) DeleteCategory(id int) error {
+	query := `DELETE FROM categories WHERE id = $1`
+
+	result, err := r.DB.Exec(query, id)
+	if err != nil {
+		log.Printf("Error deleting category: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("category not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package repositorypg
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type CommentRepositoryPG struct {
+	DB DBTX
+}
+
+func NewCommentRepositoryPG(db DBTX) *CommentRepositoryPG {
+	return &CommentRepositoryPG{DB: db}
+}
+
+func (r *CommentRepositoryPG) CreateComment(comment *model.Comment) (*model.Comment, error) {
+	query := `
+		INSERT INTO comments (expense_id, settlement_id, author_id, body, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, expense_id, settlement_id, author_id, COALESCE((SELECT name FROM users WHERE users.id = comments.author_id), ''), body, created_at, updated_at
+	`
+
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = comment.CreatedAt
+
+	err := r.DB.QueryRow(
+		query,
+		comment.ExpenseID,
+		comment.SettlementID,
+		comment.AuthorID,
+		comment.Body,
+		comment.CreatedAt,
+		comment.UpdatedAt,
+	).Scan(&comment.ID, &comment.ExpenseID, &comment.SettlementID, &comment.AuthorID, &comment.AuthorName, &comment.Body, &comment.CreatedAt, &comment.UpdatedAt)
+
+	if err != nil {
+		log.Printf("Error creating comment: %v", err)
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+func (r *CommentRepositoryPG) GetCommentByID(id int) (*model.Comment, error) {
+	query := `
+		SELECT c.id, c.expense_id, c.settlement_id, c.author_id, COALESCE(u.name, ''), c.body, c.created_at, c.updated_at
+		FROM comments c
+		LEFT JOIN users u ON u.id = c.author_id
+		WHERE c.id = $1
+	`
+
+	comment := &model.Comment{}
+	err := r.DB.QueryRow(query, id).Scan(
+		&comment.ID,
+		&comment.ExpenseID,
+		&comment.SettlementID,
+		&comment.AuthorID,
+		&comment.AuthorName,
+		&comment.Body,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("comment not found")
+		}
+		log.Printf("Error getting comment by ID: %v", err)
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+func (r *CommentRepositoryPG) GetCommentsByExpenseID(expenseID int) ([]*model.Comment, error) {
+	query := `
+		SELECT c.id, c.expense_id, c.settlement_id, c.author_id, COALESCE(u.name, ''), c.body, c.created_at, c.updated_at
+		FROM comments c
+		LEFT JOIN users u ON u.id = c.author_id
+		WHERE c.expense_id = $1
+		ORDER BY c.created_at, c.id
+	`
+
+	rows, err := r.DB.Query(query, expenseID)
+	if err != nil {
+		log.Printf("Error getting comments: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []*model.Comment
+	for rows.Next() {
+		comment := &model.Comment{}
+		err := rows.Scan(
+			&comment.ID,
+			&comment.ExpenseID,
+			&comment.SettlementID,
+			&comment.AuthorID,
+			&comment.AuthorName,
+			&comment.Body,
+			&comment.CreatedAt,
+			&comment.UpdatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning comment: %v", err)
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating comments: %v", err)
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (r *CommentRepositoryPG) GetCommentsBySettlementID(settlementID int) ([]*model.Comment, error) {
+	query := `
+		SELECT c.id, c.expense_id, c.settlement_id, c.author_id, COALESCE(u.name, ''), c.body, c.created_at, c.updated_at
+		FROM comments c
+		LEFT JOIN users u ON u.id = c.author_id
+		WHERE c.settlement_id = $1
+		ORDER BY c.created_at, c.id
+	`
+
+	rows, err := r.DB.Query(query, settlementID)
+	if err != nil {
+		log.Printf("Error getting comments: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []*model.Comment
+	for rows.Next() {
+		comment := &model.Comment{}
+		err := rows.Scan(
+			&comment.ID,
+			&comment.ExpenseID,
+			&comment.SettlementID,
+			&comment.AuthorID,
+			&comment.AuthorName,
+			&comment.Body,
+			&comment.CreatedAt,
+			&comment.UpdatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning comment: %v", err)
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating comments: %v", err)
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (r *CommentRepositoryPG) UpdateComment(comment *model.Comment) (*model.Comment, error) {
+	query := `
+		UPDATE comments
+		SET body = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING id, expense_id, settlement_id, author_id, COALESCE((SELECT name FROM users WHERE users.id = comments.author_id), ''), body, created_at, updated_at
+	`
+
+	comment.UpdatedAt = time.Now()
+
+	err := r.DB.QueryRow(query, comment.Body, comment.UpdatedAt, comment.ID).Scan(&comment.ID, &comment.ExpenseID, &comment.SettlementID, &comment.AuthorID, &comment.AuthorName, &comment.Body, &comment.CreatedAt, &comment.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("comment not found")
+		}
+		log.Printf("Error updating comment: %v", err)
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+func (r *CommentRepositoryPG) DeleteComment(id int) error {
+	query := `DELETE FROM comments WHERE id = $1`
+
+	result, err := r.DB.Exec(query, id)
+	if err != nil {
+		log.Printf("Error deleting comment: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("comment not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package repositorypg
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type ExchangeRateRepositoryPG struct {
+	DB DBTX
+}
+
+func NewExchangeRateRepositoryPG(db DBTX) *ExchangeRateRepositoryPG {
+	return &ExchangeRateRepositoryPG{DB: db}
+}
+
+// UpsertRate stores a rate, replacing any rate already known for the same
+// currency pair and effective date
+func (r *ExchangeRateRepositoryPG) UpsertRate(rate *model.ExchangeRate) error {
+	query := `
+		INSERT INTO exchange_rates (base_currency, quote_currency, rate, effective_date)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (base_currency, quote_currency, effective_date)
+		DO UPDATE SET rate = EXCLUDED.rate
+	`
+
+	_, err := r.DB.Exec(query, rate.BaseCurrency, rate.QuoteCurrency, rate.Rate, rate.EffectiveDate)
+	if err != nil {
+		log.Printf("Error upserting exchange rate: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetRate returns the most recent rate converting base into quote that was in
+// effect on the given day. A stored rate for the opposite direction is inverted.
+func (r *ExchangeRateRepositoryPG) GetRate(base, quote string, on time.Time) (*model.ExchangeRate, error) {
+	query := `
+		SELECT base_currency, quote_currency, rate, effective_date
+		FROM exchange_rates
+		WHERE ((base_currency = $1 AND quote_currency = $2) OR (base_currency = $2 AND quote_currency = $1))
+			AND effective_date <= $3
+		ORDER BY effective_date DESC, (base_currency = $1) DESC
+		LIMIT 1
+	`
+
+	rate := &model.ExchangeRate{}
+	err := r.DB.QueryRow(query, base, quote, on).Scan(
+		&rate.BaseCurrency,
+		&rate.QuoteCurrency,
+		&rate.Rate,
+		&rate.EffectiveDate,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("exchange rate not found")
+		}
+		log.Printf("Error getting exchange rate: %v", err)
+		return nil, err
+	}
+
+	if rate.BaseCurrency != base {
+		rate.BaseCurrency, rate.QuoteCurrency = base, quote
+		rate.Rate = rate.Rate.Inverse()
+	}
+
+	return rate, nil
+}
+
+func (r *ExchangeRateRepositoryPG) GetAllRates() ([]*model.ExchangeRate, error) {
+	query := `
+		SELECT base_currency, quote_currency, rate, effective_date
+		FROM exchange_rates
+		ORDER BY base_currency, quote_currency, effective_date DESC
+	`
+
+	rows, err := r.DB.Query(query)
+	if err != nil {
+		log.Printf("Error getting exchange rates: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []*model.ExchangeRate
+	for rows.Next() {
+		rate := &model.ExchangeRate{}
+		err := rows.Scan(
+			&rate.BaseCurrency,
+			&rate.QuoteCurrency,
+			&rate.Rate,
+			&rate.EffectiveDate,
+		)
+		if err != nil {
+			log.Printf("Error scanning exchange rate: %v", err)
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating exchange rates: %v", err)
+		return nil, err
+	}
+
+	return rates, nil
+}
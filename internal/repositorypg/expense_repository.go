@@ -7,24 +7,28 @@ import (
 	"time"
 
 	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
 )
 
 type ExpenseRepositoryPG struct {
-	DB *sql.DB
+	DB DBTX
 }
 
-func NewExpenseRepositoryPG(db *sql.DB) *ExpenseRepositoryPG {
+func NewExpenseRepositoryPG(db DBTX) *ExpenseRepositoryPG {
 	return &ExpenseRepositoryPG{DB: db}
 }
 
 func (r *ExpenseRepositoryPG) CreateExpense(expense *model.Expense) (*model.Expense, error) {
 	query := `
-		INSERT INTO expenses (group_id, paid_by_id, amount, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, group_id, paid_by_id, amount, description, created_at, updated_at
+		INSERT INTO expenses (group_id, paid_by_id, amount, currency, fx_rate, base_amount, split_mode, category_id, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, group_id, paid_by_id, amount, currency, fx_rate, base_amount, split_mode, category_id, description, created_at, updated_at
 	`
 
-	expense.CreatedAt = time.Now()
+	// Imported expenses keep the date they were originally recorded on
+	if expense.CreatedAt.IsZero() {
+		expense.CreatedAt = time.Now()
+	}
 	expense.UpdatedAt = time.Now()
 
 	err := r.DB.QueryRow(
@@ -32,10 +36,15 @@ func (r *ExpenseRepositoryPG) CreateExpense(expense *model.Expense) (*model.Expe
 		expense.GroupID,
 		expense.PaidByID,
 		expense.Amount,
+		expense.Currency,
+		expense.FXRate,
+		expense.BaseAmount,
+		expense.SplitMode,
+		expense.CategoryID,
 		expense.Description,
 		expense.CreatedAt,
 		expense.UpdatedAt,
-	).Scan(&expense.ID, &expense.GroupID, &expense.PaidByID, &expense.Amount, &expense.Description, &expense.CreatedAt, &expense.UpdatedAt)
+	).Scan(&expense.ID, &expense.GroupID, &expense.PaidByID, &expense.Amount, &expense.Currency, &expense.FXRate, &expense.BaseAmount, &expense.SplitMode, &expense.CategoryID, &expense.Description, &expense.CreatedAt, &expense.UpdatedAt)
 
 	if err != nil {
 		log.Printf("Error creating expense: %v", err)
@@ -47,9 +56,9 @@ func (r *ExpenseRepositoryPG) CreateExpense(expense *model.Expense) (*model.Expe
 
 func (r *ExpenseRepositoryPG) GetExpenseByID(id int) (*model.Expense, error) {
 	query := `
-		SELECT id, group_id, paid_by_id, amount, description, created_at, updated_at
+		SELECT id, group_id, paid_by_id, amount, currency, fx_rate, base_amount, split_mode, category_id, description, created_at, updated_at
 		FROM expenses
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	expense := &model.Expense{}
@@ -58,6 +67,11 @@ func (r *ExpenseRepositoryPG) GetExpenseByID(id int) (*model.Expense, error) {
 		&expense.GroupID,
 		&expense.PaidByID,
 		&expense.Amount,
+		&expense.Currency,
+		&expense.FXRate,
+		&expense.BaseAmount,
+		&expense.SplitMode,
+		&expense.CategoryID,
 		&expense.Description,
 		&expense.CreatedAt,
 		&expense.UpdatedAt,
@@ -74,11 +88,50 @@ func (r *ExpenseRepositoryPG) GetExpenseByID(id int) (*model.Expense, error) {
 	return expense, nil
 }
 
+// LockExpense reads an expense with FOR UPDATE, so that changes to it and its
+// splits are serialized. Unlike the other reads it also returns deleted
+// expenses, for the caller to check once it holds the lock.
+func (r *ExpenseRepositoryPG) LockExpense(id int) (*model.Expense, error) {
+	query := `
+		SELECT id, group_id, paid_by_id, amount, currency, fx_rate, base_amount, split_mode, category_id, description, created_at, updated_at, deleted_at
+		FROM expenses
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	expense := &model.Expense{}
+	err := r.DB.QueryRow(query, id).Scan(
+		&expense.ID,
+		&expense.GroupID,
+		&expense.PaidByID,
+		&expense.Amount,
+		&expense.Currency,
+		&expense.FXRate,
+		&expense.BaseAmount,
+		&expense.SplitMode,
+		&expense.CategoryID,
+		&expense.Description,
+		&expense.CreatedAt,
+		&expense.UpdatedAt,
+		&expense.DeletedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("expense not found")
+		}
+		log.Printf("Error locking expense: %v", err)
+		return nil, err
+	}
+
+	return expense, nil
+}
+
 func (r *ExpenseRepositoryPG) GetExpensesByGroupID(groupID int) ([]*model.Expense, error) {
 	query := `
-		SELECT id, group_id, paid_by_id, amount, description, created_at, updated_at
+		SELECT id, group_id, paid_by_id, amount, currency, fx_rate, base_amount, split_mode, category_id, description, created_at, updated_at
 		FROM expenses
-		WHERE group_id = $1
+		WHERE group_id = $1 AND deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
 
@@ -97,6 +150,11 @@ func (r *ExpenseRepositoryPG) GetExpensesByGroupID(groupID int) ([]*model.Expens
 			&expense.GroupID,
 			&expense.PaidByID,
 			&expense.Amount,
+			&expense.Currency,
+			&expense.FXRate,
+			&expense.BaseAmount,
+			&expense.SplitMode,
+			&expense.CategoryID,
 			&expense.Description,
 			&expense.CreatedAt,
 			&expense.UpdatedAt,
@@ -118,9 +176,9 @@ func (r *ExpenseRepositoryPG) GetExpensesByGroupID(groupID int) ([]*model.Expens
 
 func (r *ExpenseRepositoryPG) GetExpensesByUserID(userID int) ([]*model.Expense, error) {
 	query := `
-		SELECT id, group_id, paid_by_id, amount, description, created_at, updated_at
+		SELECT id, group_id, paid_by_id, amount, currency, fx_rate, base_amount, split_mode, category_id, description, created_at, updated_at
 		FROM expenses
-		WHERE paid_by_id = $1
+		WHERE paid_by_id = $1 AND deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
 
@@ -139,6 +197,11 @@ func (r *ExpenseRepositoryPG) GetExpensesByUserID(userID int) ([]*model.Expense,
 			&expense.GroupID,
 			&expense.PaidByID,
 			&expense.Amount,
+			&expense.Currency,
+			&expense.FXRate,
+			&expense.BaseAmount,
+			&expense.SplitMode,
+			&expense.CategoryID,
 			&expense.Description,
 			&expense.CreatedAt,
 			&expense.UpdatedAt,
@@ -158,23 +221,68 @@ func (r *ExpenseRepositoryPG) GetExpensesByUserID(userID int) ([]*model.Expense,
 	return expenses, nil
 }
 
+// IterateExpensesByGroupID streams the group's expenses, oldest first, through
+// a cursor
+func (r *ExpenseRepositoryPG) IterateExpensesByGroupID(groupID int) (repository.Iterator[model.Expense], error) {
+	query := `
+		SELECT id, group_id, paid_by_id, amount, currency, fx_rate, base_amount, split_mode, category_id, description, created_at, updated_at
+		FROM expenses
+		WHERE group_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at, id
+	`
+
+	scan := func(rows *sql.Rows) (*model.Expense, error) {
+		expense := &model.Expense{}
+		err := rows.Scan(
+			&expense.ID,
+			&expense.GroupID,
+			&expense.PaidByID,
+			&expense.Amount,
+			&expense.Currency,
+			&expense.FXRate,
+			&expense.BaseAmount,
+			&expense.SplitMode,
+			&expense.CategoryID,
+			&expense.Description,
+			&expense.CreatedAt,
+			&expense.UpdatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning expense: %v", err)
+			return nil, err
+		}
+		return expense, nil
+	}
+
+	rows, err := openCursor(r.DB, scan, query, groupID)
+	if err != nil {
+		log.Printf("Error getting expenses by group ID: %v", err)
+		return nil, err
+	}
+	return rows, nil
+}
+
 func (r *ExpenseRepositoryPG) UpdateExpense(expense *model.Expense) (*model.Expense, error) {
 	query := `
 		UPDATE expenses
-		SET amount = $1, description = $2, updated_at = $3
-		WHERE id = $4
-		RETURNING id, group_id, paid_by_id, amount, description, created_at, updated_at
+		SET paid_by_id = $1, amount = $2, base_amount = $3, split_mode = $4, category_id = $5, description = $6, updated_at = $7
+		WHERE id = $8 AND deleted_at IS NULL
+		RETURNING id, group_id, paid_by_id, amount, currency, fx_rate, base_amount, split_mode, category_id, description, created_at, updated_at
 	`
 
 	expense.UpdatedAt = time.Now()
 
 	err := r.DB.QueryRow(
 		query,
+		expense.PaidByID,
 		expense.Amount,
+		expense.BaseAmount,
+		expense.SplitMode,
+		expense.CategoryID,
 		expense.Description,
 		expense.UpdatedAt,
 		expense.ID,
-	).Scan(&expense.ID, &expense.GroupID, &expense.PaidByID, &expense.Amount, &expense.Description, &expense.CreatedAt, &expense.UpdatedAt)
+	).Scan(&expense.ID, &expense.GroupID, &expense.PaidByID, &expense.Amount, &expense.Currency, &expense.FXRate, &expense.BaseAmount, &expense.SplitMode, &expense.CategoryID, &expense.Description, &expense.CreatedAt, &expense.UpdatedAt)
 
 	if err != nil {
 		log.Printf("Error updating expense: %v", err)
@@ -184,10 +292,10 @@ func (r *ExpenseRepositoryPG) UpdateExpense(expense *model.Expense) (*model.Expe
 	return expense, nil
 }
 
-func (r *ExpenseRepositoryPG) DeleteExpense(id int) error {
-	query := `DELETE FROM expenses WHERE id = $1`
+func (r *ExpenseRepositoryPG) DeleteExpense(id int, deletedAt time.Time) error {
+	query := `UPDATE expenses SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL`
 
-	result, err := r.DB.Exec(query, id)
+	result, err := r.DB.Exec(query, id, deletedAt)
 	if err != nil {
 		log.Printf("Error deleting expense: %v", err)
 		return err
@@ -204,3 +312,225 @@ func (r *ExpenseRepositoryPG) DeleteExpense(id int) error {
 
 	return nil
 }
+
+func (r *ExpenseRepositoryPG) DeleteGroupExpenses(groupID int, deletedAt time.Time) error {
+	query := `UPDATE expenses SET deleted_at = $2 WHERE group_id = $1 AND deleted_at IS NULL`
+
+	if _, err := r.DB.Exec(query, groupID, deletedAt); err != nil {
+		log.Printf("Error deleting group expenses: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *ExpenseRepositoryPG) GetDeletedExpenseByID(id int) (*model.Expense, error) {
+	query := `
+		SELECT id, group_id, paid_by_id, amount, currency, fx_rate, base_amount, split_mode, category_id, description, created_at, updated_at, deleted_at
+		FROM expenses
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	expense := &model.Expense{}
+	err := r.DB.QueryRow(query, id).Scan(
+		&expense.ID,
+		&expense.GroupID,
+		&expense.PaidByID,
+		&expense.Amount,
+		&expense.Currency,
+		&expense.FXRate,
+		&expense.BaseAmount,
+		&expense.SplitMode,
+		&expense.CategoryID,
+		&expense.Description,
+		&expense.CreatedAt,
+		&expense.UpdatedAt,
+		&expense.DeletedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("deleted expense not found")
+		}
+		log.Printf("Error getting deleted expense by ID: %v", err)
+		return nil, err
+	}
+
+	return expense, nil
+}
+
+func (r *ExpenseRepositoryPG) RestoreExpense(id int) error {
+	query := `UPDATE expenses SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	result, err := r.DB.Exec(query, id)
+	if err != nil {
+		log.Printf("Error restoring expense: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("deleted expense not found")
+	}
+
+	return nil
+}
+
+func (r *ExpenseRepositoryPG) RestoreGroupExpenses(groupID int, deletedAt time.Time) error {
+	query := `UPDATE expenses SET deleted_at = NULL WHERE group_id = $1 AND deleted_at = $2`
+
+	if _, err := r.DB.Exec(query, groupID, deletedAt); err != nil {
+		log.Printf("Error restoring group expenses: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// PurgeDeletedExpenses removes expenses deleted before the given time; their
+// splits, attachments and comments go with them through the foreign keys
+func (r *ExpenseRepositoryPG) PurgeDeletedExpenses(before time.Time) (int64, error) {
+	query := `DELETE FROM expenses WHERE deleted_at < $1`
+
+	result, err := r.DB.Exec(query, before)
+	if err != nil {
+		log.Printf("Error purging deleted expenses: %v", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+var expenseSortColumns = map[string]sortColumn{
+	model.SortByCreatedAt:   {expr: "e.created_at", parse: parseTimeValue},
+	model.SortByAmount:      {expr: "e.amount", parse: parseIntValue},
+	model.SortByDescription: {expr: "COALESCE(e.description, '')", parse: parseTextValue},
+}
+
+// expenseFilters adds the filters of an expense list query, on expenses aliased e
+func expenseFilters(b *listBuilder, query *model.ListQuery) {
+	b.where("e.deleted_at IS NULL")
+	if query.GroupID != 0 {
+		b.where("e.group_id = " + b.arg(query.GroupID))
+	}
+	if query.UserID != 0 {
+		b.where("e.paid_by_id = " + b.arg(query.UserID))
+	}
+	if query.PayerID != 0 {
+		b.where("e.paid_by_id = " + b.arg(query.PayerID))
+	}
+	if query.ParticipantID != 0 {
+		b.where("EXISTS (SELECT 1 FROM expense_splits es WHERE es.expense_id = e.id AND es.user_id = " + b.arg(query.ParticipantID) + ")")
+	}
+	if query.CategoryID != 0 {
+		b.where(`e.category_id IN (
+			WITH RECURSIVE tree AS (
+				SELECT id FROM categories WHERE id = ` + b.arg(query.CategoryID) + `
+				UNION ALL
+				SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+			)
+			SELECT id FROM tree
+		)`)
+	}
+	b.ledgerFilters(query, "e.created_at", "e.amount", "e.description")
+}
+
+// ListExpenses returns one page of expenses. query.GroupID limits it to a group
+// and query.UserID to the expenses that user paid.
+func (r *ExpenseRepositoryPG) ListExpenses(query *model.ListQuery) ([]*model.Expense, string, error) {
+	b := &listBuilder{}
+	expenseFilters(b, query)
+
+	clauses, err := b.build(query, expenseSortColumns, "e.id")
+	if err != nil {
+		return nil, "", err
+	}
+
+	sqlQuery := `
+		SELECT e.id, e.group_id, e.paid_by_id, e.amount, e.currency, e.fx_rate, e.base_amount, e.split_mode, e.category_id, e.description, e.created_at, e.updated_at
+		FROM expenses e` + clauses
+
+	rows, err := r.DB.Query(sqlQuery, b.args...)
+	if err != nil {
+		log.Printf("Error listing expenses: %v", err)
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var expenses []*model.Expense
+	for rows.Next() {
+		expense := &model.Expense{}
+		err := rows.Scan(
+			&expense.ID,
+			&expense.GroupID,
+			&expense.PaidByID,
+			&expense.Amount,
+			&expense.Currency,
+			&expense.FXRate,
+			&expense.BaseAmount,
+			&expense.SplitMode,
+			&expense.CategoryID,
+			&expense.Description,
+			&expense.CreatedAt,
+			&expense.UpdatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning expense: %v", err)
+			return nil, "", err
+		}
+		expenses = append(expenses, expense)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating expenses: %v", err)
+		return nil, "", err
+	}
+
+	expenses, next := nextCursor(expenses, query, model.ExpenseSortValue, func(e *model.Expense) int { return e.ID })
+	return expenses, next, nil
+}
+
+// SumExpensesByCategory totals every expense matching the query's filters per
+// category and currency, ignoring its cursor and limit. Amounts are in the
+// currency of each expense's group.
+func (r *ExpenseRepositoryPG) SumExpensesByCategory(query *model.ListQuery) ([]*model.CategoryTotal, error) {
+	b := &listBuilder{}
+	expenseFilters(b, query)
+
+	sqlQuery := `
+		SELECT e.category_id, COALESCE(c.name, ''), g.currency, COALESCE(SUM(e.base_amount), 0)::BIGINT, COUNT(*)
+		FROM expenses e
+		JOIN groups g ON g.id = e.group_id
+		LEFT JOIN categories c ON c.id = e.category_id` + b.whereClause() + `
+		GROUP BY e.category_id, c.name, g.currency
+		ORDER BY g.currency, c.name NULLS LAST
+	`
+
+	rows, err := r.DB.Query(sqlQuery, b.args...)
+	if err != nil {
+		log.Printf("Error summing expenses by category: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*model.CategoryTotal
+	for rows.Next() {
+		total := &model.CategoryTotal{}
+		if err := rows.Scan(&total.CategoryID, &total.CategoryName, &total.Currency, &total.Total, &total.Count); err != nil {
+			log.Printf("Error scanning category total: %v", err)
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating category totals: %v", err)
+		return nil, err
+	}
+
+	return totals, nil
+}
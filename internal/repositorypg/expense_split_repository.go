@@ -2,25 +2,27 @@ package repositorypg
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
 )
 
 type ExpenseSplitRepositoryPG struct {
-	DB *sql.DB
+	DB DBTX
 }
 
-func NewExpenseSplitRepositoryPG(db *sql.DB) *ExpenseSplitRepositoryPG {
+func NewExpenseSplitRepositoryPG(db DBTX) *ExpenseSplitRepositoryPG {
 	return &ExpenseSplitRepositoryPG{DB: db}
 }
 
 func (r *ExpenseSplitRepositoryPG) CreateSplit(split *model.ExpenseSplit) (*model.ExpenseSplit, error) {
 	query := `
-		INSERT INTO expense_splits (expense_id, user_id, amount, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, expense_id, user_id, amount, created_at, updated_at
+		INSERT INTO expense_splits (expense_id, user_id, amount, base_amount, weight, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, expense_id, user_id, amount, base_amount, weight, created_at, updated_at
 	`
 
 	split.CreatedAt = time.Now()
@@ -31,9 +33,11 @@ func (r *ExpenseSplitRepositoryPG) CreateSplit(split *model.ExpenseSplit) (*mode
 		split.ExpenseID,
 		split.UserID,
 		split.Amount,
+		split.BaseAmount,
+		split.Weight,
 		split.CreatedAt,
 		split.UpdatedAt,
-	).Scan(&split.ID, &split.ExpenseID, &split.UserID, &split.Amount, &split.CreatedAt, &split.UpdatedAt)
+	).Scan(&split.ID, &split.ExpenseID, &split.UserID, &split.Amount, &split.BaseAmount, &split.Weight, &split.CreatedAt, &split.UpdatedAt)
 
 	if err != nil {
 		log.Printf("Error creating split: %v", err)
@@ -43,9 +47,39 @@ func (r *ExpenseSplitRepositoryPG) CreateSplit(split *model.ExpenseSplit) (*mode
 	return split, nil
 }
 
+func (r *ExpenseSplitRepositoryPG) GetSplitByID(id int) (*model.ExpenseSplit, error) {
+	query := `
+		SELECT id, expense_id, user_id, amount, base_amount, weight, created_at, updated_at
+		FROM expense_splits
+		WHERE id = $1
+	`
+
+	split := &model.ExpenseSplit{}
+	err := r.DB.QueryRow(query, id).Scan(
+		&split.ID,
+		&split.ExpenseID,
+		&split.UserID,
+		&split.Amount,
+		&split.BaseAmount,
+		&split.Weight,
+		&split.CreatedAt,
+		&split.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("split not found")
+		}
+		log.Printf("Error getting split by ID: %v", err)
+		return nil, err
+	}
+
+	return split, nil
+}
+
 func (r *ExpenseSplitRepositoryPG) GetSplitsByExpenseID(expenseID int) ([]*model.ExpenseSplit, error) {
 	query := `
-		SELECT id, expense_id, user_id, amount, created_at, updated_at
+		SELECT id, expense_id, user_id, amount, base_amount, weight, created_at, updated_at
 		FROM expense_splits
 		WHERE expense_id = $1
 		ORDER BY created_at DESC
@@ -66,6 +100,8 @@ func (r *ExpenseSplitRepositoryPG) GetSplitsByExpenseID(expenseID int) ([]*model
 			&split.ExpenseID,
 			&split.UserID,
 			&split.Amount,
+			&split.BaseAmount,
+			&split.Weight,
 			&split.CreatedAt,
 			&split.UpdatedAt,
 		)
@@ -84,11 +120,50 @@ func (r *ExpenseSplitRepositoryPG) GetSplitsByExpenseID(expenseID int) ([]*model
 	return splits, nil
 }
 
+// IterateSplitsByGroupID streams the splits of the group's expenses, in the
+// order of IterateExpensesByGroupID and by user within an expense, through a
+// cursor
+func (r *ExpenseSplitRepositoryPG) IterateSplitsByGroupID(groupID int) (repository.Iterator[model.ExpenseSplit], error) {
+	query := `
+		SELECT es.id, es.expense_id, es.user_id, es.amount, es.base_amount, es.weight, es.created_at, es.updated_at
+		FROM expense_splits es
+		JOIN expenses e ON e.id = es.expense_id
+		WHERE e.group_id = $1 AND e.deleted_at IS NULL
+		ORDER BY e.created_at, e.id, es.user_id
+	`
+
+	scan := func(rows *sql.Rows) (*model.ExpenseSplit, error) {
+		split := &model.ExpenseSplit{}
+		err := rows.Scan(
+			&split.ID,
+			&split.ExpenseID,
+			&split.UserID,
+			&split.Amount,
+			&split.BaseAmount,
+			&split.Weight,
+			&split.CreatedAt,
+			&split.UpdatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning split: %v", err)
+			return nil, err
+		}
+		return split, nil
+	}
+
+	rows, err := openCursor(r.DB, scan, query, groupID)
+	if err != nil {
+		log.Printf("Error getting splits by group ID: %v", err)
+		return nil, err
+	}
+	return rows, nil
+}
+
 func (r *ExpenseSplitRepositoryPG) GetSplitsByUserID(userID int) ([]*model.ExpenseSplit, error) {
 	query := `
-		SELECT id, expense_id, user_id, amount, created_at, updated_at
+		SELECT id, expense_id, user_id, amount, base_amount, weight, created_at, updated_at
 		FROM expense_splits
-		WHERE user_id = $1
+		WHERE user_id = $1 AND expense_id IN (SELECT id FROM expenses WHERE deleted_at IS NULL)
 		ORDER BY created_at DESC
 	`
 
@@ -107,6 +182,8 @@ func (r *ExpenseSplitRepositoryPG) GetSplitsByUserID(userID int) ([]*model.Expen
 			&split.ExpenseID,
 			&split.UserID,
 			&split.Amount,
+			&split.BaseAmount,
+			&split.Weight,
 			&split.CreatedAt,
 			&split.UpdatedAt,
 		)
@@ -140,9 +217,9 @@ func (r *ExpenseSplitRepositoryPG) DeleteSplitsByExpenseID(expenseID int) error
 func (r *ExpenseSplitRepositoryPG) UpdateSplit(split *model.ExpenseSplit) (*model.ExpenseSplit, error) {
 	query := `
 		UPDATE expense_splits
-		SET amount = $1, updated_at = $2
-		WHERE id = $3
-		RETURNING id, expense_id, user_id, amount, created_at, updated_at
+		SET amount = $1, base_amount = $2, weight = $3, updated_at = $4
+		WHERE id = $5
+		RETURNING id, expense_id, user_id, amount, base_amount, weight, created_at, updated_at
 	`
 
 	split.UpdatedAt = time.Now()
@@ -150,9 +227,11 @@ func (r *ExpenseSplitRepositoryPG) UpdateSplit(split *model.ExpenseSplit) (*mode
 	err := r.DB.QueryRow(
 		query,
 		split.Amount,
+		split.BaseAmount,
+		split.Weight,
 		split.UpdatedAt,
 		split.ID,
-	).Scan(&split.ID, &split.ExpenseID, &split.UserID, &split.Amount, &split.CreatedAt, &split.UpdatedAt)
+	).Scan(&split.ID, &split.ExpenseID, &split.UserID, &split.Amount, &split.BaseAmount, &split.Weight, &split.CreatedAt, &split.UpdatedAt)
 
 	if err != nil {
 		log.Printf("Error updating split: %v", err)
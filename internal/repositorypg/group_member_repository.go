@@ -10,18 +10,18 @@ import (
 )
 
 type GroupMemberRepositoryPG struct {
-	DB *sql.DB
+	DB DBTX
 }
 
-func NewGroupMemberRepositoryPG(db *sql.DB) *GroupMemberRepositoryPG {
+func NewGroupMemberRepositoryPG(db DBTX) *GroupMemberRepositoryPG {
 	return &GroupMemberRepositoryPG{DB: db}
 }
 
 func (r *GroupMemberRepositoryPG) AddMember(member *model.GroupMember) (*model.GroupMember, error) {
 	query := `
-		INSERT INTO group_members (group_id, user_id, added_at, updated_at)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, group_id, user_id, added_at, updated_at
+		INSERT INTO group_members (group_id, user_id, role, added_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, group_id, user_id, role, added_at, updated_at
 	`
 
 	member.AddedAt = time.Now()
@@ -31,9 +31,10 @@ func (r *GroupMemberRepositoryPG) AddMember(member *model.GroupMember) (*model.G
 		query,
 		member.GroupID,
 		member.UserID,
+		member.Role,
 		member.AddedAt,
 		member.UpdatedAt,
-	).Scan(&member.ID, &member.GroupID, &member.UserID, &member.AddedAt, &member.UpdatedAt)
+	).Scan(&member.ID, &member.GroupID, &member.UserID, &member.Role, &member.AddedAt, &member.UpdatedAt)
 
 	if err != nil {
 		log.Printf("Error adding member: %v", err)
@@ -66,7 +67,7 @@ func (r *GroupMemberRepositoryPG) RemoveMember(groupID, userID int) error {
 
 func (r *GroupMemberRepositoryPG) GetGroupMembers(groupID int) ([]*model.GroupMember, error) {
 	query := `
-		SELECT id, group_id, user_id, added_at, updated_at
+		SELECT id, group_id, user_id, role, added_at, updated_at
 		FROM group_members
 		WHERE group_id = $1
 		ORDER BY added_at DESC
@@ -86,6 +87,7 @@ func (r *GroupMemberRepositoryPG) GetGroupMembers(groupID int) ([]*model.GroupMe
 			&member.ID,
 			&member.GroupID,
 			&member.UserID,
+			&member.Role,
 			&member.AddedAt,
 			&member.UpdatedAt,
 		)
@@ -107,7 +109,7 @@ func (r *GroupMemberRepositoryPG) GetGroupMembers(groupID int) ([]*model.GroupMe
 // GetGroupMembersWithDetails returns group members with user details (name and email)
 func (r *GroupMemberRepositoryPG) GetGroupMembersWithDetails(groupID int) ([]*model.GroupMemberResponse, error) {
 	query := `
-		SELECT gm.id, gm.group_id, gm.user_id, u.name, u.email, gm.added_at
+		SELECT gm.id, gm.group_id, gm.user_id, u.name, u.email, u.placeholder, gm.role, gm.added_at
 		FROM group_members gm
 		JOIN users u ON gm.user_id = u.id
 		WHERE gm.group_id = $1
@@ -130,6 +132,8 @@ func (r *GroupMemberRepositoryPG) GetGroupMembersWithDetails(groupID int) ([]*mo
 			&member.UserID,
 			&member.UserName,
 			&member.Email,
+			&member.Placeholder,
+			&member.Role,
 			&member.AddedAt,
 		)
 		if err != nil {
@@ -149,7 +153,7 @@ func (r *GroupMemberRepositoryPG) GetGroupMembersWithDetails(groupID int) ([]*mo
 
 func (r *GroupMemberRepositoryPG) GetUserGroups(userID int) ([]*model.GroupMember, error) {
 	query := `
-		SELECT id, group_id, user_id, added_at, updated_at
+		SELECT id, group_id, user_id, role, added_at, updated_at
 		FROM group_members
 		WHERE user_id = $1
 		ORDER BY added_at DESC
@@ -169,6 +173,7 @@ func (r *GroupMemberRepositoryPG) GetUserGroups(userID int) ([]*model.GroupMembe
 			&member.ID,
 			&member.GroupID,
 			&member.UserID,
+			&member.Role,
 			&member.AddedAt,
 			&member.UpdatedAt,
 		)
@@ -203,3 +208,82 @@ func (r *GroupMemberRepositoryPG) IsMember(groupID, userID int) (bool, error) {
 
 	return count > 0, nil
 }
+
+// SharesGroup reports whether the two users are members of a same group
+func (r *GroupMemberRepositoryPG) SharesGroup(userID, otherID int) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM group_members own
+			JOIN group_members other ON other.group_id = own.group_id
+			WHERE own.user_id = $1 AND other.user_id = $2
+		)
+	`
+
+	var shares bool
+	err := r.DB.QueryRow(query, userID, otherID).Scan(&shares)
+	if err != nil {
+		log.Printf("Error checking shared groups: %v", err)
+		return false, err
+	}
+
+	return shares, nil
+}
+
+// GetMember returns a user's membership of a group, including their role
+func (r *GroupMemberRepositoryPG) GetMember(groupID, userID int) (*model.GroupMember, error) {
+	query := `
+		SELECT id, group_id, user_id, role, added_at, updated_at
+		FROM group_members
+		WHERE group_id = $1 AND user_id = $2
+	`
+
+	member := &model.GroupMember{}
+	err := r.DB.QueryRow(query, groupID, userID).Scan(
+		&member.ID,
+		&member.GroupID,
+		&member.UserID,
+		&member.Role,
+		&member.AddedAt,
+		&member.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("member not found")
+		}
+		log.Printf("Error getting member: %v", err)
+		return nil, err
+	}
+
+	return member, nil
+}
+
+func (r *GroupMemberRepositoryPG) UpdateMemberRole(groupID, userID int, role string) (*model.GroupMember, error) {
+	query := `
+		UPDATE group_members
+		SET role = $1, updated_at = $2
+		WHERE group_id = $3 AND user_id = $4
+		RETURNING id, group_id, user_id, role, added_at, updated_at
+	`
+
+	member := &model.GroupMember{}
+	err := r.DB.QueryRow(query, role, time.Now(), groupID, userID).Scan(
+		&member.ID,
+		&member.GroupID,
+		&member.UserID,
+		&member.Role,
+		&member.AddedAt,
+		&member.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("member not found")
+		}
+		log.Printf("Error updating member role: %v", err)
+		return nil, err
+	}
+
+	return member, nil
+}
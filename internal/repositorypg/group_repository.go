@@ -10,18 +10,18 @@ import (
 )
 
 type GroupRepositoryPG struct {
-	DB *sql.DB
+	DB DBTX
 }
 
-func NewGroupRepositoryPG(db *sql.DB) *GroupRepositoryPG {
+func NewGroupRepositoryPG(db DBTX) *GroupRepositoryPG {
 	return &GroupRepositoryPG{DB: db}
 }
 
 func (r *GroupRepositoryPG) CreateGroup(group *model.Group) (*model.Group, error) {
 	query := `
-		INSERT INTO groups (name, description, creator_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, name, description, creator_id, created_at, updated_at
+		INSERT INTO groups (name, description, creator_id, currency, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, name, description, creator_id, currency, created_at, updated_at
 	`
 
 	group.CreatedAt = time.Now()
@@ -32,9 +32,10 @@ func (r *GroupRepositoryPG) CreateGroup(group *model.Group) (*model.Group, error
 		group.Name,
 		group.Description,
 		group.CreatorID,
+		group.Currency,
 		group.CreatedAt,
 		group.UpdatedAt,
-	).Scan(&group.ID, &group.Name, &group.Description, &group.CreatorID, &group.CreatedAt, &group.UpdatedAt)
+	).Scan(&group.ID, &group.Name, &group.Description, &group.CreatorID, &group.Currency, &group.CreatedAt, &group.UpdatedAt)
 
 	if err != nil {
 		log.Printf("Error creating group: %v", err)
@@ -46,9 +47,9 @@ func (r *GroupRepositoryPG) CreateGroup(group *model.Group) (*model.Group, error
 
 func (r *GroupRepositoryPG) GetGroupByID(id int) (*model.Group, error) {
 	query := `
-		SELECT id, name, description, creator_id, created_at, updated_at
+		SELECT id, name, description, creator_id, currency, created_at, updated_at
 		FROM groups
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	group := &model.Group{}
@@ -57,6 +58,7 @@ func (r *GroupRepositoryPG) GetGroupByID(id int) (*model.Group, error) {
 		&group.Name,
 		&group.Description,
 		&group.CreatorID,
+		&group.Currency,
 		&group.CreatedAt,
 		&group.UpdatedAt,
 	)
@@ -74,8 +76,9 @@ func (r *GroupRepositoryPG) GetGroupByID(id int) (*model.Group, error) {
 
 func (r *GroupRepositoryPG) GetAllGroups() ([]*model.Group, error) {
 	query := `
-		SELECT id, name, description, creator_id, created_at, updated_at
+		SELECT id, name, description, creator_id, currency, created_at, updated_at
 		FROM groups
+		WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
 
@@ -94,6 +97,7 @@ func (r *GroupRepositoryPG) GetAllGroups() ([]*model.Group, error) {
 			&group.Name,
 			&group.Description,
 			&group.CreatorID,
+			&group.Currency,
 			&group.CreatedAt,
 			&group.UpdatedAt,
 		)
@@ -114,10 +118,10 @@ func (r *GroupRepositoryPG) GetAllGroups() ([]*model.Group, error) {
 
 func (r *GroupRepositoryPG) GetGroupsByUserID(userID int) ([]*model.Group, error) {
 	query := `
-		SELECT g.id, g.name, g.description, g.creator_id, g.created_at, g.updated_at
+		SELECT g.id, g.name, g.description, g.creator_id, g.currency, g.created_at, g.updated_at
 		FROM groups g
 		JOIN group_members gm ON g.id = gm.group_id
-		WHERE gm.user_id = $1
+		WHERE gm.user_id = $1 AND g.deleted_at IS NULL
 		ORDER BY g.created_at DESC
 	`
 
@@ -136,6 +140,7 @@ func (r *GroupRepositoryPG) GetGroupsByUserID(userID int) ([]*model.Group, error
 			&group.Name,
 			&group.Description,
 			&group.CreatorID,
+			&group.Currency,
 			&group.CreatedAt,
 			&group.UpdatedAt,
 		)
@@ -158,8 +163,8 @@ func (r *GroupRepositoryPG) UpdateGroup(group *model.Group) (*model.Group, error
 	query := `
 		UPDATE groups
 		SET name = $1, description = $2, updated_at = $3
-		WHERE id = $4
-		RETURNING id, name, description, creator_id, created_at, updated_at
+		WHERE id = $4 AND deleted_at IS NULL
+		RETURNING id, name, description, creator_id, currency, created_at, updated_at
 	`
 
 	group.UpdatedAt = time.Now()
@@ -170,7 +175,7 @@ func (r *GroupRepositoryPG) UpdateGroup(group *model.Group) (*model.Group, error
 		group.Description,
 		group.UpdatedAt,
 		group.ID,
-	).Scan(&group.ID, &group.Name, &group.Description, &group.CreatorID, &group.CreatedAt, &group.UpdatedAt)
+	).Scan(&group.ID, &group.Name, &group.Description, &group.CreatorID, &group.Currency, &group.CreatedAt, &group.UpdatedAt)
 
 	if err != nil {
 		log.Printf("Error updating group: %v", err)
@@ -180,10 +185,10 @@ func (r *GroupRepositoryPG) UpdateGroup(group *model.Group) (*model.Group, error
 	return group, nil
 }
 
-func (r *GroupRepositoryPG) DeleteGroup(id int) error {
-	query := `DELETE FROM groups WHERE id = $1`
+func (r *GroupRepositoryPG) DeleteGroup(id int, deletedAt time.Time) error {
+	query := `UPDATE groups SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL`
 
-	result, err := r.DB.Exec(query, id)
+	result, err := r.DB.Exec(query, id, deletedAt)
 	if err != nil {
 		log.Printf("Error deleting group: %v", err)
 		return err
@@ -200,3 +205,68 @@ func (r *GroupRepositoryPG) DeleteGroup(id int) error {
 
 	return nil
 }
+
+func (r *GroupRepositoryPG) GetDeletedGroupByID(id int) (*model.Group, error) {
+	query := `
+		SELECT id, name, description, creator_id, currency, created_at, updated_at, deleted_at
+		FROM groups
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	group := &model.Group{}
+	err := r.DB.QueryRow(query, id).Scan(
+		&group.ID,
+		&group.Name,
+		&group.Description,
+		&group.CreatorID,
+		&group.Currency,
+		&group.CreatedAt,
+		&group.UpdatedAt,
+		&group.DeletedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("deleted group not found")
+		}
+		log.Printf("Error getting deleted group by ID: %v", err)
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (r *GroupRepositoryPG) RestoreGroup(id int) error {
+	query := `UPDATE groups SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	result, err := r.DB.Exec(query, id)
+	if err != nil {
+		log.Printf("Error restoring group: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("deleted group not found")
+	}
+
+	return nil
+}
+
+// PurgeDeletedGroups removes groups deleted before the given time, and with
+// them, through the foreign keys, everything that belonged to them
+func (r *GroupRepositoryPG) PurgeDeletedGroups(before time.Time) (int64, error) {
+	query := `DELETE FROM groups WHERE deleted_at < $1`
+
+	result, err := r.DB.Exec(query, before)
+	if err != nil {
+		log.Printf("Error purging deleted groups: %v", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
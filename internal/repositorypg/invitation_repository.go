@@ -0,0 +1,180 @@
+package repositorypg
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type InvitationRepositoryPG struct {
+	DB DBTX
+}
+
+func NewInvitationRepositoryPG(db DBTX) *InvitationRepositoryPG {
+	return &InvitationRepositoryPG{DB: db}
+}
+
+func (r *InvitationRepositoryPG) CreateInvitation(invitation *model.Invitation) (*model.Invitation, error) {
+	query := `
+		INSERT INTO group_invitations (group_id, email, role, invited_by_id, token_hash, status, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, group_id, email, role, invited_by_id, token_hash, status, expires_at, responded_at, created_at
+	`
+
+	invitation.CreatedAt = time.Now()
+
+	err := r.DB.QueryRow(
+		query,
+		invitation.GroupID,
+		invitation.Email,
+		invitation.Role,
+		invitation.InvitedByID,
+		invitation.TokenHash,
+		invitation.Status,
+		invitation.ExpiresAt,
+		invitation.CreatedAt,
+	).Scan(&invitation.ID, &invitation.GroupID, &invitation.Email, &invitation.Role, &invitation.InvitedByID,
+		&invitation.TokenHash, &invitation.Status, &invitation.ExpiresAt, &invitation.RespondedAt, &invitation.CreatedAt)
+
+	if err != nil {
+		log.Printf("Error creating invitation: %v", err)
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (r *InvitationRepositoryPG) GetInvitationByID(id int) (*model.Invitation, error) {
+	query := `
+		SELECT id, group_id, email, role, invited_by_id, token_hash, status, expires_at, responded_at, created_at
+		FROM group_invitations
+		WHERE id = $1
+	`
+
+	invitation := &model.Invitation{}
+	err := r.DB.QueryRow(query, id).Scan(
+		&invitation.ID,
+		&invitation.GroupID,
+		&invitation.Email,
+		&invitation.Role,
+		&invitation.InvitedByID,
+		&invitation.TokenHash,
+		&invitation.Status,
+		&invitation.ExpiresAt,
+		&invitation.RespondedAt,
+		&invitation.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("invitation not found")
+		}
+		log.Printf("Error getting invitation by ID: %v", err)
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (r *InvitationRepositoryPG) GetInvitationByTokenHash(tokenHash string) (*model.Invitation, error) {
+	query := `
+		SELECT id, group_id, email, role, invited_by_id, token_hash, status, expires_at, responded_at, created_at
+		FROM group_invitations
+		WHERE token_hash = $1
+	`
+
+	invitation := &model.Invitation{}
+	err := r.DB.QueryRow(query, tokenHash).Scan(
+		&invitation.ID,
+		&invitation.GroupID,
+		&invitation.Email,
+		&invitation.Role,
+		&invitation.InvitedByID,
+		&invitation.TokenHash,
+		&invitation.Status,
+		&invitation.ExpiresAt,
+		&invitation.RespondedAt,
+		&invitation.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("invitation not found")
+		}
+		log.Printf("Error getting invitation by token: %v", err)
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (r *InvitationRepositoryPG) GetInvitationsByGroupID(groupID int) ([]*model.Invitation, error) {
+	query := `
+		SELECT id, group_id, email, role, invited_by_id, token_hash, status, expires_at, responded_at, created_at
+		FROM group_invitations
+		WHERE group_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.DB.Query(query, groupID)
+	if err != nil {
+		log.Printf("Error getting invitations: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []*model.Invitation
+	for rows.Next() {
+		invitation := &model.Invitation{}
+		err := rows.Scan(
+			&invitation.ID,
+			&invitation.GroupID,
+			&invitation.Email,
+			&invitation.Role,
+			&invitation.InvitedByID,
+			&invitation.TokenHash,
+			&invitation.Status,
+			&invitation.ExpiresAt,
+			&invitation.RespondedAt,
+			&invitation.CreatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning invitation: %v", err)
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating invitations: %v", err)
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+// RespondToInvitation moves a pending invitation to status. It fails when the
+// invitation is no longer pending, so a token can only be used once.
+func (r *InvitationRepositoryPG) RespondToInvitation(id int, status string) error {
+	query := `UPDATE group_invitations SET status = $1, responded_at = $2 WHERE id = $3 AND status = $4`
+
+	result, err := r.DB.Exec(query, status, time.Now(), id, model.InvitationPending)
+	if err != nil {
+		log.Printf("Error responding to invitation: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("invitation is no longer pending")
+	}
+
+	return nil
+}
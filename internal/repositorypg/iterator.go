@@ -0,0 +1,77 @@
+package repositorypg
+
+import (
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+)
+
+// cursorBatch is how many rows a cursor fetches at a time
+const cursorBatch = 500
+
+// cursorCount numbers the cursors, so their names are unique
+var cursorCount atomic.Int64
+
+// cursorIterator streams the rows of a query through a server-side cursor,
+// fetching a batch of rows at a time. Unlike an open *sql.Rows, a cursor does
+// not tie up the connection between batches, so several of them can be read
+// side by side in one transaction.
+type cursorIterator[T any] struct {
+	db    DBTX
+	name  string
+	scan  func(rows *sql.Rows) (*T, error)
+	batch []*T
+	done  bool
+}
+
+// openCursor declares a cursor for query. Cursors only live as long as their
+// transaction, so db must be a transaction.
+func openCursor[T any](db DBTX, scan func(rows *sql.Rows) (*T, error), query string, args ...interface{}) (*cursorIterator[T], error) {
+	name := fmt.Sprintf("cursor_%d", cursorCount.Add(1))
+	if _, err := db.Exec("DECLARE "+name+" NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return nil, err
+	}
+	return &cursorIterator[T]{db: db, name: name, scan: scan}, nil
+}
+
+// Next returns the next row, or nil once every row has been read
+func (it *cursorIterator[T]) Next() (*T, error) {
+	if len(it.batch) == 0 && !it.done {
+		if err := it.fetch(); err != nil {
+			return nil, err
+		}
+	}
+	if len(it.batch) == 0 {
+		return nil, nil
+	}
+	row := it.batch[0]
+	it.batch = it.batch[1:]
+	return row, nil
+}
+
+func (it *cursorIterator[T]) fetch() error {
+	rows, err := it.db.Query(fmt.Sprintf("FETCH %d FROM %s", cursorBatch, it.name))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row, err := it.scan(rows)
+		if err != nil {
+			return err
+		}
+		it.batch = append(it.batch, row)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	it.done = len(it.batch) < cursorBatch
+	return nil
+}
+
+func (it *cursorIterator[T]) Close() error {
+	it.batch = nil
+	_, err := it.db.Exec("CLOSE " + it.name)
+	return err
+}
@@ -0,0 +1,115 @@
+package repositorypg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+// sortColumn is the SQL expression a list is ordered by for a sort field, and
+// how to read a cursor value of that field back
+type sortColumn struct {
+	expr  string
+	parse func(value string) (interface{}, error)
+}
+
+func parseTimeValue(value string) (interface{}, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+func parseIntValue(value string) (interface{}, error) {
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func parseTextValue(value string) (interface{}, error) {
+	return value, nil
+}
+
+// listBuilder assembles the WHERE, ORDER BY and LIMIT clauses of a list query,
+// numbering the placeholders of its arguments
+type listBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg adds an argument and returns its placeholder
+func (b *listBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *listBuilder) where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+// ledgerFilters adds the date, amount and description filters of the query
+func (b *listBuilder) ledgerFilters(query *model.ListQuery, createdAt, amount, description string) {
+	if query.From != nil {
+		b.where(createdAt + " >= " + b.arg(*query.From))
+	}
+	if query.To != nil {
+		b.where(createdAt + " < " + b.arg(*query.To))
+	}
+	if query.MinAmount != nil {
+		b.where(amount + " >= " + b.arg(*query.MinAmount))
+	}
+	if query.MaxAmount != nil {
+		b.where(amount + " <= " + b.arg(*query.MaxAmount))
+	}
+	if query.Description != "" {
+		b.where("STRPOS(LOWER(COALESCE(" + description + ", '')), LOWER(" + b.arg(query.Description) + ")) > 0")
+	}
+}
+
+// build returns the clauses following FROM: the filters, the cursor position
+// and the order of the list. One row more than the limit is fetched so that
+// nextCursor can tell whether there is another page.
+func (b *listBuilder) build(query *model.ListQuery, columns map[string]sortColumn, idColumn string) (string, error) {
+	column, ok := columns[query.SortField]
+	if !ok {
+		return "", fmt.Errorf("cannot sort by %q", query.SortField)
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.Descending() {
+		direction, comparison = "DESC", "<"
+	}
+
+	if query.After != nil {
+		value, err := column.parse(query.After.Value)
+		if err != nil {
+			return "", fmt.Errorf("invalid cursor")
+		}
+		b.where(fmt.Sprintf("(%s, %s) %s (%s, %s)", column.expr, idColumn, comparison, b.arg(value), b.arg(query.After.ID)))
+	}
+
+	var clauses strings.Builder
+	clauses.WriteString(b.whereClause())
+	fmt.Fprintf(&clauses, " ORDER BY %s %s, %s %s LIMIT %s", column.expr, direction, idColumn, direction, b.arg(query.Limit+1))
+
+	return clauses.String(), nil
+}
+
+// whereClause returns the WHERE clause of the filters added so far, or "" when
+// there are none
+func (b *listBuilder) whereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// nextCursor trims the extra row fetched by build and returns the cursor of the
+// next page, or "" on the last page
+func nextCursor[T any](rows []*T, query *model.ListQuery, sortValue func(row *T, field string) string, id func(row *T) int) ([]*T, string) {
+	if len(rows) <= query.Limit {
+		return rows, ""
+	}
+
+	rows = rows[:query.Limit]
+	last := rows[len(rows)-1]
+	return rows, query.NextCursor(sortValue(last, query.SortField), id(last))
+}
@@ -0,0 +1,323 @@
+package repositorypg
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type RecurringExpenseRepositoryPG struct {
+	DB DBTX
+}
+
+func NewRecurringExpenseRepositoryPG(db DBTX) *RecurringExpenseRepositoryPG {
+	return &RecurringExpenseRepositoryPG{DB: db}
+}
+
+func (r *RecurringExpenseRepositoryPG) CreateRecurringExpense(recurring *model.RecurringExpense) (*model.RecurringExpense, error) {
+	query := `
+		INSERT INTO recurring_expenses (group_id, created_by_id, paid_by_id, amount, currency, category_id, description, split, frequency, interval_count, cron, start_date, end_date, next_run_at, last_run_at, paused, last_error, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		RETURNING id, group_id, created_by_id, paid_by_id, amount, currency, category_id, description, split, frequency, interval_count, cron, start_date, end_date, next_run_at, last_run_at, paused, last_error, created_at, updated_at
+	`
+
+	recurring.CreatedAt = time.Now()
+	recurring.UpdatedAt = time.Now()
+
+	err := r.DB.QueryRow(
+		query,
+		recurring.GroupID,
+		recurring.CreatedByID,
+		recurring.PaidByID,
+		recurring.Amount,
+		recurring.Currency,
+		recurring.CategoryID,
+		recurring.Description,
+		recurring.Split,
+		recurring.Frequency,
+		recurring.Interval,
+		recurring.Cron,
+		recurring.StartDate,
+		recurring.EndDate,
+		recurring.NextRunAt,
+		recurring.LastRunAt,
+		recurring.Paused,
+		recurring.LastError,
+		recurring.CreatedAt,
+		recurring.UpdatedAt,
+	).Scan(&recurring.ID, &recurring.GroupID, &recurring.CreatedByID, &recurring.PaidByID, &recurring.Amount, &recurring.Currency, &recurring.CategoryID, &recurring.Description, &recurring.Split, &recurring.Frequency, &recurring.Interval, &recurring.Cron, &recurring.StartDate, &recurring.EndDate, &recurring.NextRunAt, &recurring.LastRunAt, &recurring.Paused, &recurring.LastError, &recurring.CreatedAt, &recurring.UpdatedAt)
+
+	if err != nil {
+		log.Printf("Error creating recurring expense: %v", err)
+		return nil, err
+	}
+
+	return recurring, nil
+}
+
+func (r *RecurringExpenseRepositoryPG) GetRecurringExpenseByID(id int) (*model.RecurringExpense, error) {
+	query := `
+		SELECT id, group_id, created_by_id, paid_by_id, amount, currency, category_id, description, split, frequency, interval_count, cron, start_date, end_date, next_run_at, last_run_at, paused, last_error, created_at, updated_at
+		FROM recurring_expenses
+		WHERE id = $1
+	`
+
+	recurring := &model.RecurringExpense{}
+	err := r.DB.QueryRow(query, id).Scan(
+		&recurring.ID,
+		&recurring.GroupID,
+		&recurring.CreatedByID,
+		&recurring.PaidByID,
+		&recurring.Amount,
+		&recurring.Currency,
+		&recurring.CategoryID,
+		&recurring.Description,
+		&recurring.Split,
+		&recurring.Frequency,
+		&recurring.Interval,
+		&recurring.Cron,
+		&recurring.StartDate,
+		&recurring.EndDate,
+		&recurring.NextRunAt,
+		&recurring.LastRunAt,
+		&recurring.Paused,
+		&recurring.LastError,
+		&recurring.CreatedAt,
+		&recurring.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("recurring expense not found")
+		}
+		log.Printf("Error getting recurring expense by ID: %v", err)
+		return nil, err
+	}
+
+	return recurring, nil
+}
+
+func (r *RecurringExpenseRepositoryPG) GetRecurringExpensesByGroupID(groupID int) ([]*model.RecurringExpense, error) {
+	query := `
+		SELECT id, group_id, created_by_id, paid_by_id, amount, currency, category_id, description, split, frequency, interval_count, cron, start_date, end_date, next_run_at, last_run_at, paused, last_error, created_at, updated_at
+		FROM recurring_expenses
+		WHERE group_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.DB.Query(query, groupID)
+	if err != nil {
+		log.Printf("Error getting recurring expenses: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recurringExpenses []*model.RecurringExpense
+	for rows.Next() {
+		recurring := &model.RecurringExpense{}
+		err := rows.Scan(
+			&recurring.ID,
+			&recurring.GroupID,
+			&recurring.CreatedByID,
+			&recurring.PaidByID,
+			&recurring.Amount,
+			&recurring.Currency,
+			&recurring.CategoryID,
+			&recurring.Description,
+			&recurring.Split,
+			&recurring.Frequency,
+			&recurring.Interval,
+			&recurring.Cron,
+			&recurring.StartDate,
+			&recurring.EndDate,
+			&recurring.NextRunAt,
+			&recurring.LastRunAt,
+			&recurring.Paused,
+			&recurring.LastError,
+			&recurring.CreatedAt,
+			&recurring.UpdatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning recurring expense: %v", err)
+			return nil, err
+		}
+		recurringExpenses = append(recurringExpenses, recurring)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating recurring expenses: %v", err)
+		return nil, err
+	}
+
+	return recurringExpenses, nil
+}
+
+func (r *RecurringExpenseRepositoryPG) GetDueRecurringExpenses(now time.Time, limit int) ([]*model.RecurringExpense, error) {
+	query := `
+		SELECT id, group_id, created_by_id, paid_by_id, amount, currency, category_id, description, split, frequency, interval_count, cron, start_date, end_date, next_run_at, last_run_at, paused, last_error, created_at, updated_at
+		FROM recurring_expenses
+		WHERE NOT paused AND next_run_at <= $1
+			AND group_id IN (SELECT id FROM groups WHERE deleted_at IS NULL)
+		ORDER BY next_run_at, id
+		LIMIT $2
+	`
+
+	rows, err := r.DB.Query(query, now, limit)
+	if err != nil {
+		log.Printf("Error getting due recurring expenses: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recurringExpenses []*model.RecurringExpense
+	for rows.Next() {
+		recurring := &model.RecurringExpense{}
+		err := rows.Scan(
+			&recurring.ID,
+			&recurring.GroupID,
+			&recurring.CreatedByID,
+			&recurring.PaidByID,
+			&recurring.Amount,
+			&recurring.Currency,
+			&recurring.CategoryID,
+			&recurring.Description,
+			&recurring.Split,
+			&recurring.Frequency,
+			&recurring.Interval,
+			&recurring.Cron,
+			&recurring.StartDate,
+			&recurring.EndDate,
+			&recurring.NextRunAt,
+			&recurring.LastRunAt,
+			&recurring.Paused,
+			&recurring.LastError,
+			&recurring.CreatedAt,
+			&recurring.UpdatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning recurring expense: %v", err)
+			return nil, err
+		}
+		recurringExpenses = append(recurringExpenses, recurring)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating recurring expenses: %v", err)
+		return nil, err
+	}
+
+	return recurringExpenses, nil
+}
+
+// LockRecurringExpense reads a template with FOR UPDATE SKIP LOCKED, so that
+// a scheduler on another server creating the same occurrence is skipped
+// rather than waited for. A row it skips reads as nil.
+func (r *RecurringExpenseRepositoryPG) LockRecurringExpense(id int) (*model.RecurringExpense, error) {
+	query := `
+		SELECT id, group_id, created_by_id, paid_by_id, amount, currency, category_id, description, split, frequency, interval_count, cron, start_date, end_date, next_run_at, last_run_at, paused, last_error, created_at, updated_at
+		FROM recurring_expenses
+		WHERE id = $1
+		FOR UPDATE SKIP LOCKED
+	`
+
+	recurring := &model.RecurringExpense{}
+	err := r.DB.QueryRow(query, id).Scan(
+		&recurring.ID,
+		&recurring.GroupID,
+		&recurring.CreatedByID,
+		&recurring.PaidByID,
+		&recurring.Amount,
+		&recurring.Currency,
+		&recurring.CategoryID,
+		&recurring.Description,
+		&recurring.Split,
+		&recurring.Frequency,
+		&recurring.Interval,
+		&recurring.Cron,
+		&recurring.StartDate,
+		&recurring.EndDate,
+		&recurring.NextRunAt,
+		&recurring.LastRunAt,
+		&recurring.Paused,
+		&recurring.LastError,
+		&recurring.CreatedAt,
+		&recurring.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error locking recurring expense: %v", err)
+		return nil, err
+	}
+
+	return recurring, nil
+}
+
+func (r *RecurringExpenseRepositoryPG) UpdateRecurringExpense(recurring *model.RecurringExpense) (*model.RecurringExpense, error) {
+	query := `
+		UPDATE recurring_expenses
+		SET paid_by_id = $1, amount = $2, currency = $3, category_id = $4, description = $5, split = $6, frequency = $7, interval_count = $8, cron = $9,
+			start_date = $10, end_date = $11, next_run_at = $12, last_run_at = $13, paused = $14, last_error = $15, updated_at = $16
+		WHERE id = $17
+		RETURNING id, group_id, created_by_id, paid_by_id, amount, currency, category_id, description, split, frequency, interval_count, cron, start_date, end_date, next_run_at, last_run_at, paused, last_error, created_at, updated_at
+	`
+
+	recurring.UpdatedAt = time.Now()
+
+	err := r.DB.QueryRow(
+		query,
+		recurring.PaidByID,
+		recurring.Amount,
+		recurring.Currency,
+		recurring.CategoryID,
+		recurring.Description,
+		recurring.Split,
+		recurring.Frequency,
+		recurring.Interval,
+		recurring.Cron,
+		recurring.StartDate,
+		recurring.EndDate,
+		recurring.NextRunAt,
+		recurring.LastRunAt,
+		recurring.Paused,
+		recurring.LastError,
+		recurring.UpdatedAt,
+		recurring.ID,
+	).Scan(&recurring.ID, &recurring.GroupID, &recurring.CreatedByID, &recurring.PaidByID, &recurring.Amount, &recurring.Currency, &recurring.CategoryID, &recurring.Description, &recurring.Split, &recurring.Frequency, &recurring.Interval, &recurring.Cron, &recurring.StartDate, &recurring.EndDate, &recurring.NextRunAt, &recurring.LastRunAt, &recurring.Paused, &recurring.LastError, &recurring.CreatedAt, &recurring.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("recurring expense not found")
+		}
+		log.Printf("Error updating recurring expense: %v", err)
+		return nil, err
+	}
+
+	return recurring, nil
+}
+
+func (r *RecurringExpenseRepositoryPG) DeleteRecurringExpense(id int) error {
+	query := `DELETE FROM recurring_expenses WHERE id = $1`
+
+	result, err := r.DB.Exec(query, id)
+	if err != nil {
+		log.Printf("Error deleting recurring expense: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("recurring expense not found")
+	}
+
+	return nil
+}
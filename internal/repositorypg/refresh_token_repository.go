@@ -0,0 +1,106 @@
+package repositorypg
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+type RefreshTokenRepositoryPG struct {
+	DB DBTX
+}
+
+func NewRefreshTokenRepositoryPG(db DBTX) *RefreshTokenRepositoryPG {
+	return &RefreshTokenRepositoryPG{DB: db}
+}
+
+func (r *RefreshTokenRepositoryPG) CreateRefreshToken(token *model.RefreshToken) (*model.RefreshToken, error) {
+	query := `
+		INSERT INTO refresh_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, token_hash, expires_at, revoked_at, created_at
+	`
+
+	token.CreatedAt = time.Now()
+
+	err := r.DB.QueryRow(
+		query,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	).Scan(&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt, &token.RevokedAt, &token.CreatedAt)
+
+	if err != nil {
+		log.Printf("Error creating refresh token: %v", err)
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (r *RefreshTokenRepositoryPG) GetRefreshTokenByHash(tokenHash string) (*model.RefreshToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, revoked_at, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`
+
+	token := &model.RefreshToken{}
+	err := r.DB.QueryRow(query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("refresh token not found")
+		}
+		log.Printf("Error getting refresh token: %v", err)
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// RevokeRefreshToken marks a token as revoked. Revoking an already revoked token
+// is an error, so a token can only be used once.
+func (r *RefreshTokenRepositoryPG) RevokeRefreshToken(id int) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`
+
+	result, err := r.DB.Exec(query, time.Now(), id)
+	if err != nil {
+		log.Printf("Error revoking refresh token: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("refresh token already revoked")
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepositoryPG) RevokeUserRefreshTokens(userID int) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`
+
+	_, err := r.DB.Exec(query, time.Now(), userID)
+	if err != nil {
+		log.Printf("Error revoking refresh tokens: %v", err)
+		return err
+	}
+
+	return nil
+}
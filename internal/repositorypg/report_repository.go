@@ -0,0 +1,150 @@
+package repositorypg
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+)
+
+// reportEntriesQuery turns the ledger into one row per amount a user paid,
+// consumed, sent or received, so that every report section is a GROUP BY over
+// the same rows. Settlements have no category, and soft-deleted rows are left
+// out.
+const reportEntriesQuery = `
+	SELECT 'expense' AS kind, e.group_id, e.created_at AS at, e.paid_by_id AS user_id, e.category_id,
+		e.base_amount AS paid, 0 AS consumed, 0 AS sent, 0 AS received
+	FROM expenses e
+	WHERE e.deleted_at IS NULL
+	UNION ALL
+	SELECT 'expense', e.group_id, e.created_at, es.user_id, e.category_id, 0, es.base_amount, 0, 0
+	FROM expense_splits es
+	JOIN expenses e ON e.id = es.expense_id
+	WHERE e.deleted_at IS NULL
+	UNION ALL
+	SELECT 'settlement', s.group_id, s.created_at, s.from_user_id, NULL, 0, 0, s.base_amount, 0
+	FROM settlements s
+	WHERE s.deleted_at IS NULL
+	UNION ALL
+	SELECT 'settlement', s.group_id, s.created_at, s.to_user_id, NULL, 0, 0, 0, s.base_amount
+	FROM settlements s
+	WHERE s.deleted_at IS NULL
+`
+
+// reportBuckets maps each bucket to its date_trunc field
+var reportBuckets = map[string]string{
+	model.ReportBucketDay:   "day",
+	model.ReportBucketWeek:  "week",
+	model.ReportBucketMonth: "month",
+}
+
+type ReportRepositoryPG struct {
+	DB DBTX
+}
+
+func NewReportRepositoryPG(db DBTX) *ReportRepositoryPG {
+	return &ReportRepositoryPG{DB: db}
+}
+
+// Aggregate sums expenses, splits and settlements matching the query, grouped by
+// the given dimensions and by currency, in the order of the dimensions
+func (r *ReportRepositoryPG) Aggregate(query *model.ReportQuery, dimensions []string) ([]*model.ReportEntry, error) {
+	b := &listBuilder{}
+	if query.GroupID != 0 {
+		b.where("en.group_id = " + b.arg(query.GroupID))
+	}
+	if query.UserID != 0 {
+		b.where("en.user_id = " + b.arg(query.UserID))
+	}
+	if query.From != nil {
+		b.where("en.at >= " + b.arg(*query.From))
+	}
+	if query.To != nil {
+		b.where("en.at < " + b.arg(*query.To))
+	}
+
+	var columns, joins []string
+	for _, dimension := range dimensions {
+		switch dimension {
+		case model.ReportByPeriod:
+			field, ok := reportBuckets[query.Bucket]
+			if !ok {
+				return nil, fmt.Errorf("invalid bucket %q", query.Bucket)
+			}
+			columns = append(columns, "date_trunc('"+field+"', en.at)")
+		case model.ReportByUser:
+			columns = append(columns, "en.user_id", "COALESCE(u.name, '')")
+			joins = append(joins, "LEFT JOIN users u ON u.id = en.user_id")
+		case model.ReportByGroup:
+			columns = append(columns, "en.group_id", "g.name")
+		case model.ReportByCategory:
+			columns = append(columns, "en.category_id", "COALESCE(c.name, '')")
+			joins = append(joins, "LEFT JOIN categories c ON c.id = en.category_id")
+			b.where("en.kind = 'expense'")
+		default:
+			return nil, fmt.Errorf("cannot group a report by %q", dimension)
+		}
+	}
+	columns = append(columns, "g.currency")
+
+	// GROUP BY and ORDER BY refer to the columns by position
+	positions := make([]string, len(columns))
+	for i := range columns {
+		positions[i] = strconv.Itoa(i + 1)
+	}
+
+	sqlQuery := `
+		SELECT ` + strings.Join(columns, ", ") + `,
+			SUM(en.paid)::BIGINT, SUM(en.consumed)::BIGINT, SUM(en.sent)::BIGINT, SUM(en.received)::BIGINT
+		FROM (` + reportEntriesQuery + `) en
+		JOIN groups g ON g.id = en.group_id AND g.deleted_at IS NULL
+		` + strings.Join(joins, "\n\t\t") + b.whereClause() + `
+		GROUP BY ` + strings.Join(positions, ", ") + `
+		ORDER BY ` + strings.Join(positions, ", ")
+
+	rows, err := r.DB.Query(sqlQuery, b.args...)
+	if err != nil {
+		log.Printf("Error aggregating report: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*model.ReportEntry
+	for rows.Next() {
+		entry := &model.ReportEntry{}
+		var periodStart time.Time
+		var dest []interface{}
+		for _, dimension := range dimensions {
+			switch dimension {
+			case model.ReportByPeriod:
+				dest = append(dest, &periodStart)
+			case model.ReportByUser:
+				dest = append(dest, &entry.UserID, &entry.UserName)
+			case model.ReportByGroup:
+				dest = append(dest, &entry.GroupID, &entry.GroupName)
+			case model.ReportByCategory:
+				dest = append(dest, &entry.CategoryID, &entry.CategoryName)
+			}
+		}
+		dest = append(dest, &entry.Currency, &entry.Paid, &entry.Consumed, &entry.SettlementsSent, &entry.SettlementsReceived)
+
+		if err := rows.Scan(dest...); err != nil {
+			log.Printf("Error scanning report entry: %v", err)
+			return nil, err
+		}
+		if !periodStart.IsZero() {
+			entry.PeriodStart = &periodStart
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating report entries: %v", err)
+		return nil, err
+	}
+
+	return entries, nil
+}
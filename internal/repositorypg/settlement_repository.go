@@ -7,24 +7,28 @@ import (
 	"time"
 
 	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
 )
 
 type SettlementRepositoryPG struct {
-	DB *sql.DB
+	DB DBTX
 }
 
-func NewSettlementRepositoryPG(db *sql.DB) *SettlementRepositoryPG {
+func NewSettlementRepositoryPG(db DBTX) *SettlementRepositoryPG {
 	return &SettlementRepositoryPG{DB: db}
 }
 
 func (r *SettlementRepositoryPG) CreateSettlement(settlement *model.Settlement) (*model.Settlement, error) {
 	query := `
-		INSERT INTO settlements (group_id, from_user_id, to_user_id, amount, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, group_id, from_user_id, to_user_id, amount, description, created_at, updated_at
+		INSERT INTO settlements (group_id, from_user_id, to_user_id, amount, currency, fx_rate, base_amount, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, group_id, from_user_id, to_user_id, amount, currency, fx_rate, base_amount, description, created_at, updated_at
 	`
 
-	settlement.CreatedAt = time.Now()
+	// Imported settlements keep the date they were originally recorded on
+	if settlement.CreatedAt.IsZero() {
+		settlement.CreatedAt = time.Now()
+	}
 	settlement.UpdatedAt = time.Now()
 
 	err := r.DB.QueryRow(
@@ -33,11 +37,15 @@ func (r *SettlementRepositoryPG) CreateSettlement(settlement *model.Settlement)
 		settlement.FromUserID,
 		settlement.ToUserID,
 		settlement.Amount,
+		settlement.Currency,
+		settlement.FXRate,
+		settlement.BaseAmount,
 		settlement.Description,
 		settlement.CreatedAt,
 		settlement.UpdatedAt,
 	).Scan(&settlement.ID, &settlement.GroupID, &settlement.FromUserID, &settlement.ToUserID,
-		&settlement.Amount, &settlement.Description, &settlement.CreatedAt, &settlement.UpdatedAt)
+		&settlement.Amount, &settlement.Currency, &settlement.FXRate, &settlement.BaseAmount,
+		&settlement.Description, &settlement.CreatedAt, &settlement.UpdatedAt)
 
 	if err != nil {
 		log.Printf("Error creating settlement: %v", err)
@@ -49,15 +57,16 @@ func (r *SettlementRepositoryPG) CreateSettlement(settlement *model.Settlement)
 
 func (r *SettlementRepositoryPG) GetSettlementByID(id int) (*model.Settlement, error) {
 	query := `
-		SELECT id, group_id, from_user_id, to_user_id, amount, description, created_at, updated_at
+		SELECT id, group_id, from_user_id, to_user_id, amount, currency, fx_rate, base_amount, description, created_at, updated_at
 		FROM settlements
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	settlement := &model.Settlement{}
 	err := r.DB.QueryRow(query, id).Scan(
 		&settlement.ID, &settlement.GroupID, &settlement.FromUserID, &settlement.ToUserID,
-		&settlement.Amount, &settlement.Description, &settlement.CreatedAt, &settlement.UpdatedAt,
+		&settlement.Amount, &settlement.Currency, &settlement.FXRate, &settlement.BaseAmount,
+		&settlement.Description, &settlement.CreatedAt, &settlement.UpdatedAt,
 	)
 
 	if err != nil {
@@ -70,11 +79,38 @@ func (r *SettlementRepositoryPG) GetSettlementByID(id int) (*model.Settlement, e
 	return settlement, nil
 }
 
+// LockSettlement reads a settlement with FOR UPDATE, deleted or not, so that
+// deleting and restoring it are serialized
+func (r *SettlementRepositoryPG) LockSettlement(id int) (*model.Settlement, error) {
+	query := `
+		SELECT id, group_id, from_user_id, to_user_id, amount, currency, fx_rate, base_amount, description, created_at, updated_at, deleted_at
+		FROM settlements
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	settlement := &model.Settlement{}
+	err := r.DB.QueryRow(query, id).Scan(
+		&settlement.ID, &settlement.GroupID, &settlement.FromUserID, &settlement.ToUserID,
+		&settlement.Amount, &settlement.Currency, &settlement.FXRate, &settlement.BaseAmount,
+		&settlement.Description, &settlement.CreatedAt, &settlement.UpdatedAt, &settlement.DeletedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("settlement not found")
+		}
+		return nil, fmt.Errorf("failed to lock settlement: %v", err)
+	}
+
+	return settlement, nil
+}
+
 func (r *SettlementRepositoryPG) GetSettlementsByGroupID(groupID int) ([]*model.Settlement, error) {
 	query := `
-		SELECT id, group_id, from_user_id, to_user_id, amount, description, created_at, updated_at
+		SELECT id, group_id, from_user_id, to_user_id, amount, currency, fx_rate, base_amount, description, created_at, updated_at
 		FROM settlements
-		WHERE group_id = $1
+		WHERE group_id = $1 AND deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
 
@@ -89,7 +125,8 @@ func (r *SettlementRepositoryPG) GetSettlementsByGroupID(groupID int) ([]*model.
 		settlement := &model.Settlement{}
 		if err := rows.Scan(
 			&settlement.ID, &settlement.GroupID, &settlement.FromUserID, &settlement.ToUserID,
-			&settlement.Amount, &settlement.Description, &settlement.CreatedAt, &settlement.UpdatedAt,
+			&settlement.Amount, &settlement.Currency, &settlement.FXRate, &settlement.BaseAmount,
+			&settlement.Description, &settlement.CreatedAt, &settlement.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan settlement: %v", err)
 		}
@@ -99,11 +136,40 @@ func (r *SettlementRepositoryPG) GetSettlementsByGroupID(groupID int) ([]*model.
 	return settlements, nil
 }
 
+// IterateSettlementsByGroupID streams the group's settlements, oldest first,
+// through a cursor
+func (r *SettlementRepositoryPG) IterateSettlementsByGroupID(groupID int) (repository.Iterator[model.Settlement], error) {
+	query := `
+		SELECT id, group_id, from_user_id, to_user_id, amount, currency, fx_rate, base_amount, description, created_at, updated_at
+		FROM settlements
+		WHERE group_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at, id
+	`
+
+	scan := func(rows *sql.Rows) (*model.Settlement, error) {
+		settlement := &model.Settlement{}
+		if err := rows.Scan(
+			&settlement.ID, &settlement.GroupID, &settlement.FromUserID, &settlement.ToUserID,
+			&settlement.Amount, &settlement.Currency, &settlement.FXRate, &settlement.BaseAmount,
+			&settlement.Description, &settlement.CreatedAt, &settlement.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan settlement: %v", err)
+		}
+		return settlement, nil
+	}
+
+	rows, err := openCursor(r.DB, scan, query, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settlements: %v", err)
+	}
+	return rows, nil
+}
+
 func (r *SettlementRepositoryPG) GetSettlementsByUserID(userID int) ([]*model.Settlement, error) {
 	query := `
-		SELECT id, group_id, from_user_id, to_user_id, amount, description, created_at, updated_at
+		SELECT id, group_id, from_user_id, to_user_id, amount, currency, fx_rate, base_amount, description, created_at, updated_at
 		FROM settlements
-		WHERE from_user_id = $1 OR to_user_id = $1
+		WHERE (from_user_id = $1 OR to_user_id = $1) AND deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
 
@@ -118,7 +184,8 @@ func (r *SettlementRepositoryPG) GetSettlementsByUserID(userID int) ([]*model.Se
 		settlement := &model.Settlement{}
 		if err := rows.Scan(
 			&settlement.ID, &settlement.GroupID, &settlement.FromUserID, &settlement.ToUserID,
-			&settlement.Amount, &settlement.Description, &settlement.CreatedAt, &settlement.UpdatedAt,
+			&settlement.Amount, &settlement.Currency, &settlement.FXRate, &settlement.BaseAmount,
+			&settlement.Description, &settlement.CreatedAt, &settlement.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan settlement: %v", err)
 		}
@@ -130,8 +197,9 @@ func (r *SettlementRepositoryPG) GetSettlementsByUserID(userID int) ([]*model.Se
 
 func (r *SettlementRepositoryPG) GetAllSettlements() ([]*model.Settlement, error) {
 	query := `
-		SELECT id, group_id, from_user_id, to_user_id, amount, description, created_at, updated_at
+		SELECT id, group_id, from_user_id, to_user_id, amount, currency, fx_rate, base_amount, description, created_at, updated_at
 		FROM settlements
+		WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
 
@@ -146,7 +214,8 @@ func (r *SettlementRepositoryPG) GetAllSettlements() ([]*model.Settlement, error
 		settlement := &model.Settlement{}
 		if err := rows.Scan(
 			&settlement.ID, &settlement.GroupID, &settlement.FromUserID, &settlement.ToUserID,
-			&settlement.Amount, &settlement.Description, &settlement.CreatedAt, &settlement.UpdatedAt,
+			&settlement.Amount, &settlement.Currency, &settlement.FXRate, &settlement.BaseAmount,
+			&settlement.Description, &settlement.CreatedAt, &settlement.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan settlement: %v", err)
 		}
@@ -155,3 +224,163 @@ func (r *SettlementRepositoryPG) GetAllSettlements() ([]*model.Settlement, error
 
 	return settlements, nil
 }
+
+var settlementSortColumns = map[string]sortColumn{
+	model.SortByCreatedAt: {expr: "s.created_at", parse: parseTimeValue},
+	model.SortByAmount:    {expr: "s.amount", parse: parseIntValue},
+}
+
+// ListSettlements returns one page of settlements. query.GroupID limits it to a
+// group and query.UserID to the settlements that user sent or received.
+func (r *SettlementRepositoryPG) ListSettlements(query *model.ListQuery) ([]*model.Settlement, string, error) {
+	b := &listBuilder{}
+	b.where("s.deleted_at IS NULL")
+	if query.GroupID != 0 {
+		b.where("s.group_id = " + b.arg(query.GroupID))
+	}
+	if query.UserID != 0 {
+		userID := b.arg(query.UserID)
+		b.where("(s.from_user_id = " + userID + " OR s.to_user_id = " + userID + ")")
+	}
+	if query.PayerID != 0 {
+		b.where("s.from_user_id = " + b.arg(query.PayerID))
+	}
+	if query.ParticipantID != 0 {
+		participantID := b.arg(query.ParticipantID)
+		b.where("(s.from_user_id = " + participantID + " OR s.to_user_id = " + participantID + ")")
+	}
+	b.ledgerFilters(query, "s.created_at", "s.amount", "s.description")
+
+	clauses, err := b.build(query, settlementSortColumns, "s.id")
+	if err != nil {
+		return nil, "", err
+	}
+
+	sqlQuery := `
+		SELECT s.id, s.group_id, s.from_user_id, s.to_user_id, s.amount, s.currency, s.fx_rate, s.base_amount, s.description, s.created_at, s.updated_at
+		FROM settlements s` + clauses
+
+	rows, err := r.DB.Query(sqlQuery, b.args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query settlements: %v", err)
+	}
+	defer rows.Close()
+
+	var settlements []*model.Settlement
+	for rows.Next() {
+		settlement := &model.Settlement{}
+		if err := rows.Scan(
+			&settlement.ID, &settlement.GroupID, &settlement.FromUserID, &settlement.ToUserID,
+			&settlement.Amount, &settlement.Currency, &settlement.FXRate, &settlement.BaseAmount,
+			&settlement.Description, &settlement.CreatedAt, &settlement.UpdatedAt,
+		); err != nil {
+			return nil, "", fmt.Errorf("failed to scan settlement: %v", err)
+		}
+		settlements = append(settlements, settlement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to iterate settlements: %v", err)
+	}
+
+	settlements, next := nextCursor(settlements, query, model.SettlementSortValue, func(s *model.Settlement) int { return s.ID })
+	return settlements, next, nil
+}
+
+func (r *SettlementRepositoryPG) DeleteSettlement(id int, deletedAt time.Time) error {
+	query := `UPDATE settlements SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL`
+
+	result, err := r.DB.Exec(query, id, deletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to delete settlement: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("settlement not found")
+	}
+
+	return nil
+}
+
+func (r *SettlementRepositoryPG) DeleteGroupSettlements(groupID int, deletedAt time.Time) error {
+	query := `UPDATE settlements SET deleted_at = $2 WHERE group_id = $1 AND deleted_at IS NULL`
+
+	if _, err := r.DB.Exec(query, groupID, deletedAt); err != nil {
+		return fmt.Errorf("failed to delete group settlements: %v", err)
+	}
+
+	return nil
+}
+
+func (r *SettlementRepositoryPG) GetDeletedSettlementByID(id int) (*model.Settlement, error) {
+	query := `
+		SELECT id, group_id, from_user_id, to_user_id, amount, currency, fx_rate, base_amount, description, created_at, updated_at, deleted_at
+		FROM settlements
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	settlement := &model.Settlement{}
+	err := r.DB.QueryRow(query, id).Scan(
+		&settlement.ID, &settlement.GroupID, &settlement.FromUserID, &settlement.ToUserID,
+		&settlement.Amount, &settlement.Currency, &settlement.FXRate, &settlement.BaseAmount,
+		&settlement.Description, &settlement.CreatedAt, &settlement.UpdatedAt, &settlement.DeletedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("deleted settlement not found")
+		}
+		return nil, fmt.Errorf("failed to get deleted settlement: %v", err)
+	}
+
+	return settlement, nil
+}
+
+func (r *SettlementRepositoryPG) RestoreSettlement(id int) error {
+	query := `UPDATE settlements SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	result, err := r.DB.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore settlement: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("deleted settlement not found")
+	}
+
+	return nil
+}
+
+func (r *SettlementRepositoryPG) RestoreGroupSettlements(groupID int, deletedAt time.Time) error {
+	query := `UPDATE settlements SET deleted_at = NULL WHERE group_id = $1 AND deleted_at = $2`
+
+	if _, err := r.DB.Exec(query, groupID, deletedAt); err != nil {
+		return fmt.Errorf("failed to restore group settlements: %v", err)
+	}
+
+	return nil
+}
+
+// PurgeDeletedSettlements removes settlements deleted before the given time;
+// their comments go with them through the foreign key
+func (r *SettlementRepositoryPG) PurgeDeletedSettlements(before time.Time) (int64, error) {
+	query := `DELETE FROM settlements WHERE deleted_at < $1`
+
+	result, err := r.DB.Exec(query, before)
+	if err != nil {
+		log.Printf("Error purging deleted settlements: %v", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,119 @@
+package repositorypg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+// DBTX is the part of *sql.DB and *sql.Tx used by the repositories, so the same
+// repository code runs either directly on the pool or inside a transaction
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+type UnitOfWorkPG struct {
+	DB *sql.DB
+}
+
+func NewUnitOfWorkPG(db *sql.DB) *UnitOfWorkPG {
+	return &UnitOfWorkPG{DB: db}
+}
+
+// Do runs fn inside a database transaction, committing it when fn succeeds and
+// rolling it back otherwise
+func (u *UnitOfWorkPG) Do(fn func(tx repository.Tx) error) error {
+	sqlTx, err := u.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			sqlTx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&txPG{tx: sqlTx}); err != nil {
+		if rbErr := sqlTx.Rollback(); rbErr != nil {
+			log.Printf("Error rolling back transaction: %v", rbErr)
+		}
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// View runs fn inside a read-only REPEATABLE READ transaction, so every query
+// of fn sees the data as it was when the first one ran
+func (u *UnitOfWorkPG) View(fn func(tx repository.Tx) error) error {
+	sqlTx, err := u.DB.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer sqlTx.Rollback()
+
+	return fn(&txPG{tx: sqlTx})
+}
+
+// txPG hands out repositories bound to one *sql.Tx
+type txPG struct {
+	tx *sql.Tx
+}
+
+func (t *txPG) Users() repository.UserRepository {
+	return NewUserRepositoryPG(t.tx)
+}
+
+func (t *txPG) Groups() repository.GroupRepository {
+	return NewGroupRepositoryPG(t.tx)
+}
+
+func (t *txPG) Members() repository.GroupMemberRepository {
+	return NewGroupMemberRepositoryPG(t.tx)
+}
+
+func (t *txPG) Expenses() repository.ExpenseRepository {
+	return NewExpenseRepositoryPG(t.tx)
+}
+
+func (t *txPG) Splits() repository.ExpenseSplitRepository {
+	return NewExpenseSplitRepositoryPG(t.tx)
+}
+
+func (t *txPG) Settlements() repository.SettlementRepository {
+	return NewSettlementRepositoryPG(t.tx)
+}
+
+func (t *txPG) Balances() repository.BalanceRepository {
+	return NewBalanceRepositoryPG(t.tx)
+}
+
+func (t *txPG) Invitations() repository.InvitationRepository {
+	return NewInvitationRepositoryPG(t.tx)
+}
+
+func (t *txPG) RecurringExpenses() repository.RecurringExpenseRepository {
+	return NewRecurringExpenseRepositoryPG(t.tx)
+}
+
+func (t *txPG) Activities() repository.ActivityRepository {
+	return NewActivityRepositoryPG(t.tx)
+}
+
+func (t *txPG) Categories() repository.CategoryRepository {
+	return NewCategoryRepositoryPG(t.tx)
+}
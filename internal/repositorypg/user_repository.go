@@ -10,18 +10,18 @@ import (
 )
 
 type UserRepositoryPG struct {
-	DB *sql.DB
+	DB DBTX
 }
 
-func NewUserRepositoryPG(db *sql.DB) *UserRepositoryPG {
+func NewUserRepositoryPG(db DBTX) *UserRepositoryPG {
 	return &UserRepositoryPG{DB: db}
 }
 
 func (r *UserRepositoryPG) CreateUser(user *model.User) (*model.User, error) {
 	query := `
-		INSERT INTO users (email, name, created_at, updated_at)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, email, name, created_at, updated_at
+		INSERT INTO users (email, name, password_hash, placeholder, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, email, name, password_hash, placeholder, created_at, updated_at
 	`
 
 	user.CreatedAt = time.Now()
@@ -29,11 +29,13 @@ func (r *UserRepositoryPG) CreateUser(user *model.User) (*model.User, error) {
 
 	err := r.DB.QueryRow(
 		query,
-		user.Email,
+		model.NormalizeEmail(user.Email),
 		user.Name,
+		user.PasswordHash,
+		user.Placeholder,
 		user.CreatedAt,
 		user.UpdatedAt,
-	).Scan(&user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.Placeholder, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
@@ -48,16 +50,18 @@ func (r *UserRepositoryPG) CreateUser(user *model.User) (*model.User, error) {
 
 func (r *UserRepositoryPG) GetUserByEmail(email string) (*model.User, error) {
 	query := `
-		SELECT id, email, name, created_at, updated_at
+		SELECT id, email, name, password_hash, placeholder, created_at, updated_at
 		FROM users
-		WHERE email = $1
+		WHERE lower(email) = $1
 	`
 
 	user := &model.User{}
-	err := r.DB.QueryRow(query, email).Scan(
+	err := r.DB.QueryRow(query, model.NormalizeEmail(email)).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Name,
+		&user.PasswordHash,
+		&user.Placeholder,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -75,7 +79,7 @@ func (r *UserRepositoryPG) GetUserByEmail(email string) (*model.User, error) {
 
 func (r *UserRepositoryPG) GetUserByID(id int) (*model.User, error) {
 	query := `
-		SELECT id, email, name, created_at, updated_at
+		SELECT id, email, name, password_hash, placeholder, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
@@ -85,6 +89,8 @@ func (r *UserRepositoryPG) GetUserByID(id int) (*model.User, error) {
 		&user.ID,
 		&user.Email,
 		&user.Name,
+		&user.PasswordHash,
+		&user.Placeholder,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -102,7 +108,7 @@ func (r *UserRepositoryPG) GetUserByID(id int) (*model.User, error) {
 
 func (r *UserRepositoryPG) GetAllUsers() ([]*model.User, error) {
 	query := `
-		SELECT id, email, name, created_at, updated_at
+		SELECT id, email, name, password_hash, placeholder, created_at, updated_at
 		FROM users
 		ORDER BY created_at DESC
 	`
@@ -121,6 +127,8 @@ func (r *UserRepositoryPG) GetAllUsers() ([]*model.User, error) {
 			&user.ID,
 			&user.Email,
 			&user.Name,
+			&user.PasswordHash,
+			&user.Placeholder,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		)
@@ -144,7 +152,7 @@ func (r *UserRepositoryPG) UpdateUser(user *model.User) (*model.User, error) {
 		UPDATE users
 		SET name = $1, updated_at = $2
 		WHERE id = $3
-		RETURNING id, email, name, created_at, updated_at
+		RETURNING id, email, name, password_hash, placeholder, created_at, updated_at
 	`
 
 	user.UpdatedAt = time.Now()
@@ -154,7 +162,7 @@ func (r *UserRepositoryPG) UpdateUser(user *model.User) (*model.User, error) {
 		user.Name,
 		user.UpdatedAt,
 		user.ID,
-	).Scan(&user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.Placeholder, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		log.Printf("Error updating user: %v", err)
@@ -164,6 +172,81 @@ func (r *UserRepositoryPG) UpdateUser(user *model.User) (*model.User, error) {
 	return user, nil
 }
 
+// RegisterPlaceholder gives a placeholder user a name and password, keeping
+// their history
+func (r *UserRepositoryPG) RegisterPlaceholder(user *model.User) (*model.User, error) {
+	query := `
+		UPDATE users
+		SET name = $1, password_hash = $2, placeholder = FALSE, updated_at = $3
+		WHERE id = $4 AND placeholder
+		RETURNING id, email, name, password_hash, placeholder, created_at, updated_at
+	`
+
+	user.UpdatedAt = time.Now()
+
+	err := r.DB.QueryRow(
+		query,
+		user.Name,
+		user.PasswordHash,
+		user.UpdatedAt,
+		user.ID,
+	).Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.Placeholder, &user.CreatedAt, &user.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("placeholder not found")
+		}
+		log.Printf("Error registering placeholder: %v", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// mergeUserStatements move everything recorded for user $1 to user $2. Splits
+// of an expense both users share are added up, and memberships of a group
+// both belong to keep the role of $2.
+var mergeUserStatements = []string{
+	`UPDATE expense_splits i
+	SET amount = i.amount + f.amount, base_amount = i.base_amount + f.base_amount, weight = i.weight + f.weight, updated_at = NOW()
+	FROM expense_splits f
+	WHERE i.user_id = $2 AND f.user_id = $1 AND f.expense_id = i.expense_id`,
+	`DELETE FROM expense_splits f
+	USING expense_splits i
+	WHERE f.user_id = $1 AND i.user_id = $2 AND f.expense_id = i.expense_id`,
+	`UPDATE expense_splits SET user_id = $2, updated_at = NOW() WHERE user_id = $1`,
+	`UPDATE expenses SET paid_by_id = $2, updated_at = NOW() WHERE paid_by_id = $1`,
+	`UPDATE settlements SET from_user_id = $2, updated_at = NOW() WHERE from_user_id = $1`,
+	`UPDATE settlements SET to_user_id = $2, updated_at = NOW() WHERE to_user_id = $1`,
+	`UPDATE groups SET creator_id = $2, updated_at = NOW() WHERE creator_id = $1`,
+	`UPDATE group_invitations SET invited_by_id = $2 WHERE invited_by_id = $1`,
+	`UPDATE recurring_expenses SET paid_by_id = $2, updated_at = NOW() WHERE paid_by_id = $1`,
+	`UPDATE recurring_expenses SET created_by_id = $2, updated_at = NOW() WHERE created_by_id = $1`,
+	`UPDATE expense_attachments SET uploaded_by_id = $2 WHERE uploaded_by_id = $1`,
+	`UPDATE comments SET author_id = $2 WHERE author_id = $1`,
+	`UPDATE activity_log SET actor_id = $2 WHERE actor_id = $1`,
+	`DELETE FROM group_members f
+	USING group_members i
+	WHERE f.user_id = $1 AND i.user_id = $2 AND f.group_id = i.group_id`,
+	`UPDATE group_members SET user_id = $2, updated_at = NOW() WHERE user_id = $1`,
+	`DELETE FROM balances WHERE from_user_id = $1 OR to_user_id = $1`,
+	`DELETE FROM users WHERE id = $1`,
+}
+
+// MergeUser moves the history and memberships of fromID to intoID and deletes
+// fromID. Settlements between the two become payments to oneself, which
+// balances ignore. The balances of the groups fromID has ledger rows in are
+// left stale, to be rebuilt from the ledger; it must run in a transaction.
+func (r *UserRepositoryPG) MergeUser(fromID, intoID int) error {
+	for _, statement := range mergeUserStatements {
+		if _, err := r.DB.Exec(statement, fromID, intoID); err != nil {
+			log.Printf("Error merging user %d into %d: %v", fromID, intoID, err)
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *UserRepositoryPG) DeleteUser(id int) error {
 	query := `DELETE FROM users WHERE id = $1`
 
@@ -184,3 +267,73 @@ func (r *UserRepositoryPG) DeleteUser(id int) error {
 
 	return nil
 }
+
+var userSortColumns = map[string]sortColumn{
+	model.SortByCreatedAt: {expr: "created_at", parse: parseTimeValue},
+	model.SortByName:      {expr: "name", parse: parseTextValue},
+	model.SortByEmail:     {expr: "email", parse: parseTextValue},
+}
+
+// ListUsers returns one page of users. Only query.UserID and the date range of
+// the query apply.
+func (r *UserRepositoryPG) ListUsers(query *model.ListQuery) ([]*model.User, string, error) {
+	b := &listBuilder{}
+	if query.UserID != 0 {
+		userID := b.arg(query.UserID)
+		b.where(`(id = ` + userID + ` OR id IN (
+			SELECT other.user_id
+			FROM group_members other
+			JOIN group_members own ON own.group_id = other.group_id
+			WHERE own.user_id = ` + userID + `
+		))`)
+	}
+	if query.From != nil {
+		b.where("created_at >= " + b.arg(*query.From))
+	}
+	if query.To != nil {
+		b.where("created_at < " + b.arg(*query.To))
+	}
+
+	clauses, err := b.build(query, userSortColumns, "id")
+	if err != nil {
+		return nil, "", err
+	}
+
+	sqlQuery := `
+		SELECT id, email, name, password_hash, placeholder, created_at, updated_at
+		FROM users` + clauses
+
+	rows, err := r.DB.Query(sqlQuery, b.args...)
+	if err != nil {
+		log.Printf("Error listing users: %v", err)
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var users []*model.User
+	for rows.Next() {
+		user := &model.User{}
+		err := rows.Scan(
+			&user.ID,
+			&user.Email,
+			&user.Name,
+			&user.PasswordHash,
+			&user.Placeholder,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning user: %v", err)
+			return nil, "", err
+		}
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating users: %v", err)
+		return nil, "", err
+	}
+
+	users, next := nextCursor(users, query, model.UserSortValue, func(u *model.User) int { return u.ID })
+	return users, next, nil
+}
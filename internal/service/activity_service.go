@@ -0,0 +1,196 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+// ActivityService reads the activity log of groups. Entries are written by the
+// services making the changes, in the same transaction, through recordActivity.
+type ActivityService struct {
+	activityRepo repository.ActivityRepository
+}
+
+func NewActivityService(activityRepo repository.ActivityRepository) *ActivityService {
+	return &ActivityService{activityRepo: activityRepo}
+}
+
+// GetGroupActivity returns a page of the group's activity log, newest first
+// unless the query asks otherwise
+func (s *ActivityService) GetGroupActivity(groupID int, query *model.ListQuery) ([]*model.Activity, string, error) {
+	query.GroupID = groupID
+	return s.activityRepo.ListActivities(query)
+}
+
+// recordActivity appends an entry to the group's activity log. before and after
+// are snapshots of the entity, nil when it does not exist on that side of the
+// change; only the fields that differ are kept. An update that changed nothing
+// is not recorded. actorID is 0 for changes the server makes itself.
+func recordActivity(tx repository.Tx, groupID, actorID int, action, entityType string, entityID int, before, after interface{}) error {
+	changes, err := diffSnapshots(before, after)
+	if err != nil {
+		return err
+	}
+	if action == model.ActivityUpdated && len(changes) == 0 {
+		return nil
+	}
+
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	activity := &model.Activity{
+		GroupID:    groupID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    encoded,
+	}
+	if actorID != 0 {
+		activity.ActorID = &actorID
+	}
+
+	if _, err := tx.Activities().CreateActivity(activity); err != nil {
+		return fmt.Errorf("failed to record activity: %v", err)
+	}
+	return nil
+}
+
+// diffSnapshots compares the JSON fields of two snapshots and returns the ones
+// that differ
+func diffSnapshots(before, after interface{}) (map[string]model.FieldChange, error) {
+	beforeFields, err := snapshotFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := snapshotFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]model.FieldChange)
+	for field, value := range beforeFields {
+		if afterValue, ok := afterFields[field]; !ok || !bytes.Equal(value, afterValue) {
+			changes[field] = model.FieldChange{Before: value, After: afterValue}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = model.FieldChange{After: value}
+		}
+	}
+	return changes, nil
+}
+
+func snapshotFields(snapshot interface{}) (map[string]json.RawMessage, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// expenseSnapshot is what the activity log records of an expense
+type expenseSnapshot struct {
+	PaidByID    int          `json:"paid_by_id"`
+	Amount      money.Amount `json:"amount"`
+	Currency    string       `json:"currency"`
+	BaseAmount  money.Amount `json:"base_amount"`
+	SplitMode   string       `json:"split_mode"`
+	CategoryID  *int         `json:"category_id"`
+	Description string       `json:"description"`
+	// Splits is each participant's share, by user ID
+	Splits map[int]money.Amount `json:"splits"`
+}
+
+// snapshotExpense reads an expense and its splits inside tx
+func snapshotExpense(tx repository.Tx, expenseID int) (*model.Expense, *expenseSnapshot, error) {
+	expense, err := tx.Expenses().GetExpenseByID(expenseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	splits, err := tx.Splits().GetSplitsByExpenseID(expenseID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snapshot := &expenseSnapshot{
+		PaidByID:    expense.PaidByID,
+		Amount:      expense.Amount,
+		Currency:    expense.Currency,
+		BaseAmount:  expense.BaseAmount,
+		SplitMode:   expense.SplitMode,
+		CategoryID:  expense.CategoryID,
+		Description: expense.Description,
+		Splits:      make(map[int]money.Amount, len(splits)),
+	}
+	for _, split := range splits {
+		snapshot.Splits[split.UserID] = split.Amount
+	}
+	return expense, snapshot, nil
+}
+
+// recordExpenseChange records the change of an expense from before to its
+// current state
+func recordExpenseChange(tx repository.Tx, actorID, expenseID int, before *expenseSnapshot) error {
+	expense, after, err := snapshotExpense(tx, expenseID)
+	if err != nil {
+		return err
+	}
+	return recordActivity(tx, expense.GroupID, actorID, model.ActivityUpdated, model.EntityExpense, expenseID, before, after)
+}
+
+// settlementSnapshot is what the activity log records of a settlement
+type settlementSnapshot struct {
+	FromUserID  int          `json:"from_user_id"`
+	ToUserID    int          `json:"to_user_id"`
+	Amount      money.Amount `json:"amount"`
+	Currency    string       `json:"currency"`
+	BaseAmount  money.Amount `json:"base_amount"`
+	Description string       `json:"description"`
+}
+
+func snapshotSettlement(settlement *model.Settlement) *settlementSnapshot {
+	return &settlementSnapshot{
+		FromUserID:  settlement.FromUserID,
+		ToUserID:    settlement.ToUserID,
+		Amount:      settlement.Amount,
+		Currency:    settlement.Currency,
+		BaseAmount:  settlement.BaseAmount,
+		Description: settlement.Description,
+	}
+}
+
+// memberSnapshot is what the activity log records of a group member
+type memberSnapshot struct {
+	UserID int    `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+func snapshotMember(member *model.GroupMember) *memberSnapshot {
+	return &memberSnapshot{UserID: member.UserID, Role: member.Role}
+}
+
+// groupSnapshot is what the activity log records of a group
+type groupSnapshot struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Currency    string `json:"currency"`
+}
+
+func snapshotGroup(group *model.Group) *groupSnapshot {
+	return &groupSnapshot{Name: group.Name, Description: group.Description, Currency: group.Currency}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/blobstore"
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+// ErrAttachmentTooLarge is returned for uploads over the size limit
+var ErrAttachmentTooLarge = errors.New("attachment is too large")
+
+// AttachmentService attaches files such as receipts to expenses. Metadata is
+// kept in the database and contents in blob storage.
+type AttachmentService struct {
+	attachmentRepo repository.AttachmentRepository
+	expenseRepo    repository.ExpenseRepository
+	store          blobstore.Store
+	// maxSize is the largest file accepted, in bytes
+	maxSize int64
+}
+
+func NewAttachmentService(
+	attachmentRepo repository.AttachmentRepository,
+	expenseRepo repository.ExpenseRepository,
+	store blobstore.Store,
+	maxSize int64,
+) *AttachmentService {
+	return &AttachmentService{
+		attachmentRepo: attachmentRepo,
+		expenseRepo:    expenseRepo,
+		store:          store,
+		maxSize:        maxSize,
+	}
+}
+
+// MaxSize is the largest file accepted, in bytes
+func (s *AttachmentService) MaxSize() int64 {
+	return s.maxSize
+}
+
+func newAttachmentResponse(attachment *model.Attachment) *model.AttachmentResponse {
+	return &model.AttachmentResponse{
+		ID:           attachment.ID,
+		ExpenseID:    attachment.ExpenseID,
+		UploadedByID: attachment.UploadedByID,
+		FileName:     attachment.FileName,
+		ContentType:  attachment.ContentType,
+		Size:         attachment.Size,
+		HasThumbnail: attachment.ThumbnailKey != "",
+		CreatedAt:    attachment.CreatedAt,
+	}
+}
+
+// UploadAttachment stores the file read from r and attaches it to the expense.
+// Its type is sniffed from the content, whatever the file name says; images
+// get a thumbnail.
+func (s *AttachmentService) UploadAttachment(expenseID, uploaderID int, fileName string, r io.Reader) (*model.AttachmentResponse, error) {
+	if _, err := s.expenseRepo.GetExpenseByID(expenseID); err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment: %v", err)
+	}
+	if int64(len(content)) > s.maxSize {
+		return nil, fmt.Errorf("%w: the limit is %d bytes", ErrAttachmentTooLarge, s.maxSize)
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("attachment is empty")
+	}
+
+	contentType := sniffContentType(content)
+	if !allowedContentType(contentType) {
+		return nil, fmt.Errorf("unsupported attachment type %s, expected one of %s", contentType, strings.Join(model.AttachmentContentTypes, ", "))
+	}
+
+	token, err := newRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	attachment := &model.Attachment{
+		ExpenseID:    expenseID,
+		UploadedByID: &uploaderID,
+		FileName:     cleanFileName(fileName),
+		ContentType:  contentType,
+		Size:         int64(len(content)),
+		StorageKey:   fmt.Sprintf("expenses/%d/%s", expenseID, token),
+	}
+
+	if err := s.store.Put(attachment.StorageKey, content, contentType); err != nil {
+		return nil, err
+	}
+
+	// An image the decoders cannot read is still attached, only without a
+	// thumbnail
+	if strings.HasPrefix(contentType, "image/") {
+		thumbnail, err := makeThumbnail(content)
+		if err != nil {
+			log.Printf("Error making thumbnail of %s: %v", attachment.StorageKey, err)
+		} else {
+			thumbnailKey := attachment.StorageKey + "-thumbnail"
+			if err := s.store.Put(thumbnailKey, thumbnail, "image/jpeg"); err != nil {
+				deleteBlobs(s.store, attachment)
+				return nil, err
+			}
+			attachment.ThumbnailKey = thumbnailKey
+		}
+	}
+
+	created, err := s.attachmentRepo.CreateAttachment(attachment)
+	if err != nil {
+		deleteBlobs(s.store, attachment)
+		return nil, err
+	}
+
+	return newAttachmentResponse(created), nil
+}
+
+// GetAttachment returns an attachment, checking that it belongs to the expense
+func (s *AttachmentService) GetAttachment(expenseID, id int) (*model.Attachment, error) {
+	attachment, err := s.attachmentRepo.GetAttachmentByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.ExpenseID != expenseID {
+		return nil, fmt.Errorf("attachment not found")
+	}
+	return attachment, nil
+}
+
+func (s *AttachmentService) GetExpenseAttachments(expenseID int) ([]*model.AttachmentResponse, error) {
+	attachments, err := s.attachmentRepo.GetAttachmentsByExpenseID(expenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*model.AttachmentResponse, len(attachments))
+	for i, attachment := range attachments {
+		responses[i] = newAttachmentResponse(attachment)
+	}
+	return responses, nil
+}
+
+// OpenAttachment opens the content of an attachment, or of its thumbnail. The
+// caller closes it.
+func (s *AttachmentService) OpenAttachment(attachment *model.Attachment, thumbnail bool) (io.ReadCloser, error) {
+	key := attachment.StorageKey
+	if thumbnail {
+		if attachment.ThumbnailKey == "" {
+			return nil, fmt.Errorf("attachment has no thumbnail")
+		}
+		key = attachment.ThumbnailKey
+	}
+
+	content, err := s.store.Get(key)
+	if errors.Is(err, blobstore.ErrNotFound) {
+		return nil, fmt.Errorf("attachment content not found")
+	}
+	return content, err
+}
+
+// DeleteAttachment removes an attachment and its content
+func (s *AttachmentService) DeleteAttachment(attachment *model.Attachment) error {
+	if err := s.attachmentRepo.DeleteAttachment(attachment.ID); err != nil {
+		return err
+	}
+
+	deleteBlobs(s.store, attachment)
+	return nil
+}
+
+// deleteBlobs removes the content of an attachment. Failures only leave
+// unreferenced blobs behind, so they are logged rather than returned.
+func deleteBlobs(store blobstore.Store, attachment *model.Attachment) {
+	for _, key := range []string{attachment.StorageKey, attachment.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := store.Delete(key); err != nil {
+			log.Printf("Error deleting blob %s: %v", key, err)
+		}
+	}
+}
+
+// sniffContentType detects the type of a file from its first bytes
+func sniffContentType(content []byte) string {
+	contentType := http.DetectContentType(content)
+	// Drop parameters such as "; charset=utf-8"
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return contentType
+}
+
+func allowedContentType(contentType string) bool {
+	for _, allowed := range model.AttachmentContentTypes {
+		if contentType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanFileName keeps the base name of an uploaded file, without any directory
+// a client may have sent, shortened to fit the database column
+func cleanFileName(name string) string {
+	name = strings.ToValidUTF8(strings.TrimSpace(name), "")
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		return "attachment"
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+
+	minPasswordLength = 8
+	// bcrypt ignores everything after the first 72 bytes
+	maxPasswordLength = 72
+)
+
+// jwtHeader is the encoded header of every access token; only HS256 is issued or accepted
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// dummyPasswordHash is compared against when a login email is unknown, so that
+// unknown emails take as long to reject as wrong passwords
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+type AuthService struct {
+	userRepo  repository.UserRepository
+	tokenRepo repository.RefreshTokenRepository
+	secret    []byte
+}
+
+func NewAuthService(userRepo repository.UserRepository, tokenRepo repository.RefreshTokenRepository, secret []byte) *AuthService {
+	return &AuthService{userRepo: userRepo, tokenRepo: tokenRepo, secret: secret}
+}
+
+// accessClaims are the claims carried by an access token (a JWT signed with HS256)
+type accessClaims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Login checks an email and password and issues a new access and refresh token
+func (s *AuthService) Login(email, password string) (*model.TokenResponse, error) {
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err != nil {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, fmt.Errorf("invalid email or password")
+	}
+
+	if user.PasswordHash == "" || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, fmt.Errorf("invalid email or password")
+	}
+
+	return s.issueTokens(user)
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token. The old
+// refresh token is revoked, so each refresh token can only be used once.
+func (s *AuthService) Refresh(refreshToken string) (*model.TokenResponse, error) {
+	token, err := s.validRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tokenRepo.RevokeRefreshToken(token.ID); err != nil {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	user, err := s.userRepo.GetUserByID(token.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	return s.issueTokens(user)
+}
+
+// Logout revokes a refresh token belonging to the user. Access tokens already
+// issued stay valid until they expire.
+func (s *AuthService) Logout(userID int, refreshToken string) error {
+	token, err := s.validRefreshToken(refreshToken)
+	if err != nil {
+		return err
+	}
+
+	if token.UserID != userID {
+		return fmt.Errorf("invalid refresh token")
+	}
+
+	return s.tokenRepo.RevokeRefreshToken(token.ID)
+}
+
+// Authenticate returns the user an access token was issued to
+func (s *AuthService) Authenticate(accessToken string) (*model.User, error) {
+	userID, err := s.parseAccessToken(accessToken, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access token")
+	}
+
+	return user, nil
+}
+
+func (s *AuthService) issueTokens(user *model.User) (*model.TokenResponse, error) {
+	now := time.Now()
+
+	accessToken, err := s.signAccessToken(user.ID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := newRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.tokenRepo.CreateRefreshToken(&model.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+		User: &model.UserResponse{
+			ID:        user.ID,
+			Email:     user.Email,
+			Name:      user.Name,
+			CreatedAt: user.CreatedAt,
+		},
+	}, nil
+}
+
+func (s *AuthService) validRefreshToken(refreshToken string) (*model.RefreshToken, error) {
+	token, err := s.tokenRepo.GetRefreshTokenByHash(hashToken(refreshToken))
+	if err != nil {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	if token.RevokedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	return token, nil
+}
+
+func (s *AuthService) signAccessToken(userID int, now time.Time) (string, error) {
+	claims, err := json.Marshal(accessClaims{
+		Subject:   strconv.Itoa(userID),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(accessTokenTTL).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return unsigned + "." + s.sign(unsigned), nil
+}
+
+// parseAccessToken verifies an access token's signature and expiry and returns
+// the ID of the user it was issued to
+func (s *AuthService) parseAccessToken(token string, now time.Time) (int, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return 0, fmt.Errorf("invalid access token")
+	}
+
+	if !hmac.Equal([]byte(parts[2]), []byte(s.sign(parts[0]+"."+parts[1]))) {
+		return 0, fmt.Errorf("invalid access token")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid access token")
+	}
+
+	var claims accessClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return 0, fmt.Errorf("invalid access token")
+	}
+
+	if now.Unix() >= claims.ExpiresAt {
+		return 0, fmt.Errorf("access token expired")
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return 0, fmt.Errorf("invalid access token")
+	}
+
+	return userID, nil
+}
+
+func (s *AuthService) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// hashPassword validates a new password and returns its bcrypt hash
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	if len(password) > maxPasswordLength {
+		return "", fmt.Errorf("password must be at most %d bytes", maxPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// newRandomToken returns 32 random bytes, base64url encoded
+func newRandomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken is how refresh tokens are stored, so a database leak does not leak
+// usable tokens
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
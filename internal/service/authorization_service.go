@@ -0,0 +1,219 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+// ErrForbidden is wrapped by every error returned when a user is not allowed to
+// perform an action
+var ErrForbidden = errors.New("forbidden")
+
+// roleRank orders member roles from least to most privileged
+var roleRank = map[string]int{
+	model.RoleViewer: 0,
+	model.RoleMember: 1,
+	model.RoleAdmin:  2,
+	model.RoleOwner:  3,
+}
+
+func validRole(role string) bool {
+	_, ok := roleRank[role]
+	return ok
+}
+
+// AuthorizationService decides what a user may do within a group based on their
+// membership and role
+type AuthorizationService struct {
+	memberRepo     repository.GroupMemberRepository
+	expenseRepo    repository.ExpenseRepository
+	splitRepo      repository.ExpenseSplitRepository
+	settlementRepo repository.SettlementRepository
+}
+
+func NewAuthorizationService(
+	memberRepo repository.GroupMemberRepository,
+	expenseRepo repository.ExpenseRepository,
+	splitRepo repository.ExpenseSplitRepository,
+	settlementRepo repository.SettlementRepository,
+) *AuthorizationService {
+	return &AuthorizationService{
+		memberRepo:     memberRepo,
+		expenseRepo:    expenseRepo,
+		splitRepo:      splitRepo,
+		settlementRepo: settlementRepo,
+	}
+}
+
+// RequireMember checks that the user belongs to the group, in any role
+func (s *AuthorizationService) RequireMember(groupID, userID int) error {
+	isMember, err := s.memberRepo.IsMember(groupID, userID)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return fmt.Errorf("%w: you are not a member of this group", ErrForbidden)
+	}
+	return nil
+}
+
+// RequireRole checks that the user belongs to the group with at least minRole
+// and returns their membership
+func (s *AuthorizationService) RequireRole(groupID, userID int, minRole string) (*model.GroupMember, error) {
+	member, err := s.memberRepo.GetMember(groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: you are not a member of this group", ErrForbidden)
+	}
+	if roleRank[member.Role] < roleRank[minRole] {
+		return nil, fmt.Errorf("%w: this requires the %s role", ErrForbidden, minRole)
+	}
+	return member, nil
+}
+
+// CanReadExpense checks that the user belongs to the expense's group
+func (s *AuthorizationService) CanReadExpense(expenseID, userID int) error {
+	expense, err := s.expenseRepo.GetExpenseByID(expenseID)
+	if err != nil {
+		return err
+	}
+	return s.RequireMember(expense.GroupID, userID)
+}
+
+// CanEditExpense checks that the user paid the expense or is a group admin.
+// Viewers cannot edit expenses, even ones they paid.
+func (s *AuthorizationService) CanEditExpense(expenseID, userID int) error {
+	expense, err := s.expenseRepo.GetExpenseByID(expenseID)
+	if err != nil {
+		return err
+	}
+	return s.canEditExpense(expense, userID)
+}
+
+// CanRestoreExpense checks that the user may edit a soft-deleted expense
+func (s *AuthorizationService) CanRestoreExpense(expenseID, userID int) error {
+	expense, err := s.expenseRepo.GetDeletedExpenseByID(expenseID)
+	if err != nil {
+		return err
+	}
+	return s.canEditExpense(expense, userID)
+}
+
+func (s *AuthorizationService) canEditExpense(expense *model.Expense, userID int) error {
+	member, err := s.RequireRole(expense.GroupID, userID, model.RoleMember)
+	if err != nil {
+		return err
+	}
+	if expense.PaidByID != userID && roleRank[member.Role] < roleRank[model.RoleAdmin] {
+		return fmt.Errorf("%w: only the payer or a group admin can change this expense", ErrForbidden)
+	}
+	return nil
+}
+
+// CanEditSplit checks that the user may edit the expense the split belongs to
+func (s *AuthorizationService) CanEditSplit(splitID, userID int) error {
+	split, err := s.splitRepo.GetSplitByID(splitID)
+	if err != nil {
+		return err
+	}
+	return s.CanEditExpense(split.ExpenseID, userID)
+}
+
+// CanReadSettlement checks that the user belongs to the settlement's group
+func (s *AuthorizationService) CanReadSettlement(settlementID, userID int) error {
+	settlement, err := s.settlementRepo.GetSettlementByID(settlementID)
+	if err != nil {
+		return err
+	}
+	return s.RequireMember(settlement.GroupID, userID)
+}
+
+// CanRecordSettlement checks that the user takes part in the payment, or is a
+// group admin recording it for others
+func (s *AuthorizationService) CanRecordSettlement(req *model.SettlementRequest, userID int) error {
+	return s.canChangeSettlement(req.GroupID, req.FromUserID, req.ToUserID, userID, "record")
+}
+
+// CanDeleteSettlement checks that the user could have recorded the settlement
+func (s *AuthorizationService) CanDeleteSettlement(settlementID, userID int) error {
+	settlement, err := s.settlementRepo.GetSettlementByID(settlementID)
+	if err != nil {
+		return err
+	}
+	return s.canChangeSettlement(settlement.GroupID, settlement.FromUserID, settlement.ToUserID, userID, "delete")
+}
+
+// CanRestoreSettlement checks that the user could have recorded a soft-deleted
+// settlement
+func (s *AuthorizationService) CanRestoreSettlement(settlementID, userID int) error {
+	settlement, err := s.settlementRepo.GetDeletedSettlementByID(settlementID)
+	if err != nil {
+		return err
+	}
+	return s.canChangeSettlement(settlement.GroupID, settlement.FromUserID, settlement.ToUserID, userID, "restore")
+}
+
+func (s *AuthorizationService) canChangeSettlement(groupID, fromUserID, toUserID, userID int, action string) error {
+	member, err := s.RequireRole(groupID, userID, model.RoleMember)
+	if err != nil {
+		return err
+	}
+	if userID != fromUserID && userID != toUserID && roleRank[member.Role] < roleRank[model.RoleAdmin] {
+		return fmt.Errorf("%w: only the people involved or a group admin can %s this payment", ErrForbidden, action)
+	}
+	return nil
+}
+
+// CanSeeUser checks that the user is the other user or shares a group with
+// them. Nobody else can read another account's name and email.
+func (s *AuthorizationService) CanSeeUser(otherID, userID int) error {
+	if otherID == userID {
+		return nil
+	}
+	shares, err := s.memberRepo.SharesGroup(userID, otherID)
+	if err != nil {
+		return err
+	}
+	if !shares {
+		return fmt.Errorf("%w: you do not share a group with this user", ErrForbidden)
+	}
+	return nil
+}
+
+// CanClaimPlaceholder checks that the user may merge the placeholder into their
+// own account, which only the person it was added for may: the placeholder's
+// email must be theirs. Someone without an account claims it by registering
+// with the invitation sent to that email instead.
+func (s *AuthorizationService) CanClaimPlaceholder(placeholder *model.User, user *model.User) error {
+	if model.NormalizeEmail(placeholder.Email) != model.NormalizeEmail(user.Email) {
+		return fmt.Errorf("%w: only the person this placeholder was added for can claim it", ErrForbidden)
+	}
+	return nil
+}
+
+// CanEditRecurringExpense checks that the user created the recurring expense
+// or is a group admin. Viewers cannot edit recurring expenses.
+func (s *AuthorizationService) CanEditRecurringExpense(recurring *model.RecurringExpense, userID int) error {
+	member, err := s.RequireRole(recurring.GroupID, userID, model.RoleMember)
+	if err != nil {
+		return err
+	}
+	if recurring.CreatedByID != userID && roleRank[member.Role] < roleRank[model.RoleAdmin] {
+		return fmt.Errorf("%w: only its creator or a group admin can change this recurring expense", ErrForbidden)
+	}
+	return nil
+}
+
+// CanEditComment checks that the user wrote the comment and still belongs to
+// the group of the expense or settlement it is on
+func (s *AuthorizationService) CanEditComment(comment *model.Comment, userID int) error {
+	if comment.AuthorID == nil || *comment.AuthorID != userID {
+		return fmt.Errorf("%w: only its author can change this comment", ErrForbidden)
+	}
+	if comment.ExpenseID != nil {
+		return s.CanReadExpense(*comment.ExpenseID, userID)
+	}
+	return s.CanReadSettlement(*comment.SettlementID, userID)
+}
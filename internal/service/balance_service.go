@@ -1,85 +1,106 @@
 package service
 
 import (
+	"fmt"
+	"sort"
+
 	"github.com/shreyansh/expense-go-collab-backend/internal/model"
-	"github.com/shreyansh/expense-go-collab-backend/internal/repositorypg"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
 )
 
 type BalanceService struct {
-	balanceRepo *repositorypg.BalanceRepositoryPG
+	balanceRepo repository.BalanceRepository
+	groupRepo   repository.GroupRepository
+	uow         repository.UnitOfWork
 }
 
-func NewBalanceService(balanceRepo *repositorypg.BalanceRepositoryPG) *BalanceService {
-	return &BalanceService{balanceRepo: balanceRepo}
+func NewBalanceService(balanceRepo repository.BalanceRepository, groupRepo repository.GroupRepository, uow repository.UnitOfWork) *BalanceService {
+	return &BalanceService{balanceRepo: balanceRepo, groupRepo: groupRepo, uow: uow}
 }
 
-func (s *BalanceService) GetUserBalance(userID, groupID int) (float64, error) {
-	return s.balanceRepo.GetUserBalanceInGroup(userID, groupID)
+// GetUserBalance returns the user's net balance in the group, in the group currency
+func (s *BalanceService) GetUserBalance(userID, groupID int) (*model.BalanceResponse, error) {
+	group, err := s.groupRepo.GetGroupByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.balanceRepo.GetUserBalanceInGroup(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.BalanceResponse{
+		UserID:   userID,
+		GroupID:  groupID,
+		Amount:   balance,
+		Currency: group.Currency,
+	}, nil
 }
 
-func (s *BalanceService) GetGroupBalances(groupID int) (map[int]float64, error) {
+func (s *BalanceService) GetGroupBalances(groupID int) (map[int]money.Amount, error) {
 	return s.balanceRepo.GetGroupBalances(groupID)
 }
 
+// GetUserRelativeBalances returns the pairwise debts between the current user and
+// every other member of the group (who they owe and who owes them)
 func (s *BalanceService) GetUserRelativeBalances(
 	groupID int,
 	currentUserID int,
-	userRepo *repositorypg.UserRepositoryPG,
+	userRepo repository.UserRepository,
 ) ([]*model.UserBalanceView, error) {
 
-	balances, err := s.balanceRepo.GetGroupBalances(groupID)
+	group, err := s.groupRepo.GetGroupByID(groupID)
 	if err != nil {
 		return nil, err
 	}
 
-	currentUserBalance := balances[currentUserID]
+	pairs, err := s.balanceRepo.GetPairwiseBalances(groupID)
+	if err != nil {
+		return nil, err
+	}
 
 	var responses []*model.UserBalanceView
 
-	for userID, amount := range balances {
-
-		// 🚫 Rule 1: skip self
-		if userID == currentUserID {
+	for _, pair := range pairs {
+
+		var otherUserID int
+		var viewType string
+		switch currentUserID {
+		case pair.FromUserID:
+			otherUserID = pair.ToUserID
+			viewType = "you_owe"
+		case pair.ToUserID:
+			otherUserID = pair.FromUserID
+			viewType = "owes_you"
+		default:
 			continue
 		}
 
-		user, err := userRepo.GetUserByID(userID)
+		user, err := userRepo.GetUserByID(otherUserID)
 		if err != nil {
 			continue
 		}
 
-		diff := currentUserBalance - amount
-
-		if diff == 0 {
-			continue
-		}
-
-		view := &model.UserBalanceView{
-			UserID:   userID,
+		responses = append(responses, &model.UserBalanceView{
+			UserID:   otherUserID,
 			UserName: user.Name,
-			Amount:   abs(diff),
-		}
-
-		if diff < 0 {
-			view.Type = "you_owe"
-		} else {
-			view.Type = "owes_you"
-		}
-
-		responses = append(responses, view)
+			Amount:   pair.Amount,
+			Currency: group.Currency,
+			Type:     viewType,
+		})
 	}
 
 	return responses, nil
 }
-func abs(x float64) float64 {
-	if x < 0 {
-		return -x
-	}
-	return x
-}
 
+func (s *BalanceService) GetGroupBalancesWithNames(groupID int, userRepo repository.UserRepository) ([]*model.UserBalanceResponse, error) {
+	group, err := s.groupRepo.GetGroupByID(groupID)
+	if err != nil {
+		return nil, err
+	}
 
-func (s *BalanceService) GetGroupBalancesWithNames(groupID int, userRepo *repositorypg.UserRepositoryPG) ([]*model.UserBalanceResponse, error) {
 	balances, err := s.balanceRepo.GetGroupBalances(groupID)
 	if err != nil {
 		return nil, err
@@ -96,8 +117,121 @@ func (s *BalanceService) GetGroupBalancesWithNames(groupID int, userRepo *reposi
 			UserID:   userID,
 			UserName: user.Name,
 			Amount:   amount,
+			Currency: group.Currency,
 		})
 	}
 
 	return responses, nil
 }
+
+// RebuildBalances recomputes the materialized balances of the given groups, or of
+// every group when none is given, from their ledgers. It returns the number of
+// groups rebuilt.
+func (s *BalanceService) RebuildBalances(groupIDs ...int) (int, error) {
+	groupIDs, err := s.groupIDsOrAll(groupIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, groupID := range groupIDs {
+		err := s.uow.Do(func(tx repository.Tx) error {
+			return tx.Balances().CalculateBalances(groupID)
+		})
+		if err != nil {
+			return i, fmt.Errorf("group %d: %v", groupID, err)
+		}
+	}
+
+	return len(groupIDs), nil
+}
+
+// CheckBalances compares the materialized balances of the given groups, or of
+// every group when none is given, with the balances computed from their ledgers,
+// and returns every pair of users where the two differ
+func (s *BalanceService) CheckBalances(groupIDs ...int) ([]*model.BalanceDrift, error) {
+	groupIDs, err := s.groupIDsOrAll(groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var drifts []*model.BalanceDrift
+	for _, groupID := range groupIDs {
+		stored, err := s.balanceRepo.GetPairwiseBalances(groupID)
+		if err != nil {
+			return nil, fmt.Errorf("group %d: %v", groupID, err)
+		}
+		expected, err := s.balanceRepo.GetLedgerPairwiseBalances(groupID)
+		if err != nil {
+			return nil, fmt.Errorf("group %d: %v", groupID, err)
+		}
+
+		storedByPair := signedPairs(stored)
+		expectedByPair := signedPairs(expected)
+
+		pairs := make(map[userPair]bool)
+		for p := range storedByPair {
+			pairs[p] = true
+		}
+		for p := range expectedByPair {
+			pairs[p] = true
+		}
+		for p := range pairs {
+			if storedByPair[p] != expectedByPair[p] {
+				drifts = append(drifts, &model.BalanceDrift{
+					GroupID:    groupID,
+					FromUserID: p.low,
+					ToUserID:   p.high,
+					Stored:     storedByPair[p],
+					Expected:   expectedByPair[p],
+				})
+			}
+		}
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		a, b := drifts[i], drifts[j]
+		if a.GroupID != b.GroupID {
+			return a.GroupID < b.GroupID
+		}
+		if a.FromUserID != b.FromUserID {
+			return a.FromUserID < b.FromUserID
+		}
+		return a.ToUserID < b.ToUserID
+	})
+
+	return drifts, nil
+}
+
+// userPair is two users, lower user ID first
+type userPair struct{ low, high int }
+
+// signedPairs keys pairwise balances by pair, as the amount the lower user ID
+// owes the higher one
+func signedPairs(balances []*model.PairwiseBalance) map[userPair]money.Amount {
+	pairs := make(map[userPair]money.Amount)
+	for _, balance := range balances {
+		if balance.FromUserID < balance.ToUserID {
+			pairs[userPair{balance.FromUserID, balance.ToUserID}] += balance.Amount
+		} else {
+			pairs[userPair{balance.ToUserID, balance.FromUserID}] -= balance.Amount
+		}
+	}
+	return pairs
+}
+
+// groupIDsOrAll returns groupIDs, or the IDs of every group when it is empty
+func (s *BalanceService) groupIDsOrAll(groupIDs []int) ([]int, error) {
+	if len(groupIDs) > 0 {
+		return groupIDs, nil
+	}
+
+	groups, err := s.groupRepo.GetAllGroups()
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		groupIDs = append(groupIDs, group.ID)
+	}
+
+	return groupIDs, nil
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repositorymem"
+)
+
+func newSettlementService(store *repositorymem.Store) *SettlementService {
+	return NewSettlementService(
+		repositorymem.NewSettlementRepositoryMem(store),
+		repositorymem.NewUserRepositoryMem(store),
+		repositorymem.NewGroupRepositoryMem(store),
+		repositorymem.NewGroupMemberRepositoryMem(store),
+		repositorymem.NewBalanceRepositoryMem(store),
+		repositorymem.NewExchangeRateRepositoryMem(store),
+		repositorymem.NewUnitOfWorkMem(store),
+	)
+}
+
+// TestBalanceMaintenance runs expense and settlement changes one after the
+// other, checking after each that the balances kept up to date by the
+// services are the ones CalculateBalances rebuilds from the ledger
+func TestBalanceMaintenance(t *testing.T) {
+	store := repositorymem.NewStore()
+	groupID, userIDs := seedGroup(t, store, "ann", "bob", "cat")
+	ann, bob, cat := userIDs[0], userIDs[1], userIDs[2]
+	balanceRepo := repositorymem.NewBalanceRepositoryMem(store)
+	expenses := newExpenseService(store)
+	settlements := newSettlementService(store)
+
+	var dinnerID, taxiID, paymentID int
+	steps := []struct {
+		name string
+		run  func() error
+		// want is every member's net balance after the step, positive when
+		// they are owed
+		want map[int]money.Amount
+	}{
+		{
+			name: "create an expense split equally",
+			run: func() error {
+				expense, err := expenses.CreateExpense(&model.ExpenseRequest{GroupID: groupID, PaidByID: ann, Amount: 9000}, ann)
+				if err == nil {
+					dinnerID = expense.ID
+				}
+				return err
+			},
+			want: map[int]money.Amount{ann: 6000, bob: -3000, cat: -3000},
+		},
+		{
+			name: "record a settlement",
+			run: func() error {
+				settlement, err := settlements.CreateSettlement(&model.SettlementRequest{GroupID: groupID, FromUserID: bob, ToUserID: ann, Amount: 1000}, bob)
+				if err == nil {
+					paymentID = settlement.ID
+				}
+				return err
+			},
+			want: map[int]money.Amount{ann: 5000, bob: -2000, cat: -3000},
+		},
+		{
+			name: "change the amount and the payer",
+			run: func() error {
+				newAmount := money.Amount(6000)
+				_, err := expenses.UpdateExpense(dinnerID, &model.ExpenseUpdateRequest{Amount: &newAmount, PaidByID: bob}, ann)
+				return err
+			},
+			want: map[int]money.Amount{ann: -3000, bob: 5000, cat: -2000},
+		},
+		{
+			name: "create an expense with exact amounts",
+			run: func() error {
+				expense, err := expenses.CreateExpense(&model.ExpenseRequest{
+					GroupID:  groupID,
+					PaidByID: cat,
+					Amount:   3000,
+					Split: &model.SplitSpec{Mode: model.SplitModeExact, Participants: []model.SplitParticipant{
+						{UserID: ann, Amount: 1000}, {UserID: cat, Amount: 2000},
+					}},
+				}, cat)
+				if err == nil {
+					taxiID = expense.ID
+				}
+				return err
+			},
+			want: map[int]money.Amount{ann: -4000, bob: 5000, cat: -1000},
+		},
+		{
+			name: "delete an expense",
+			run:  func() error { return expenses.DeleteExpense(dinnerID, ann) },
+			want: map[int]money.Amount{ann: -2000, bob: 1000, cat: 1000},
+		},
+		{
+			name: "delete a settlement",
+			run:  func() error { return settlements.DeleteSettlement(paymentID, bob) },
+			want: map[int]money.Amount{ann: -1000, cat: 1000},
+		},
+		{
+			name: "restore the expense",
+			run: func() error {
+				_, err := expenses.RestoreExpense(dinnerID, ann)
+				return err
+			},
+			want: map[int]money.Amount{ann: -3000, bob: 4000, cat: -1000},
+		},
+		{
+			name: "restore the settlement",
+			run: func() error {
+				_, err := settlements.RestoreSettlement(paymentID, bob)
+				return err
+			},
+			want: map[int]money.Amount{ann: -4000, bob: 5000, cat: -1000},
+		},
+		{
+			name: "replace the splits",
+			run: func() error {
+				_, err := expenses.ReplaceSplits(dinnerID, &model.SplitSpec{Mode: model.SplitModeShares, Participants: []model.SplitParticipant{
+					{UserID: ann, Shares: 1}, {UserID: bob, Shares: 1},
+				}}, ann)
+				return err
+			},
+			want: map[int]money.Amount{ann: -5000, bob: 4000, cat: 1000},
+		},
+		{
+			name: "delete the other expense",
+			run:  func() error { return expenses.DeleteExpense(taxiID, cat) },
+			want: map[int]money.Amount{ann: -4000, bob: 4000},
+		},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := netBalances(t, balanceRepo, groupID); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: balances = %v, want %v", step.name, got, step.want)
+		}
+		checkLedger(t, balanceRepo, groupID)
+	}
+
+	balances := NewBalanceService(balanceRepo, repositorymem.NewGroupRepositoryMem(store), repositorymem.NewUnitOfWorkMem(store))
+	drifts, err := balances.CheckBalances(groupID)
+	if err != nil {
+		t.Fatalf("checking balances: %v", err)
+	}
+	if len(drifts) > 0 {
+		t.Errorf("CheckBalances found %d drifted balances", len(drifts))
+	}
+}
+
+// TestDeleteSettlementTwice checks that a settlement is only taken off the
+// balances once
+func TestDeleteSettlementTwice(t *testing.T) {
+	store := repositorymem.NewStore()
+	groupID, userIDs := seedGroup(t, store, "ann", "bob")
+	ann, bob := userIDs[0], userIDs[1]
+	balanceRepo := repositorymem.NewBalanceRepositoryMem(store)
+	settlements := newSettlementService(store)
+
+	settlement, err := settlements.CreateSettlement(&model.SettlementRequest{GroupID: groupID, FromUserID: bob, ToUserID: ann, Amount: 500}, bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := settlements.DeleteSettlement(settlement.ID, bob); err != nil {
+		t.Fatal(err)
+	}
+	if err := settlements.DeleteSettlement(settlement.ID, bob); err == nil {
+		t.Error("deleting the settlement again succeeded")
+	}
+	if _, err := settlements.RestoreSettlement(settlement.ID, bob); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := settlements.RestoreSettlement(settlement.ID, bob); err == nil {
+		t.Error("restoring the settlement again succeeded")
+	}
+
+	want := map[int]money.Amount{ann: -500, bob: 500}
+	if got := netBalances(t, balanceRepo, groupID); !reflect.DeepEqual(got, want) {
+		t.Errorf("balances = %v, want %v", got, want)
+	}
+	checkLedger(t, balanceRepo, groupID)
+}
+
+// netBalances returns the materialized net balance of every member, leaving
+// out those who are settled
+func netBalances(t *testing.T, balanceRepo repository.BalanceRepository, groupID int) map[int]money.Amount {
+	t.Helper()
+
+	balances, err := balanceRepo.GetGroupBalances(groupID)
+	if err != nil {
+		t.Fatalf("getting balances: %v", err)
+	}
+	nets := make(map[int]money.Amount)
+	for userID, amount := range balances {
+		if amount != 0 {
+			nets[userID] = amount
+		}
+	}
+	return nets
+}
+
+// checkLedger fails the test when the materialized pairwise balances differ
+// from what CalculateBalances rebuilds from the ledger
+func checkLedger(t *testing.T, balanceRepo repository.BalanceRepository, groupID int) {
+	t.Helper()
+
+	maintained, err := balanceRepo.GetPairwiseBalances(groupID)
+	if err != nil {
+		t.Fatalf("getting pairwise balances: %v", err)
+	}
+	if err := balanceRepo.CalculateBalances(groupID); err != nil {
+		t.Fatalf("calculating balances: %v", err)
+	}
+	rebuilt, err := balanceRepo.GetPairwiseBalances(groupID)
+	if err != nil {
+		t.Fatalf("getting pairwise balances: %v", err)
+	}
+
+	got, want := signedPairs(maintained), signedPairs(rebuilt)
+	for pair, amount := range want {
+		if got[pair] != amount {
+			t.Errorf("user %d owes user %d %s, the ledger says %s", pair.low, pair.high, got[pair], amount)
+		}
+	}
+	for pair, amount := range got {
+		if _, ok := want[pair]; !ok && amount != 0 {
+			t.Errorf("user %d owes user %d %s, the ledger says nothing", pair.low, pair.high, amount)
+		}
+	}
+}
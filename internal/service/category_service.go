@@ -0,0 +1,224 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+// CategoryService manages the category set of each group: the global categories
+// shared by every group, plus the group's own custom ones
+type CategoryService struct {
+	categoryRepo repository.CategoryRepository
+	groupRepo    repository.GroupRepository
+}
+
+func NewCategoryService(categoryRepo repository.CategoryRepository, groupRepo repository.GroupRepository) *CategoryService {
+	return &CategoryService{categoryRepo: categoryRepo, groupRepo: groupRepo}
+}
+
+func newCategoryResponse(category *model.Category) *model.CategoryResponse {
+	return &model.CategoryResponse{
+		ID:       category.ID,
+		GroupID:  category.GroupID,
+		ParentID: category.ParentID,
+		Name:     category.Name,
+		Global:   category.GroupID == nil,
+		Children: []*model.CategoryResponse{},
+	}
+}
+
+// GetGroupCategories returns the categories a group can use as a tree: top
+// level categories with their subcategories nested under them
+func (s *CategoryService) GetGroupCategories(groupID int) ([]*model.CategoryResponse, error) {
+	if _, err := s.groupRepo.GetGroupByID(groupID); err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categoryRepo.GetCategoriesForGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*model.CategoryResponse, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = newCategoryResponse(category)
+	}
+
+	roots := []*model.CategoryResponse{}
+	for _, category := range categories {
+		response := byID[category.ID]
+		if category.ParentID == nil {
+			roots = append(roots, response)
+			continue
+		}
+		if parent, ok := byID[*category.ParentID]; ok {
+			parent.Children = append(parent.Children, response)
+		}
+	}
+
+	return roots, nil
+}
+
+// GetCategoryByID returns a single category, without its subcategories
+func (s *CategoryService) GetCategoryByID(id int) (*model.Category, error) {
+	return s.categoryRepo.GetCategoryByID(id)
+}
+
+// CreateCategory adds a custom category to a group, at the top level or under
+// any category the group can use
+func (s *CategoryService) CreateCategory(req *model.CategoryRequest) (*model.CategoryResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("category name is required")
+	}
+
+	if _, err := s.groupRepo.GetGroupByID(req.GroupID); err != nil {
+		return nil, err
+	}
+
+	category := &model.Category{GroupID: &req.GroupID, Name: name}
+	if req.ParentID != nil && *req.ParentID != 0 {
+		if err := checkCategory(s.categoryRepo, req.GroupID, *req.ParentID); err != nil {
+			return nil, err
+		}
+		category.ParentID = req.ParentID
+	}
+
+	if err := s.checkSiblingName(category); err != nil {
+		return nil, err
+	}
+
+	createdCategory, err := s.categoryRepo.CreateCategory(category)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCategoryResponse(createdCategory), nil
+}
+
+// UpdateCategory renames a group's custom category or moves it under another
+// category. Global categories cannot be changed.
+func (s *CategoryService) UpdateCategory(id int, req *model.CategoryUpdateRequest) (*model.CategoryResponse, error) {
+	category, err := s.categoryRepo.GetCategoryByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if category.GroupID == nil {
+		return nil, fmt.Errorf("global categories cannot be changed")
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("category name is required")
+		}
+		category.Name = name
+	}
+
+	if req.ParentID != nil {
+		if *req.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			if err := checkCategory(s.categoryRepo, *category.GroupID, *req.ParentID); err != nil {
+				return nil, err
+			}
+			if err := s.checkNotDescendant(*req.ParentID, id); err != nil {
+				return nil, err
+			}
+			category.ParentID = req.ParentID
+		}
+	}
+
+	if err := s.checkSiblingName(category); err != nil {
+		return nil, err
+	}
+
+	updatedCategory, err := s.categoryRepo.UpdateCategory(category)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCategoryResponse(updatedCategory), nil
+}
+
+// DeleteCategory deletes a group's custom category. Its expenses become
+// uncategorized; a category with subcategories must be emptied first.
+func (s *CategoryService) DeleteCategory(id int) error {
+	category, err := s.categoryRepo.GetCategoryByID(id)
+	if err != nil {
+		return err
+	}
+	if category.GroupID == nil {
+		return fmt.Errorf("global categories cannot be deleted")
+	}
+
+	categories, err := s.categoryRepo.GetCategoriesForGroup(*category.GroupID)
+	if err != nil {
+		return err
+	}
+	for _, other := range categories {
+		if other.ParentID != nil && *other.ParentID == id {
+			return fmt.Errorf("category has subcategories; move or delete them first")
+		}
+	}
+
+	return s.categoryRepo.DeleteCategory(id)
+}
+
+// checkSiblingName rejects a category named like another one under the same
+// parent in the same group, ignoring case
+func (s *CategoryService) checkSiblingName(category *model.Category) error {
+	categories, err := s.categoryRepo.GetCategoriesForGroup(*category.GroupID)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range categories {
+		if other.ID == category.ID || !strings.EqualFold(other.Name, category.Name) {
+			continue
+		}
+		if (other.ParentID == nil) != (category.ParentID == nil) {
+			continue
+		}
+		if other.ParentID == nil || *other.ParentID == *category.ParentID {
+			return fmt.Errorf("a category named %q already exists here", category.Name)
+		}
+	}
+
+	return nil
+}
+
+// checkNotDescendant rejects moving a category under itself or one of its own
+// subcategories
+func (s *CategoryService) checkNotDescendant(parentID, id int) error {
+	for parentID != 0 {
+		if parentID == id {
+			return fmt.Errorf("a category cannot be moved under itself")
+		}
+		parent, err := s.categoryRepo.GetCategoryByID(parentID)
+		if err != nil {
+			return err
+		}
+		if parent.ParentID == nil {
+			break
+		}
+		parentID = *parent.ParentID
+	}
+	return nil
+}
+
+// checkCategory checks that a category exists and can be used in the group:
+// it is either global or one of the group's own
+func checkCategory(categoryRepo repository.CategoryRepository, groupID, categoryID int) error {
+	category, err := categoryRepo.GetCategoryByID(categoryID)
+	if err != nil {
+		return err
+	}
+	if category.GroupID != nil && *category.GroupID != groupID {
+		return fmt.Errorf("category %d does not belong to this group", categoryID)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+// CommentService keeps the discussion threads on expenses and settlements
+type CommentService struct {
+	commentRepo    repository.CommentRepository
+	expenseRepo    repository.ExpenseRepository
+	settlementRepo repository.SettlementRepository
+}
+
+func NewCommentService(
+	commentRepo repository.CommentRepository,
+	expenseRepo repository.ExpenseRepository,
+	settlementRepo repository.SettlementRepository,
+) *CommentService {
+	return &CommentService{
+		commentRepo:    commentRepo,
+		expenseRepo:    expenseRepo,
+		settlementRepo: settlementRepo,
+	}
+}
+
+// CommentOnExpense adds a comment to the expense's thread
+func (s *CommentService) CommentOnExpense(expenseID, authorID int, req *model.CommentRequest) (*model.Comment, error) {
+	body, err := commentBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.expenseRepo.GetExpenseByID(expenseID); err != nil {
+		return nil, err
+	}
+
+	return s.commentRepo.CreateComment(&model.Comment{
+		ExpenseID: &expenseID,
+		AuthorID:  &authorID,
+		Body:      body,
+	})
+}
+
+// CommentOnSettlement adds a comment to the settlement's thread
+func (s *CommentService) CommentOnSettlement(settlementID, authorID int, req *model.CommentRequest) (*model.Comment, error) {
+	body, err := commentBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.settlementRepo.GetSettlementByID(settlementID); err != nil {
+		return nil, err
+	}
+
+	return s.commentRepo.CreateComment(&model.Comment{
+		SettlementID: &settlementID,
+		AuthorID:     &authorID,
+		Body:         body,
+	})
+}
+
+func (s *CommentService) GetComment(id int) (*model.Comment, error) {
+	return s.commentRepo.GetCommentByID(id)
+}
+
+// GetExpenseComments returns the expense's thread, oldest first
+func (s *CommentService) GetExpenseComments(expenseID int) ([]*model.Comment, error) {
+	return s.commentRepo.GetCommentsByExpenseID(expenseID)
+}
+
+// GetSettlementComments returns the settlement's thread, oldest first
+func (s *CommentService) GetSettlementComments(settlementID int) ([]*model.Comment, error) {
+	return s.commentRepo.GetCommentsBySettlementID(settlementID)
+}
+
+// UpdateComment replaces the body of a comment
+func (s *CommentService) UpdateComment(comment *model.Comment, req *model.CommentRequest) (*model.Comment, error) {
+	body, err := commentBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	comment.Body = body
+	return s.commentRepo.UpdateComment(comment)
+}
+
+func (s *CommentService) DeleteComment(id int) error {
+	return s.commentRepo.DeleteComment(id)
+}
+
+// commentBody trims a comment and checks that it is neither empty nor too long
+func commentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", fmt.Errorf("comment cannot be empty")
+	}
+	if utf8.RuneCountInString(body) > model.MaxCommentLength {
+		return "", fmt.Errorf("comment cannot be longer than %d characters", model.MaxCommentLength)
+	}
+	return body, nil
+}
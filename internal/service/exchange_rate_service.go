@@ -0,0 +1,214 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+const rateDateLayout = "2006-01-02"
+
+type ExchangeRateService struct {
+	rateRepo repository.ExchangeRateRepository
+}
+
+func NewExchangeRateService(rateRepo repository.ExchangeRateRepository) *ExchangeRateService {
+	return &ExchangeRateService{rateRepo: rateRepo}
+}
+
+// rateRecord is the file representation of an exchange rate. The effective date
+// is optional and defaults to today.
+type rateRecord struct {
+	BaseCurrency  string     `json:"base_currency"`
+	QuoteCurrency string     `json:"quote_currency"`
+	Rate          money.Rate `json:"rate"`
+	EffectiveDate string     `json:"effective_date"`
+}
+
+// LoadRatesFromFile imports exchange rates from a local .csv or .json file and
+// returns how many were stored.
+//
+// CSV files need a header row with base_currency, quote_currency, rate and
+// optionally effective_date (YYYY-MM-DD). JSON files hold an array of objects
+// with the same keys.
+func (s *ExchangeRateService) LoadRatesFromFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open rates file: %v", err)
+	}
+	defer file.Close()
+
+	var records []rateRecord
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, err = readRateCSV(file)
+	case ".json":
+		err = json.NewDecoder(file).Decode(&records)
+	default:
+		return 0, fmt.Errorf("unsupported rates file type: %s", path)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse rates file: %v", err)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for i, record := range records {
+		rate, err := record.toModel(today)
+		if err != nil {
+			return i, fmt.Errorf("rate %d: %v", i+1, err)
+		}
+		if err := s.rateRepo.UpsertRate(rate); err != nil {
+			return i, err
+		}
+	}
+
+	return len(records), nil
+}
+
+func (s *ExchangeRateService) GetAllRates() ([]*model.ExchangeRate, error) {
+	return s.rateRepo.GetAllRates()
+}
+
+func readRateCSV(r io.Reader) ([]rateRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"base_currency", "quote_currency", "rate"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing %s column", required)
+		}
+	}
+
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	var records []rateRecord
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rate, err := money.ParseRate(field(row, "rate"))
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rateRecord{
+			BaseCurrency:  field(row, "base_currency"),
+			QuoteCurrency: field(row, "quote_currency"),
+			Rate:          rate,
+			EffectiveDate: field(row, "effective_date"),
+		})
+	}
+
+	return records, nil
+}
+
+func (r rateRecord) toModel(defaultDate time.Time) (*model.ExchangeRate, error) {
+	base, err := money.NormalizeCurrency(r.BaseCurrency, "")
+	if err != nil || base == "" {
+		return nil, fmt.Errorf("invalid base currency %q", r.BaseCurrency)
+	}
+	quote, err := money.NormalizeCurrency(r.QuoteCurrency, "")
+	if err != nil || quote == "" {
+		return nil, fmt.Errorf("invalid quote currency %q", r.QuoteCurrency)
+	}
+	if r.Rate.IsZero() {
+		return nil, fmt.Errorf("rate is required")
+	}
+
+	date := defaultDate
+	if r.EffectiveDate != "" {
+		date, err = time.Parse(rateDateLayout, strings.TrimSpace(r.EffectiveDate))
+		if err != nil {
+			return nil, fmt.Errorf("invalid effective date %q", r.EffectiveDate)
+		}
+	}
+
+	return &model.ExchangeRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          r.Rate,
+		EffectiveDate: date,
+	}, nil
+}
+
+// convertAmount converts an amount paid in currency from into the group currency
+// to, using the rate in effect on the given day. The rate used is returned so it
+// can be snapshotted on the expense or settlement.
+func convertAmount(
+	rateRepo repository.ExchangeRateRepository,
+	amount money.Amount,
+	from, to string,
+	on time.Time,
+) (money.Amount, money.Rate, error) {
+	if from == to {
+		return amount, money.OneRate(), nil
+	}
+
+	rate, err := rateRepo.GetRate(from, to, on)
+	if err != nil {
+		return 0, money.Rate{}, fmt.Errorf("no exchange rate from %s to %s: %v", from, to, err)
+	}
+
+	return rate.Rate.Convert(amount), rate.Rate, nil
+}
+
+// allocateBaseAmounts spreads an expense's converted amount over its splits in
+// proportion to their original amounts, so the converted splits add up exactly
+// to the converted expense
+func allocateBaseAmounts(baseAmount money.Amount, amounts []money.Amount) ([]money.Amount, error) {
+	weights := make([]int64, len(amounts))
+	for i, amount := range amounts {
+		weights[i] = int64(amount)
+	}
+	return money.Allocate(baseAmount, weights)
+}
+
+// groupCurrencies memoizes group currency lookups while building lists of responses
+type groupCurrencies struct {
+	groupRepo repository.GroupRepository
+	cache     map[int]string
+}
+
+func newGroupCurrencies(groupRepo repository.GroupRepository) *groupCurrencies {
+	return &groupCurrencies{groupRepo: groupRepo, cache: make(map[int]string)}
+}
+
+func (g *groupCurrencies) get(groupID int) string {
+	if currency, ok := g.cache[groupID]; ok {
+		return currency
+	}
+
+	currency := money.DefaultCurrency
+	if group, err := g.groupRepo.GetGroupByID(groupID); err == nil {
+		currency = group.Currency
+	}
+	g.cache[groupID] = currency
+
+	return currency
+}
@@ -2,57 +2,173 @@ package service
 
 import (
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/shreyansh/expense-go-collab-backend/internal/model"
-	"github.com/shreyansh/expense-go-collab-backend/internal/repositorypg"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
 )
 
 type ExpenseService struct {
-	userRepo    *repositorypg.UserRepositoryPG
-	expenseRepo *repositorypg.ExpenseRepositoryPG
-	splitRepo   *repositorypg.ExpenseSplitRepositoryPG
-	memberRepo  *repositorypg.GroupMemberRepositoryPG
+	userRepo     repository.UserRepository
+	groupRepo    repository.GroupRepository
+	expenseRepo  repository.ExpenseRepository
+	splitRepo    repository.ExpenseSplitRepository
+	memberRepo   repository.GroupMemberRepository
+	rateRepo     repository.ExchangeRateRepository
+	categoryRepo repository.CategoryRepository
+	uow          repository.UnitOfWork
 }
 
 func NewExpenseService(
-	userRepo *repositorypg.UserRepositoryPG,
-	expenseRepo *repositorypg.ExpenseRepositoryPG,
-	splitRepo *repositorypg.ExpenseSplitRepositoryPG,
-	memberRepo *repositorypg.GroupMemberRepositoryPG,
+	userRepo repository.UserRepository,
+	groupRepo repository.GroupRepository,
+	expenseRepo repository.ExpenseRepository,
+	splitRepo repository.ExpenseSplitRepository,
+	memberRepo repository.GroupMemberRepository,
+	rateRepo repository.ExchangeRateRepository,
+	categoryRepo repository.CategoryRepository,
+	uow repository.UnitOfWork,
 ) *ExpenseService {
 	return &ExpenseService{
-		userRepo:    userRepo,
-		expenseRepo: expenseRepo,
-		splitRepo:   splitRepo,
-		memberRepo:  memberRepo,
+		userRepo:     userRepo,
+		groupRepo:    groupRepo,
+		expenseRepo:  expenseRepo,
+		splitRepo:    splitRepo,
+		memberRepo:   memberRepo,
+		rateRepo:     rateRepo,
+		categoryRepo: categoryRepo,
+		uow:          uow,
 	}
 }
 
-func (s *ExpenseService) CreateExpense(groupID, paidByID int, amount float64, description string) (*model.ExpenseResponse, error) {
-	if amount <= 0 {
+func newExpenseResponse(expense *model.Expense, paidByName, baseCurrency string) *model.ExpenseResponse {
+	return &model.ExpenseResponse{
+		ID:           expense.ID,
+		GroupID:      expense.GroupID,
+		PaidByID:     expense.PaidByID,
+		PaidByName:   paidByName,
+		Amount:       expense.Amount,
+		Currency:     expense.Currency,
+		FXRate:       expense.FXRate,
+		BaseAmount:   expense.BaseAmount,
+		BaseCurrency: baseCurrency,
+		SplitMode:    expense.SplitMode,
+		CategoryID:   expense.CategoryID,
+		Description:  expense.Description,
+		CreatedAt:    expense.CreatedAt,
+	}
+}
+
+func newExpenseSplitResponse(split *model.ExpenseSplit) *model.ExpenseSplitResponse {
+	return &model.ExpenseSplitResponse{
+		ID:         split.ID,
+		ExpenseID:  split.ExpenseID,
+		UserID:     split.UserID,
+		Amount:     split.Amount,
+		BaseAmount: split.BaseAmount,
+	}
+}
+
+// CreateExpense records an expense paid in the given currency (the group currency
+// when empty). The exchange rate to the group currency is snapshotted on the expense.
+// The expense is split according to req.Split, or equally among all members when
+// no split is given.
+func (s *ExpenseService) CreateExpense(req *model.ExpenseRequest, actorID int) (*model.ExpenseResponse, error) {
+	return s.createExpense(req, time.Time{}, actorID, nil)
+}
+
+// createExpense records an expense dated at date, or now when date is zero.
+// inTx, when set, runs first in the transaction that stores the expense and
+// can abort it by returning an error.
+func (s *ExpenseService) createExpense(req *model.ExpenseRequest, date time.Time, actorID int, inTx func(tx repository.Tx) error) (*model.ExpenseResponse, error) {
+	if req.Amount <= 0 {
 		return nil, fmt.Errorf("amount must be greater than 0")
 	}
 
 	// Get user details
-	user, err := s.userRepo.GetUserByID(paidByID)
+	user, err := s.userRepo.GetUserByID(req.PaidByID)
 	if err != nil {
 		return nil, fmt.Errorf("user not found")
 	}
 
+	group, err := s.groupRepo.GetGroupByID(req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberIDs, err := groupMemberIDs(s.memberRepo, req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if !containsUser(memberIDs, req.PaidByID) {
+		return nil, fmt.Errorf("payer is not a member of this group")
+	}
+
+	var categoryID *int
+	if req.CategoryID != nil && *req.CategoryID != 0 {
+		if err := checkCategory(s.categoryRepo, req.GroupID, *req.CategoryID); err != nil {
+			return nil, err
+		}
+		categoryID = req.CategoryID
+	}
+
+	splitMode, splits, err := computeSplits(req.Amount, req.Split, memberIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := money.NormalizeCurrency(req.Currency, group.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	rateDate := date
+	if rateDate.IsZero() {
+		rateDate = time.Now()
+	}
+	baseAmount, rate, err := convertAmount(s.rateRepo, req.Amount, currency, group.Currency, rateDate)
+	if err != nil {
+		return nil, err
+	}
+
 	expense := &model.Expense{
-		GroupID:     groupID,
-		PaidByID:    paidByID,
-		Amount:      amount,
-		Description: description,
+		GroupID:     req.GroupID,
+		PaidByID:    req.PaidByID,
+		Amount:      req.Amount,
+		Currency:    currency,
+		FXRate:      rate,
+		BaseAmount:  baseAmount,
+		SplitMode:   splitMode,
+		CategoryID:  categoryID,
+		Description: req.Description,
+		CreatedAt:   date,
 	}
 
-	createdExpense, err := s.expenseRepo.CreateExpense(expense)
+	// The expense and its splits are stored together or not at all
+	var createdExpense *model.Expense
+	err = s.uow.Do(func(tx repository.Tx) error {
+		if inTx != nil {
+			if err := inTx(tx); err != nil {
+				return err
+			}
+		}
+
+		createdExpense, err = recordExpense(tx, expense, splits, actorID)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Auto-split expense equally among all group members
-	members, err := s.memberRepo.GetGroupMembers(groupID)
+	return newExpenseResponse(createdExpense, user.Name, group.Currency), nil
+}
+
+// groupMemberIDs returns the user IDs of every member of the group, read
+// through memberRepo so that it can run inside a transaction
+func groupMemberIDs(memberRepo repository.GroupMemberRepository, groupID int) ([]int, error) {
+	members, err := memberRepo.GetGroupMembers(groupID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get group members: %v", err)
 	}
@@ -61,31 +177,21 @@ func (s *ExpenseService) CreateExpense(groupID, paidByID int, amount float64, de
 		return nil, fmt.Errorf("no members in group")
 	}
 
-	// Calculate split amount
-	splitAmount := amount / float64(len(members))
+	memberIDs := make([]int, len(members))
+	for i, member := range members {
+		memberIDs[i] = member.UserID
+	}
 
-	// Create splits for each member
-	for _, member := range members {
-		split := &model.ExpenseSplit{
-			ExpenseID: createdExpense.ID,
-			UserID:    member.UserID,
-			Amount:    splitAmount,
-		}
-		_, err := s.splitRepo.CreateSplit(split)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create split: %v", err)
+	return memberIDs, nil
+}
+
+func containsUser(userIDs []int, userID int) bool {
+	for _, id := range userIDs {
+		if id == userID {
+			return true
 		}
 	}
-
-	return &model.ExpenseResponse{
-		ID:          createdExpense.ID,
-		GroupID:     createdExpense.GroupID,
-		PaidByID:    createdExpense.PaidByID,
-		PaidByName:  user.Name,
-		Amount:      createdExpense.Amount,
-		Description: createdExpense.Description,
-		CreatedAt:   createdExpense.CreatedAt,
-	}, nil
+	return false
 }
 
 func (s *ExpenseService) GetExpenseByID(id int) (*model.ExpenseResponse, error) {
@@ -100,24 +206,38 @@ func (s *ExpenseService) GetExpenseByID(id int) (*model.ExpenseResponse, error)
 		return nil, fmt.Errorf("user not found")
 	}
 
-	return &model.ExpenseResponse{
-		ID:          expense.ID,
-		GroupID:     expense.GroupID,
-		PaidByID:    expense.PaidByID,
-		PaidByName:  user.Name,
-		Amount:      expense.Amount,
-		Description: expense.Description,
-		CreatedAt:   expense.CreatedAt,
-	}, nil
+	currencies := newGroupCurrencies(s.groupRepo)
+	return newExpenseResponse(expense, user.Name, currencies.get(expense.GroupID)), nil
+}
+
+// GetExpensesByGroupID returns one page of the group's expenses, the cursor of
+// the next page and the totals per category of every matching expense
+func (s *ExpenseService) GetExpensesByGroupID(groupID int, query *model.ListQuery) (*model.ExpenseListResponse, error) {
+	query.GroupID = groupID
+	return s.listExpenses(query)
+}
+
+// GetExpensesByUserID returns one page of the expenses the user paid, the
+// cursor of the next page and the totals per category of every matching expense
+func (s *ExpenseService) GetExpensesByUserID(userID int, query *model.ListQuery) (*model.ExpenseListResponse, error) {
+	query.UserID = userID
+	return s.listExpenses(query)
 }
 
-func (s *ExpenseService) GetExpensesByGroupID(groupID int) ([]*model.ExpenseResponse, error) {
-	expenses, err := s.expenseRepo.GetExpensesByGroupID(groupID)
+func (s *ExpenseService) listExpenses(query *model.ListQuery) (*model.ExpenseListResponse, error) {
+	expenses, next, err := s.expenseRepo.ListExpenses(query)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := s.expenseRepo.SumExpensesByCategory(query)
 	if err != nil {
 		return nil, err
 	}
 
-	var responses []*model.ExpenseResponse
+	currencies := newGroupCurrencies(s.groupRepo)
+
+	responses := []*model.ExpenseResponse{}
 	for _, expense := range expenses {
 		// Get user details
 		user, err := s.userRepo.GetUserByID(expense.PaidByID)
@@ -125,114 +245,390 @@ func (s *ExpenseService) GetExpensesByGroupID(groupID int) ([]*model.ExpenseResp
 			continue // Skip if user not found
 		}
 
-		responses = append(responses, &model.ExpenseResponse{
-			ID:          expense.ID,
-			GroupID:     expense.GroupID,
-			PaidByID:    expense.PaidByID,
-			PaidByName:  user.Name,
-			Amount:      expense.Amount,
-			Description: expense.Description,
-			CreatedAt:   expense.CreatedAt,
-		})
+		responses = append(responses, newExpenseResponse(expense, user.Name, currencies.get(expense.GroupID)))
 	}
 
-	return responses, nil
+	if totals == nil {
+		totals = []*model.CategoryTotal{}
+	}
+
+	return &model.ExpenseListResponse{Expenses: responses, NextCursor: next, CategoryTotals: totals}, nil
 }
 
-func (s *ExpenseService) GetExpensesByUserID(userID int) ([]*model.ExpenseResponse, error) {
-	expenses, err := s.expenseRepo.GetExpensesByUserID(userID)
+// UpdateExpense changes the amount, payer, category, description and/or splits
+// of an expense. The amount is converted with the rate snapshotted when the
+// expense was created. A new split spec replaces the splits; otherwise a changed
+// amount is split again with the expense's original split mode and participants.
+func (s *ExpenseService) UpdateExpense(id int, req *model.ExpenseUpdateRequest, actorID int) (*model.ExpenseResponse, error) {
+	if req.Amount != nil && *req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than 0")
+	}
+
+	var updatedExpense *model.Expense
+	err := s.uow.Do(func(tx repository.Tx) error {
+		locked, err := lockLiveExpense(tx, id)
+		if err != nil {
+			return err
+		}
+		if req.CategoryID != nil && *req.CategoryID != 0 {
+			if err := checkCategory(tx.Categories(), locked.GroupID, *req.CategoryID); err != nil {
+				return err
+			}
+		}
+		expense, before, err := snapshotExpense(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := postExpense(tx, id, -1); err != nil {
+			return err
+		}
+
+		memberIDs, err := groupMemberIDs(tx.Members(), expense.GroupID)
+		if err != nil {
+			return err
+		}
+
+		if req.PaidByID != 0 && req.PaidByID != expense.PaidByID {
+			if !containsUser(memberIDs, req.PaidByID) {
+				return fmt.Errorf("payer is not a member of this group")
+			}
+			expense.PaidByID = req.PaidByID
+		}
+		if req.Description != nil {
+			expense.Description = *req.Description
+		}
+		if req.CategoryID != nil {
+			expense.CategoryID = req.CategoryID
+			if *req.CategoryID == 0 {
+				expense.CategoryID = nil
+			}
+		}
+
+		amountChanged := req.Amount != nil && *req.Amount != expense.Amount
+		if amountChanged {
+			expense.Amount = *req.Amount
+			expense.BaseAmount = expense.FXRate.Convert(*req.Amount)
+		}
+
+		switch {
+		case req.Split != nil:
+			spec := *req.Split
+			if spec.Mode == "" {
+				spec.Mode = expense.SplitMode
+			}
+			mode, splits, err := computeSplits(expense.Amount, &spec, memberIDs)
+			if err != nil {
+				return err
+			}
+			expense.SplitMode = mode
+			if err := replaceSplits(tx, expense, splits); err != nil {
+				return err
+			}
+		case amountChanged:
+			splits, err := resplit(tx, expense)
+			if err != nil {
+				return err
+			}
+			if err := replaceSplits(tx, expense, splits); err != nil {
+				return err
+			}
+		}
+
+		updatedExpense, err = tx.Expenses().UpdateExpense(expense)
+		if err != nil {
+			return err
+		}
+
+		if err := postExpense(tx, id, 1); err != nil {
+			return err
+		}
+		return recordExpenseChange(tx, actorID, id, before)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	var responses []*model.ExpenseResponse
-	for _, expense := range expenses {
-		// Get user details
-		user, err := s.userRepo.GetUserByID(expense.PaidByID)
+	// Get user details
+	user, err := s.userRepo.GetUserByID(updatedExpense.PaidByID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	currencies := newGroupCurrencies(s.groupRepo)
+	return newExpenseResponse(updatedExpense, user.Name, currencies.get(updatedExpense.GroupID)), nil
+}
+
+// ReplaceSplits replaces every split of an expense at once. The new splits must
+// add up to the expense amount, and become the expense's split mode.
+func (s *ExpenseService) ReplaceSplits(expenseID int, spec *model.SplitSpec, actorID int) ([]*model.ExpenseSplitResponse, error) {
+	var splits []*model.ExpenseSplit
+	err := s.uow.Do(func(tx repository.Tx) error {
+		if _, err := lockLiveExpense(tx, expenseID); err != nil {
+			return err
+		}
+		expense, before, err := snapshotExpense(tx, expenseID)
 		if err != nil {
-			continue // Skip if user not found
+			return err
+		}
+		if err := postExpense(tx, expenseID, -1); err != nil {
+			return err
 		}
 
-		responses = append(responses, &model.ExpenseResponse{
-			ID:          expense.ID,
-			GroupID:     expense.GroupID,
-			PaidByID:    expense.PaidByID,
-			PaidByName:  user.Name,
-			Amount:      expense.Amount,
-			Description: expense.Description,
-			CreatedAt:   expense.CreatedAt,
-		})
+		memberIDs, err := groupMemberIDs(tx.Members(), expense.GroupID)
+		if err != nil {
+			return err
+		}
+
+		expense.SplitMode, splits, err = computeSplits(expense.Amount, spec, memberIDs)
+		if err != nil {
+			return err
+		}
+		if err := replaceSplits(tx, expense, splits); err != nil {
+			return err
+		}
+
+		if _, err = tx.Expenses().UpdateExpense(expense); err != nil {
+			return err
+		}
+
+		if err := postExpense(tx, expenseID, 1); err != nil {
+			return err
+		}
+		return recordExpenseChange(tx, actorID, expenseID, before)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []*model.ExpenseSplitResponse
+	for _, split := range splits {
+		responses = append(responses, newExpenseSplitResponse(split))
 	}
 
 	return responses, nil
 }
 
-func (s *ExpenseService) UpdateExpense(id int, amount float64, description string) (*model.ExpenseResponse, error) {
-	if amount <= 0 {
-		return nil, fmt.Errorf("amount must be greater than 0")
+// resplit divides the expense amount again between the current participants,
+// keeping their weights under the expense's split mode
+func resplit(tx repository.Tx, expense *model.Expense) ([]*model.ExpenseSplit, error) {
+	existing, err := tx.Splits().GetSplitsByExpenseID(expense.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) == 0 {
+		return nil, fmt.Errorf("expense has no splits to recompute")
+	}
+	if expense.SplitMode == model.SplitModeExact {
+		return nil, fmt.Errorf("exact splits must be given again when the amount changes")
 	}
 
-	expense := &model.Expense{
-		ID:          id,
-		Amount:      amount,
-		Description: description,
+	sort.Slice(existing, func(i, j int) bool { return existing[i].UserID < existing[j].UserID })
+	participants := make([]model.SplitParticipant, len(existing))
+	weights := make([]int64, len(existing))
+	for i, split := range existing {
+		participants[i] = model.SplitParticipant{UserID: split.UserID}
+		weights[i] = split.Weight
 	}
 
-	updatedExpense, err := s.expenseRepo.UpdateExpense(expense)
+	return splitByWeights(expense.Amount, expense.SplitMode, participants, weights)
+}
+
+// replaceSplits deletes the splits of an expense and stores the given ones in
+// their place, converting them into the group currency
+func replaceSplits(tx repository.Tx, expense *model.Expense, splits []*model.ExpenseSplit) error {
+	splitAmounts := make([]money.Amount, len(splits))
+	for i, split := range splits {
+		splitAmounts[i] = split.Amount
+	}
+	baseAmounts, err := allocateBaseAmounts(expense.BaseAmount, splitAmounts)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Splits().DeleteSplitsByExpenseID(expense.ID); err != nil {
+		return err
+	}
+
+	for i, split := range splits {
+		split.ExpenseID = expense.ID
+		split.BaseAmount = baseAmounts[i]
+		if _, err := tx.Splits().CreateSplit(split); err != nil {
+			return fmt.Errorf("failed to create split: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// lockLiveExpense locks an expense until the transaction ends, so that
+// concurrent changes do not both take off the same balance posting. It fails
+// when the expense was deleted while waiting for the lock. Every change to an
+// expense or its splits starts with it.
+func lockLiveExpense(tx repository.Tx, expenseID int) (*model.Expense, error) {
+	expense, err := tx.Expenses().LockExpense(expenseID)
 	if err != nil {
 		return nil, err
 	}
+	if expense.DeletedAt != nil {
+		return nil, fmt.Errorf("expense not found")
+	}
+	return expense, nil
+}
 
-	// Get user details
-	user, err := s.userRepo.GetUserByID(updatedExpense.PaidByID)
+// postExpense adds what each participant of the expense owes its payer to the
+// group's balances, or takes it off again when sign is -1. Every change to an
+// expense or its splits is wrapped in a -1 and a 1 post in the same transaction.
+func postExpense(tx repository.Tx, expenseID int, sign money.Amount) error {
+	expense, err := tx.Expenses().GetExpenseByID(expenseID)
 	if err != nil {
-		return nil, fmt.Errorf("user not found")
+		return err
 	}
 
-	return &model.ExpenseResponse{
-		ID:          updatedExpense.ID,
-		GroupID:     updatedExpense.GroupID,
-		PaidByID:    updatedExpense.PaidByID,
-		PaidByName:  user.Name,
-		Amount:      updatedExpense.Amount,
-		Description: updatedExpense.Description,
-		CreatedAt:   updatedExpense.CreatedAt,
-	}, nil
+	splits, err := tx.Splits().GetSplitsByExpenseID(expenseID)
+	if err != nil {
+		return err
+	}
+
+	for _, split := range splits {
+		err := tx.Balances().AdjustBalance(expense.GroupID, split.UserID, expense.PaidByID, sign*split.BaseAmount)
+		if err != nil {
+			return fmt.Errorf("failed to update balances: %v", err)
+		}
+	}
+
+	return nil
 }
 
-func (s *ExpenseService) DeleteExpense(id int) error {
-	// Delete all splits for this expense first
-	err := s.splitRepo.DeleteSplitsByExpenseID(id)
+// DeleteExpense soft-deletes an expense and takes it out of the balances. Its
+// splits are kept so that RestoreExpense can bring it back as it was.
+func (s *ExpenseService) DeleteExpense(id, actorID int) error {
+	return s.uow.Do(func(tx repository.Tx) error {
+		if _, err := lockLiveExpense(tx, id); err != nil {
+			return err
+		}
+		expense, before, err := snapshotExpense(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := postExpense(tx, id, -1); err != nil {
+			return err
+		}
+
+		if err := tx.Expenses().DeleteExpense(id, time.Now()); err != nil {
+			return err
+		}
+		return recordActivity(tx, expense.GroupID, actorID, model.ActivityDeleted, model.EntityExpense, id, before, nil)
+	})
+}
+
+// RestoreExpense brings back a soft-deleted expense and posts it to the
+// balances again. An expense deleted with its group comes back with the group.
+func (s *ExpenseService) RestoreExpense(id, actorID int) (*model.ExpenseResponse, error) {
+	err := s.uow.Do(func(tx repository.Tx) error {
+		deleted, err := tx.Expenses().LockExpense(id)
+		if err != nil {
+			return err
+		}
+		if deleted.DeletedAt == nil {
+			return fmt.Errorf("deleted expense not found")
+		}
+		if _, err := tx.Groups().GetGroupByID(deleted.GroupID); err != nil {
+			return fmt.Errorf("the expense's group is deleted, restore the group first")
+		}
+
+		if err := tx.Expenses().RestoreExpense(id); err != nil {
+			return err
+		}
+		if err := postExpense(tx, id, 1); err != nil {
+			return err
+		}
+
+		_, after, err := snapshotExpense(tx, id)
+		if err != nil {
+			return err
+		}
+		return recordActivity(tx, deleted.GroupID, actorID, model.ActivityRestored, model.EntityExpense, id, nil, after)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return s.expenseRepo.DeleteExpense(id)
+	return s.GetExpenseByID(id)
 }
 
-// Add Split
-func (s *ExpenseService) AddSplit(expenseID, userID int, amount float64) (*model.ExpenseSplitResponse, error) {
+// AddSplit adds a split for a group member who is not yet part of the expense
+func (s *ExpenseService) AddSplit(expenseID, userID int, amount money.Amount, actorID int) (*model.ExpenseSplitResponse, error) {
 	if amount <= 0 {
 		return nil, fmt.Errorf("split amount must be greater than 0")
 	}
 
-	split := &model.ExpenseSplit{
-		ExpenseID: expenseID,
-		UserID:    userID,
-		Amount:    amount,
-	}
+	var createdSplit *model.ExpenseSplit
+	err := s.uow.Do(func(tx repository.Tx) error {
+		expense, err := lockLiveExpense(tx, expenseID)
+		if err != nil {
+			return err
+		}
+
+		memberIDs, err := groupMemberIDs(tx.Members(), expense.GroupID)
+		if err != nil {
+			return err
+		}
+		if !containsUser(memberIDs, userID) {
+			return fmt.Errorf("user %d is not a member of this group", userID)
+		}
 
-	createdSplit, err := s.splitRepo.CreateSplit(split)
+		// Splits always add up to the expense amount, so a split can only be
+		// added to fill a gap left by splits recorded before that was enforced
+		existing, err := tx.Splits().GetSplitsByExpenseID(expenseID)
+		if err != nil {
+			return err
+		}
+		total := amount
+		for _, split := range existing {
+			if split.UserID == userID {
+				return fmt.Errorf("user %d already has a split on this expense", userID)
+			}
+			total += split.Amount
+		}
+		if total != expense.Amount {
+			return splitTotalError(total, expense.Amount)
+		}
+
+		split := &model.ExpenseSplit{
+			ExpenseID: expenseID,
+			UserID:    userID,
+			Amount:    amount,
+			Weight:    int64(amount),
+		}
+
+		_, before, err := snapshotExpense(tx, expenseID)
+		if err != nil {
+			return err
+		}
+		if err := postExpense(tx, expenseID, -1); err != nil {
+			return err
+		}
+
+		createdSplit, err = tx.Splits().CreateSplit(split)
+		if err != nil {
+			return err
+		}
+		// The new split takes its share of the converted expense from the
+		// others, so they are all converted again
+		if err := updateSplits(tx, expense, append(existing, createdSplit)); err != nil {
+			return err
+		}
+
+		if err := postExpense(tx, expenseID, 1); err != nil {
+			return err
+		}
+		return recordExpenseChange(tx, actorID, expenseID, before)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &model.ExpenseSplitResponse{
-		ID:        createdSplit.ID,
-		ExpenseID: createdSplit.ExpenseID,
-		UserID:    createdSplit.UserID,
-		Amount:    createdSplit.Amount,
-	}, nil
+	return newExpenseSplitResponse(createdSplit), nil
 }
 
 func (s *ExpenseService) GetSplitsByExpenseID(expenseID int) ([]*model.ExpenseSplitResponse, error) {
@@ -243,12 +639,7 @@ func (s *ExpenseService) GetSplitsByExpenseID(expenseID int) ([]*model.ExpenseSp
 
 	var responses []*model.ExpenseSplitResponse
 	for _, split := range splits {
-		responses = append(responses, &model.ExpenseSplitResponse{
-			ID:        split.ID,
-			ExpenseID: split.ExpenseID,
-			UserID:    split.UserID,
-			Amount:    split.Amount,
-		})
+		responses = append(responses, newExpenseSplitResponse(split))
 	}
 
 	return responses, nil
@@ -262,36 +653,100 @@ func (s *ExpenseService) GetSplitsByUserID(userID int) ([]*model.ExpenseSplitRes
 
 	var responses []*model.ExpenseSplitResponse
 	for _, split := range splits {
-		responses = append(responses, &model.ExpenseSplitResponse{
-			ID:        split.ID,
-			ExpenseID: split.ExpenseID,
-			UserID:    split.UserID,
-			Amount:    split.Amount,
-		})
+		responses = append(responses, newExpenseSplitResponse(split))
 	}
 
 	return responses, nil
 }
 
-func (s *ExpenseService) UpdateSplit(id int, amount float64) (*model.ExpenseSplitResponse, error) {
+// UpdateSplit changes the amount of a single split. The splits of an expense must
+// keep adding up to its amount; to move money between participants use ReplaceSplits.
+func (s *ExpenseService) UpdateSplit(id int, amount money.Amount, actorID int) (*model.ExpenseSplitResponse, error) {
 	if amount <= 0 {
 		return nil, fmt.Errorf("split amount must be greater than 0")
 	}
 
-	split := &model.ExpenseSplit{
-		ID:     id,
-		Amount: amount,
+	// A split never moves to another expense, so it can be found before locking
+	found, err := s.splitRepo.GetSplitByID(id)
+	if err != nil {
+		return nil, err
 	}
 
-	updatedSplit, err := s.splitRepo.UpdateSplit(split)
+	var updatedSplit *model.ExpenseSplit
+	err = s.uow.Do(func(tx repository.Tx) error {
+		expense, err := lockLiveExpense(tx, found.ExpenseID)
+		if err != nil {
+			return err
+		}
+
+		splits, err := tx.Splits().GetSplitsByExpenseID(expense.ID)
+		if err != nil {
+			return err
+		}
+		total := amount
+		for _, split := range splits {
+			if split.ID == id {
+				split.Amount = amount
+				updatedSplit = split
+			} else {
+				total += split.Amount
+			}
+		}
+		if updatedSplit == nil {
+			return fmt.Errorf("split not found")
+		}
+		if total != expense.Amount {
+			return splitTotalError(total, expense.Amount)
+		}
+
+		_, before, err := snapshotExpense(tx, expense.ID)
+		if err != nil {
+			return err
+		}
+		if err := postExpense(tx, expense.ID, -1); err != nil {
+			return err
+		}
+
+		if err := updateSplits(tx, expense, splits); err != nil {
+			return err
+		}
+
+		if err := postExpense(tx, expense.ID, 1); err != nil {
+			return err
+		}
+		return recordExpenseChange(tx, actorID, expense.ID, before)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &model.ExpenseSplitResponse{
-		ID:        updatedSplit.ID,
-		ExpenseID: updatedSplit.ExpenseID,
-		UserID:    updatedSplit.UserID,
-		Amount:    updatedSplit.Amount,
-	}, nil
+	return newExpenseSplitResponse(updatedSplit), nil
+}
+
+// updateSplits converts the splits of an expense into the group currency all
+// at once, so they add up exactly to the converted expense, and saves them.
+// Leftover cents go by user ID, whatever order the splits were read in.
+func updateSplits(tx repository.Tx, expense *model.Expense, splits []*model.ExpenseSplit) error {
+	sort.Slice(splits, func(i, j int) bool { return splits[i].UserID < splits[j].UserID })
+	splitAmounts := make([]money.Amount, len(splits))
+	for i, split := range splits {
+		splitAmounts[i] = split.Amount
+	}
+	baseAmounts, err := allocateBaseAmounts(expense.BaseAmount, splitAmounts)
+	if err != nil {
+		return err
+	}
+
+	for i, split := range splits {
+		split.BaseAmount = baseAmounts[i]
+		if _, err := tx.Splits().UpdateSplit(split); err != nil {
+			return fmt.Errorf("failed to update split: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func splitTotalError(total, expected money.Amount) error {
+	return fmt.Errorf("splits would add up to %s instead of the expense amount %s; replace all splits of the expense at once", total, expected)
 }
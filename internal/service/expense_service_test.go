@@ -0,0 +1,163 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repositorymem"
+)
+
+func newExpenseService(store *repositorymem.Store) *ExpenseService {
+	return NewExpenseService(
+		repositorymem.NewUserRepositoryMem(store),
+		repositorymem.NewGroupRepositoryMem(store),
+		repositorymem.NewExpenseRepositoryMem(store),
+		repositorymem.NewExpenseSplitRepositoryMem(store),
+		repositorymem.NewGroupMemberRepositoryMem(store),
+		repositorymem.NewExchangeRateRepositoryMem(store),
+		repositorymem.NewCategoryRepositoryMem(store),
+		repositorymem.NewUnitOfWorkMem(store),
+	)
+}
+
+func TestUpdateExpense(t *testing.T) {
+	amount := func(a money.Amount) *money.Amount { return &a }
+	description := "Lunch"
+	missing := 999
+
+	tests := []struct {
+		name    string
+		req     model.ExpenseUpdateRequest
+		wantErr bool
+		// wantAmount is the expense amount afterwards
+		wantAmount money.Amount
+	}{
+		{name: "new amount", req: model.ExpenseUpdateRequest{Amount: amount(1200)}, wantAmount: 1200},
+		{name: "no amount keeps it", req: model.ExpenseUpdateRequest{Description: &description}, wantAmount: 900},
+		{name: "zero amount", req: model.ExpenseUpdateRequest{Amount: amount(0)}, wantErr: true},
+		{name: "negative amount", req: model.ExpenseUpdateRequest{Amount: amount(-100)}, wantErr: true},
+		{name: "unknown category", req: model.ExpenseUpdateRequest{CategoryID: &missing}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := repositorymem.NewStore()
+			groupID, userIDs := seedGroup(t, store, "ann", "bob", "cat")
+			expenses := newExpenseService(store)
+
+			created, err := expenses.CreateExpense(&model.ExpenseRequest{GroupID: groupID, PaidByID: userIDs[0], Amount: 900}, userIDs[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = expenses.UpdateExpense(created.ID, &tt.req, userIDs[0])
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("UpdateExpense succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateExpense failed: %v", err)
+			}
+
+			expense, err := expenses.GetExpenseByID(created.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expense.Amount != tt.wantAmount {
+				t.Errorf("amount = %s, want %s", expense.Amount, tt.wantAmount)
+			}
+		})
+	}
+}
+
+func TestUpdateSplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  money.Amount
+		wantErr bool
+	}{
+		{name: "same amount", amount: 334},
+		{name: "more than the expense leaves", amount: 400, wantErr: true},
+		{name: "less than the expense needs", amount: 300, wantErr: true},
+		{name: "zero", amount: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := repositorymem.NewStore()
+			groupID, userIDs := seedGroup(t, store, "ann", "bob", "cat")
+			expenses := newExpenseService(store)
+
+			// Converting each split on its own would add up to a cent more
+			// than the converted expense
+			rate, err := money.ParseRate("1.005")
+			if err != nil {
+				t.Fatal(err)
+			}
+			rates := repositorymem.NewExchangeRateRepositoryMem(store)
+			if err := rates.UpsertRate(&model.ExchangeRate{BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: rate, EffectiveDate: time.Now()}); err != nil {
+				t.Fatal(err)
+			}
+			created, err := expenses.CreateExpense(&model.ExpenseRequest{GroupID: groupID, PaidByID: userIDs[0], Amount: 1000, Currency: "EUR"}, userIDs[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before, err := expenses.GetSplitsByExpenseID(created.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var splitID int
+			for _, split := range before {
+				if split.UserID == userIDs[0] {
+					splitID = split.ID
+				}
+			}
+
+			_, err = expenses.UpdateSplit(splitID, tt.amount, userIDs[0])
+			if tt.wantErr && err == nil {
+				t.Fatal("UpdateSplit succeeded, want an error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("UpdateSplit failed: %v", err)
+			}
+
+			after, err := expenses.GetSplitsByExpenseID(created.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(after, before) {
+				t.Errorf("splits = %s, want them unchanged %s", describeSplits(after), describeSplits(before))
+			}
+			var baseTotal money.Amount
+			for _, split := range after {
+				baseTotal += split.BaseAmount
+			}
+			if baseTotal != created.BaseAmount {
+				t.Errorf("converted splits add up to %s, want %s", baseTotal, created.BaseAmount)
+			}
+
+			expense, err := expenses.GetExpenseByID(created.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expense.SplitMode != model.SplitModeEqual {
+				t.Errorf("split mode = %s, want %s", expense.SplitMode, model.SplitModeEqual)
+			}
+		})
+	}
+}
+
+func describeSplits(splits []*model.ExpenseSplitResponse) string {
+	var s string
+	for _, split := range splits {
+		s += fmt.Sprintf("%+v ", *split)
+	}
+	return s
+}
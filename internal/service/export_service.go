@@ -0,0 +1,254 @@
+package service
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+// ledgerCSVHeader is the header of a group ledger export. Each expense row is
+// followed by one split row per participant, who owes the payer their share.
+// from_balance and to_balance are the running balances of the two users after
+// the row (positive = owed by the group), in the group currency.
+var ledgerCSVHeader = []string{
+	"date", "type", "expense_id", "settlement_id", "description", "category",
+	"from", "to", "amount", "currency", "base_amount", "base_currency",
+	"from_balance", "to_balance",
+}
+
+// Row types of a ledger export
+const (
+	ledgerRowExpense    = "expense"
+	ledgerRowSplit      = "split"
+	ledgerRowSettlement = "settlement"
+)
+
+// ExportService writes a group's ledger out for use in other tools
+type ExportService struct {
+	categoryRepo repository.CategoryRepository
+	uow          repository.UnitOfWork
+}
+
+func NewExportService(categoryRepo repository.CategoryRepository, uow repository.UnitOfWork) *ExportService {
+	return &ExportService{categoryRepo: categoryRepo, uow: uow}
+}
+
+// WriteGroupLedgerCSV writes every expense with its splits and every settlement
+// of the group to w in the order they were recorded, with the running balances
+// of the users involved. Rows are streamed from the repositories one at a time,
+// all read from the same snapshot so that the running balances add up even
+// while the group changes.
+func (s *ExportService) WriteGroupLedgerCSV(groupID int, w io.Writer) error {
+	return s.uow.View(func(tx repository.Tx) error {
+		return s.writeGroupLedgerCSV(tx, groupID, w)
+	})
+}
+
+func (s *ExportService) writeGroupLedgerCSV(tx repository.Tx, groupID int, w io.Writer) error {
+	group, err := tx.Groups().GetGroupByID(groupID)
+	if err != nil {
+		return err
+	}
+
+	expenses, err := tx.Expenses().IterateExpensesByGroupID(groupID)
+	if err != nil {
+		return err
+	}
+	defer expenses.Close()
+
+	splits, err := tx.Splits().IterateSplitsByGroupID(groupID)
+	if err != nil {
+		return err
+	}
+	defer splits.Close()
+
+	settlements, err := tx.Settlements().IterateSettlementsByGroupID(groupID)
+	if err != nil {
+		return err
+	}
+	defer settlements.Close()
+
+	ledger := &ledgerWriter{
+		csv:        csv.NewWriter(w),
+		group:      group,
+		userRepo:   tx.Users(),
+		names:      make(map[int]string),
+		categories: newCategoryNames(s.categoryRepo),
+		balances:   make(map[int]money.Amount),
+	}
+	if err := ledger.csv.Write(ledgerCSVHeader); err != nil {
+		return err
+	}
+
+	// Merge the expense and settlement streams by date, expenses first on ties
+	expense, err := expenses.Next()
+	if err != nil {
+		return err
+	}
+	settlement, err := settlements.Next()
+	if err != nil {
+		return err
+	}
+	split, err := splits.Next()
+	if err != nil {
+		return err
+	}
+
+	for expense != nil || settlement != nil {
+		if expense != nil && (settlement == nil || !settlement.CreatedAt.Before(expense.CreatedAt)) {
+			if err := ledger.writeExpense(expense); err != nil {
+				return err
+			}
+			// Splits come in the same order as expenses. One that does not
+			// belong to this expense is passed over when its expense came
+			// earlier or is gone, so that it cannot hold up the rest.
+			for split != nil {
+				if split.ExpenseID == expense.ID {
+					if err := ledger.writeSplit(expense, split); err != nil {
+						return err
+					}
+				} else if !strayedSplit(tx, expense, split) {
+					break
+				}
+				if split, err = splits.Next(); err != nil {
+					return err
+				}
+			}
+			if expense, err = expenses.Next(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := ledger.writeSettlement(settlement); err != nil {
+			return err
+		}
+		if settlement, err = settlements.Next(); err != nil {
+			return err
+		}
+	}
+
+	ledger.csv.Flush()
+	return ledger.csv.Error()
+}
+
+// strayedSplit reports whether a split that does not belong to expense comes
+// before it in the export order, or belongs to no exported expense at all
+func strayedSplit(tx repository.Tx, expense *model.Expense, split *model.ExpenseSplit) bool {
+	owner, err := tx.Expenses().GetExpenseByID(split.ExpenseID)
+	if err != nil || owner.GroupID != expense.GroupID {
+		return true
+	}
+	if !owner.CreatedAt.Equal(expense.CreatedAt) {
+		return owner.CreatedAt.Before(expense.CreatedAt)
+	}
+	return owner.ID < expense.ID
+}
+
+// ledgerWriter writes ledger rows and keeps the running balance of every user
+type ledgerWriter struct {
+	csv        *csv.Writer
+	group      *model.Group
+	userRepo   repository.UserRepository
+	names      map[int]string
+	categories *categoryNames
+	balances   map[int]money.Amount
+}
+
+func (l *ledgerWriter) writeExpense(expense *model.Expense) error {
+	category, err := l.categories.get(expense.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	return l.csv.Write([]string{
+		formatLedgerTime(expense.CreatedAt), ledgerRowExpense, strconv.Itoa(expense.ID), "", safeCell(expense.Description), safeCell(category),
+		l.name(expense.PaidByID), "", expense.Amount.String(), expense.Currency, expense.BaseAmount.String(), l.group.Currency,
+		"", "",
+	})
+}
+
+// writeSplit writes what a participant owes the payer of an expense
+func (l *ledgerWriter) writeSplit(expense *model.Expense, split *model.ExpenseSplit) error {
+	if split.UserID != expense.PaidByID {
+		l.balances[split.UserID] -= split.BaseAmount
+		l.balances[expense.PaidByID] += split.BaseAmount
+	}
+
+	return l.csv.Write([]string{
+		formatLedgerTime(expense.CreatedAt), ledgerRowSplit, strconv.Itoa(expense.ID), "", safeCell(expense.Description), "",
+		l.name(split.UserID), l.name(expense.PaidByID), split.Amount.String(), expense.Currency, split.BaseAmount.String(), l.group.Currency,
+		l.balances[split.UserID].String(), l.balances[expense.PaidByID].String(),
+	})
+}
+
+func (l *ledgerWriter) writeSettlement(settlement *model.Settlement) error {
+	l.balances[settlement.FromUserID] += settlement.BaseAmount
+	l.balances[settlement.ToUserID] -= settlement.BaseAmount
+
+	return l.csv.Write([]string{
+		formatLedgerTime(settlement.CreatedAt), ledgerRowSettlement, "", strconv.Itoa(settlement.ID), safeCell(settlement.Description), "",
+		l.name(settlement.FromUserID), l.name(settlement.ToUserID), settlement.Amount.String(), settlement.Currency, settlement.BaseAmount.String(), l.group.Currency,
+		l.balances[settlement.FromUserID].String(), l.balances[settlement.ToUserID].String(),
+	})
+}
+
+// name returns a user's name, or their ID when the user no longer exists
+func (l *ledgerWriter) name(userID int) string {
+	if name, ok := l.names[userID]; ok {
+		return name
+	}
+
+	name := fmt.Sprintf("user %d", userID)
+	if user, err := l.userRepo.GetUserByID(userID); err == nil {
+		name = safeCell(user.Name)
+	}
+	l.names[userID] = name
+	return name
+}
+
+// safeCell keeps a user-written cell from being run as a formula by
+// spreadsheets, by prefixing a quote to text that starts like one
+func safeCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func formatLedgerTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// categoryNames looks up category names once per category
+type categoryNames struct {
+	categoryRepo repository.CategoryRepository
+	names        map[int]string
+}
+
+func newCategoryNames(categoryRepo repository.CategoryRepository) *categoryNames {
+	return &categoryNames{categoryRepo: categoryRepo, names: make(map[int]string)}
+}
+
+func (c *categoryNames) get(id *int) (string, error) {
+	if id == nil {
+		return "", nil
+	}
+	if name, ok := c.names[*id]; ok {
+		return name, nil
+	}
+
+	category, err := c.categoryRepo.GetCategoryByID(*id)
+	if err != nil {
+		return "", err
+	}
+	c.names[*id] = category.Name
+	return category.Name, nil
+}
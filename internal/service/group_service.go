@@ -2,27 +2,31 @@ package service
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/shreyansh/expense-go-collab-backend/internal/model"
-	"github.com/shreyansh/expense-go-collab-backend/internal/repositorypg"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
 )
 
 type GroupService struct {
-	userRepo    *repositorypg.UserRepositoryPG
-	groupRepo   *repositorypg.GroupRepositoryPG
-	memberRepo  *repositorypg.GroupMemberRepositoryPG
-	expenseRepo *repositorypg.ExpenseRepositoryPG
-	splitRepo   *repositorypg.ExpenseSplitRepositoryPG
-	balanceRepo *repositorypg.BalanceRepositoryPG
+	userRepo    repository.UserRepository
+	groupRepo   repository.GroupRepository
+	memberRepo  repository.GroupMemberRepository
+	expenseRepo repository.ExpenseRepository
+	splitRepo   repository.ExpenseSplitRepository
+	balanceRepo repository.BalanceRepository
+	uow         repository.UnitOfWork
 }
 
 func NewGroupService(
-	userRepo *repositorypg.UserRepositoryPG,
-	groupRepo *repositorypg.GroupRepositoryPG,
-	memberRepo *repositorypg.GroupMemberRepositoryPG,
-	expenseRepo *repositorypg.ExpenseRepositoryPG,
-	splitRepo *repositorypg.ExpenseSplitRepositoryPG,
-	balanceRepo *repositorypg.BalanceRepositoryPG,
+	userRepo repository.UserRepository,
+	groupRepo repository.GroupRepository,
+	memberRepo repository.GroupMemberRepository,
+	expenseRepo repository.ExpenseRepository,
+	splitRepo repository.ExpenseSplitRepository,
+	balanceRepo repository.BalanceRepository,
+	uow repository.UnitOfWork,
 ) *GroupService {
 	return &GroupService{
 		userRepo:    userRepo,
@@ -31,37 +35,58 @@ func NewGroupService(
 		expenseRepo: expenseRepo,
 		splitRepo:   splitRepo,
 		balanceRepo: balanceRepo,
+		uow:         uow,
 	}
 }
 
-func (s *GroupService) CreateGroup(name string, description string, creatorID int) (*model.GroupResponse, error) {
+// CreateGroup creates a group whose balances are kept in the given currency
+// (the default currency when empty). The currency cannot be changed afterwards.
+func (s *GroupService) CreateGroup(name string, description string, currency string, creatorID int) (*model.GroupResponse, error) {
 	if name == "" {
 		return nil, fmt.Errorf("group name is required")
 	}
 
+	currency, err := money.NormalizeCurrency(currency, money.DefaultCurrency)
+	if err != nil {
+		return nil, err
+	}
+
 	group := &model.Group{
 		Name:        name,
 		Description: description,
 		CreatorID:   creatorID,
+		Currency:    currency,
 	}
 
-	createdGroup, err := s.groupRepo.CreateGroup(group)
+	var createdGroup *model.Group
+	err = s.uow.Do(func(tx repository.Tx) error {
+		createdGroup, err = tx.Groups().CreateGroup(group)
+		if err != nil {
+			return err
+		}
+
+		// Add creator as the group owner
+		member := &model.GroupMember{
+			GroupID: createdGroup.ID,
+			UserID:  creatorID,
+			Role:    model.RoleOwner,
+		}
+		if _, err := tx.Members().AddMember(member); err != nil {
+			return err
+		}
+
+		return recordActivity(tx, createdGroup.ID, creatorID, model.ActivityCreated, model.EntityGroup, createdGroup.ID, nil, snapshotGroup(createdGroup))
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Add creator as member
-	member := &model.GroupMember{
-		GroupID: createdGroup.ID,
-		UserID:  creatorID,
-	}
-	s.memberRepo.AddMember(member)
-
 	return &model.GroupResponse{
 		ID:          createdGroup.ID,
 		Name:        createdGroup.Name,
 		Description: createdGroup.Description,
 		CreatorID:   createdGroup.CreatorID,
+		Currency:    createdGroup.Currency,
 		CreatedAt:   createdGroup.CreatedAt,
 	}, nil
 }
@@ -77,6 +102,7 @@ func (s *GroupService) GetGroupByID(id int) (*model.GroupResponse, error) {
 		Name:        group.Name,
 		Description: group.Description,
 		CreatorID:   group.CreatorID,
+		Currency:    group.Currency,
 		CreatedAt:   group.CreatedAt,
 	}, nil
 }
@@ -94,6 +120,7 @@ func (s *GroupService) GetAllGroups() ([]*model.GroupResponse, error) {
 			Name:        group.Name,
 			Description: group.Description,
 			CreatorID:   group.CreatorID,
+			Currency:    group.Currency,
 			CreatedAt:   group.CreatedAt,
 		})
 	}
@@ -114,6 +141,7 @@ func (s *GroupService) GetGroupsByUserID(userID int) ([]*model.GroupResponse, er
 			Name:        group.Name,
 			Description: group.Description,
 			CreatorID:   group.CreatorID,
+			Currency:    group.Currency,
 			CreatedAt:   group.CreatedAt,
 		})
 	}
@@ -121,14 +149,27 @@ func (s *GroupService) GetGroupsByUserID(userID int) ([]*model.GroupResponse, er
 	return responses, nil
 }
 
-func (s *GroupService) UpdateGroup(id int, name, description string) (*model.GroupResponse, error) {
+func (s *GroupService) UpdateGroup(id int, name, description string, actorID int) (*model.GroupResponse, error) {
 	group := &model.Group{
 		ID:          id,
 		Name:        name,
 		Description: description,
 	}
 
-	updatedGroup, err := s.groupRepo.UpdateGroup(group)
+	var updatedGroup *model.Group
+	err := s.uow.Do(func(tx repository.Tx) error {
+		before, err := tx.Groups().GetGroupByID(id)
+		if err != nil {
+			return err
+		}
+
+		updatedGroup, err = tx.Groups().UpdateGroup(group)
+		if err != nil {
+			return err
+		}
+
+		return recordActivity(tx, id, actorID, model.ActivityUpdated, model.EntityGroup, id, snapshotGroup(before), snapshotGroup(updatedGroup))
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -138,15 +179,83 @@ func (s *GroupService) UpdateGroup(id int, name, description string) (*model.Gro
 		Name:        updatedGroup.Name,
 		Description: updatedGroup.Description,
 		CreatorID:   updatedGroup.CreatorID,
+		Currency:    updatedGroup.Currency,
 		CreatedAt:   updatedGroup.CreatedAt,
 	}, nil
 }
 
-func (s *GroupService) DeleteGroup(id int) error {
-	return s.groupRepo.DeleteGroup(id)
+// DeleteGroup soft-deletes a group together with its expenses and settlements,
+// all with the same deletion time so that RestoreGroup can tell them from the
+// ones deleted earlier on their own. The group's balances are cleared.
+func (s *GroupService) DeleteGroup(id, actorID int) error {
+	return s.uow.Do(func(tx repository.Tx) error {
+		group, err := tx.Groups().GetGroupByID(id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Groups().DeleteGroup(id, now); err != nil {
+			return err
+		}
+		if err := tx.Expenses().DeleteGroupExpenses(id, now); err != nil {
+			return err
+		}
+		if err := tx.Settlements().DeleteGroupSettlements(id, now); err != nil {
+			return err
+		}
+		if err := tx.Balances().CalculateBalances(id); err != nil {
+			return fmt.Errorf("failed to update balances: %v", err)
+		}
+
+		return recordActivity(tx, id, actorID, model.ActivityDeleted, model.EntityGroup, id, snapshotGroup(group), nil)
+	})
 }
 
-func (s *GroupService) AddMemberToGroup(groupID, userID int) (*model.GroupMemberResponse, error) {
+// RestoreGroup brings back a soft-deleted group with the expenses and
+// settlements deleted along with it, and rebuilds its balances
+func (s *GroupService) RestoreGroup(id, actorID int) (*model.GroupResponse, error) {
+	var group *model.Group
+	err := s.uow.Do(func(tx repository.Tx) error {
+		deleted, err := tx.Groups().GetDeletedGroupByID(id)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Groups().RestoreGroup(id); err != nil {
+			return err
+		}
+		if err := tx.Expenses().RestoreGroupExpenses(id, *deleted.DeletedAt); err != nil {
+			return err
+		}
+		if err := tx.Settlements().RestoreGroupSettlements(id, *deleted.DeletedAt); err != nil {
+			return err
+		}
+		if err := tx.Balances().CalculateBalances(id); err != nil {
+			return fmt.Errorf("failed to update balances: %v", err)
+		}
+
+		group, err = tx.Groups().GetGroupByID(id)
+		if err != nil {
+			return err
+		}
+		return recordActivity(tx, id, actorID, model.ActivityRestored, model.EntityGroup, id, nil, snapshotGroup(group))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.GroupResponse{
+		ID:          group.ID,
+		Name:        group.Name,
+		Description: group.Description,
+		CreatorID:   group.CreatorID,
+		Currency:    group.Currency,
+		CreatedAt:   group.CreatedAt,
+	}, nil
+}
+
+func (s *GroupService) AddMemberToGroup(groupID, userID, actorID int) (*model.GroupMemberResponse, error) {
 	isMember, err := s.memberRepo.IsMember(groupID, userID)
 	if err != nil {
 		return nil, err
@@ -158,9 +267,10 @@ func (s *GroupService) AddMemberToGroup(groupID, userID int) (*model.GroupMember
 	member := &model.GroupMember{
 		GroupID: groupID,
 		UserID:  userID,
+		Role:    model.RoleMember,
 	}
 
-	createdMember, err := s.memberRepo.AddMember(member)
+	createdMember, err := s.addMember(member, actorID)
 	if err != nil {
 		return nil, err
 	}
@@ -169,12 +279,21 @@ func (s *GroupService) AddMemberToGroup(groupID, userID int) (*model.GroupMember
 		ID:      createdMember.ID,
 		GroupID: createdMember.GroupID,
 		UserID:  createdMember.UserID,
+		Role:    createdMember.Role,
 		AddedAt: createdMember.AddedAt,
 	}, nil
 }
 
-// AddMemberToGroupByEmail adds a member by email and returns enriched response with user details
-func (s *GroupService) AddMemberToGroupByEmail(groupID int, email string) (*model.GroupMemberResponse, error) {
+// AddMemberToGroupByEmail adds a member by email and returns enriched response with user details.
+// The role defaults to member; a group has a single owner, so it cannot be given out here.
+func (s *GroupService) AddMemberToGroupByEmail(groupID int, email, role string, actorID int) (*model.GroupMemberResponse, error) {
+	if role == "" {
+		role = model.RoleMember
+	}
+	if !validRole(role) || role == model.RoleOwner {
+		return nil, fmt.Errorf("invalid role: %s", role)
+	}
+
 	// Look up user by email
 	user, err := s.userRepo.GetUserByEmail(email)
 	if err != nil {
@@ -194,26 +313,100 @@ func (s *GroupService) AddMemberToGroupByEmail(groupID int, email string) (*mode
 	member := &model.GroupMember{
 		GroupID: groupID,
 		UserID:  user.ID,
+		Role:    role,
 	}
 
-	createdMember, err := s.memberRepo.AddMember(member)
+	createdMember, err := s.addMember(member, actorID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Return enriched response with user details
 	return &model.GroupMemberResponse{
-		ID:       createdMember.ID,
-		GroupID:  createdMember.GroupID,
-		UserID:   createdMember.UserID,
-		UserName: user.Name,
-		Email:    user.Email,
-		AddedAt:  createdMember.AddedAt,
+		ID:          createdMember.ID,
+		GroupID:     createdMember.GroupID,
+		UserID:      createdMember.UserID,
+		UserName:    user.Name,
+		Email:       user.Email,
+		Placeholder: user.Placeholder,
+		Role:        createdMember.Role,
+		AddedAt:     createdMember.AddedAt,
 	}, nil
 }
 
-func (s *GroupService) RemoveMemberFromGroup(groupID, userID int) error {
-	return s.memberRepo.RemoveMember(groupID, userID)
+// addMember adds a member and records it in the activity log
+func (s *GroupService) addMember(member *model.GroupMember, actorID int) (*model.GroupMember, error) {
+	var createdMember *model.GroupMember
+	err := s.uow.Do(func(tx repository.Tx) error {
+		var err error
+		createdMember, err = tx.Members().AddMember(member)
+		if err != nil {
+			return err
+		}
+
+		return recordActivity(tx, member.GroupID, actorID, model.ActivityCreated, model.EntityMember, member.UserID, nil, snapshotMember(createdMember))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return createdMember, nil
+}
+
+// RemoveMemberFromGroup removes a member. The owner cannot be removed.
+func (s *GroupService) RemoveMemberFromGroup(groupID, userID, actorID int) error {
+	return s.uow.Do(func(tx repository.Tx) error {
+		member, err := tx.Members().GetMember(groupID, userID)
+		if err != nil {
+			return err
+		}
+		if member.Role == model.RoleOwner {
+			return fmt.Errorf("the group owner cannot be removed")
+		}
+
+		if err := tx.Members().RemoveMember(groupID, userID); err != nil {
+			return err
+		}
+
+		return recordActivity(tx, groupID, actorID, model.ActivityDeleted, model.EntityMember, userID, snapshotMember(member), nil)
+	})
+}
+
+// UpdateMemberRole changes a member's role. The owner's role cannot be changed
+// and no one else can be made owner.
+func (s *GroupService) UpdateMemberRole(groupID, userID int, role string, actorID int) (*model.GroupMemberResponse, error) {
+	if !validRole(role) || role == model.RoleOwner {
+		return nil, fmt.Errorf("invalid role: %s", role)
+	}
+
+	var updatedMember *model.GroupMember
+	err := s.uow.Do(func(tx repository.Tx) error {
+		member, err := tx.Members().GetMember(groupID, userID)
+		if err != nil {
+			return err
+		}
+		if member.Role == model.RoleOwner {
+			return fmt.Errorf("the group owner's role cannot be changed")
+		}
+
+		updatedMember, err = tx.Members().UpdateMemberRole(groupID, userID, role)
+		if err != nil {
+			return err
+		}
+
+		return recordActivity(tx, groupID, actorID, model.ActivityUpdated, model.EntityMember, userID, snapshotMember(member), snapshotMember(updatedMember))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.GroupMemberResponse{
+		ID:      updatedMember.ID,
+		GroupID: updatedMember.GroupID,
+		UserID:  updatedMember.UserID,
+		Role:    updatedMember.Role,
+		AddedAt: updatedMember.AddedAt,
+	}, nil
 }
 
 func (s *GroupService) GetGroupMembers(groupID int) ([]*model.GroupMemberResponse, error) {
@@ -0,0 +1,454 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+// ImportService records expenses in bulk from files kept outside the app
+type ImportService struct {
+	userRepo     repository.UserRepository
+	groupRepo    repository.GroupRepository
+	memberRepo   repository.GroupMemberRepository
+	categoryRepo repository.CategoryRepository
+	rateRepo     repository.ExchangeRateRepository
+	uow          repository.UnitOfWork
+}
+
+func NewImportService(
+	userRepo repository.UserRepository,
+	groupRepo repository.GroupRepository,
+	memberRepo repository.GroupMemberRepository,
+	categoryRepo repository.CategoryRepository,
+	rateRepo repository.ExchangeRateRepository,
+	uow repository.UnitOfWork,
+) *ImportService {
+	return &ImportService{
+		userRepo:     userRepo,
+		groupRepo:    groupRepo,
+		memberRepo:   memberRepo,
+		categoryRepo: categoryRepo,
+		rateRepo:     rateRepo,
+		uow:          uow,
+	}
+}
+
+// importLine is a row read from an import file, or the error that kept it from
+// being read
+type importLine struct {
+	line int
+	row  *model.ImportRow
+	err  error
+}
+
+// importedExpense is a validated row, ready to be stored
+type importedExpense struct {
+	result  *model.ImportRowResult
+	expense *model.Expense
+	splits  []*model.ExpenseSplit
+}
+
+// ImportExpenses reads expenses from a CSV or JSON lines file and validates
+// every row. Unless dryRun is set and as long as every row is valid, they are
+// all recorded in one transaction; otherwise nothing is. The response reports
+// on each row either way. An error is only returned when the file itself
+// cannot be read.
+func (s *ImportService) ImportExpenses(groupID int, format string, r io.Reader, dryRun bool, actorID int) (*model.ImportResponse, error) {
+	group, err := s.groupRepo.GetGroupByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []importLine
+	switch format {
+	case model.ImportFormatCSV:
+		lines, err = readImportCSV(r)
+	case model.ImportFormatJSONL:
+		lines, err = readImportJSONL(r)
+	default:
+		err = fmt.Errorf("format must be %s or %s", model.ImportFormatCSV, model.ImportFormatJSONL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	memberIDs, err := groupMemberIDs(s.memberRepo, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := &memberResolver{userRepo: s.userRepo, memberRepo: s.memberRepo, groupID: groupID, ids: make(map[string]int)}
+	response := &model.ImportResponse{DryRun: dryRun, Total: len(lines), Rows: []*model.ImportRowResult{}}
+	var imported []*importedExpense
+	for _, line := range lines {
+		result := &model.ImportRowResult{Line: line.line}
+		response.Rows = append(response.Rows, result)
+
+		if line.err != nil {
+			result.Errors = []string{line.err.Error()}
+			continue
+		}
+
+		expense, splits, errs := s.validateRow(group, memberIDs, members, line.row)
+		if len(errs) > 0 {
+			for _, err := range errs {
+				result.Errors = append(result.Errors, err.Error())
+			}
+			continue
+		}
+		imported = append(imported, &importedExpense{result: result, expense: expense, splits: splits})
+	}
+
+	response.Valid = len(imported)
+	response.Invalid = response.Total - response.Valid
+	if dryRun || response.Invalid > 0 || response.Valid == 0 {
+		return response, nil
+	}
+
+	err = s.uow.Do(func(tx repository.Tx) error {
+		for _, item := range imported {
+			created, err := recordExpense(tx, item.expense, item.splits, actorID)
+			if err != nil {
+				return fmt.Errorf("line %d: %v", item.result.Line, err)
+			}
+			item.result.ExpenseID = created.ID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response.Applied = true
+	return response, nil
+}
+
+// recordExpense stores a new expense with its splits, posts it to the group
+// balances and records it in the activity log
+func recordExpense(tx repository.Tx, expense *model.Expense, splits []*model.ExpenseSplit, actorID int) (*model.Expense, error) {
+	created, err := tx.Expenses().CreateExpense(expense)
+	if err != nil {
+		return nil, err
+	}
+	if err := replaceSplits(tx, created, splits); err != nil {
+		return nil, err
+	}
+	if err := postExpense(tx, created.ID, 1); err != nil {
+		return nil, err
+	}
+
+	_, snapshot, err := snapshotExpense(tx, created.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordActivity(tx, created.GroupID, actorID, model.ActivityCreated, model.EntityExpense, created.ID, nil, snapshot); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// validateRow checks a row the way CreateExpense checks a request and turns it
+// into an expense with its splits. Independent problems are all reported.
+func (s *ImportService) validateRow(
+	group *model.Group,
+	memberIDs []int,
+	members *memberResolver,
+	row *model.ImportRow,
+) (*model.Expense, []*model.ExpenseSplit, []error) {
+	var errs []error
+
+	payerID, err := members.resolve(row.PayerEmail)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("payer: %v", err))
+	}
+
+	if row.Amount <= 0 {
+		errs = append(errs, fmt.Errorf("amount must be greater than 0"))
+	}
+
+	date := time.Now()
+	if row.Date != "" {
+		if date, err = parseImportDate(row.Date); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	currency, err := money.NormalizeCurrency(row.Currency, group.Currency)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if row.CategoryID != nil {
+		if err := checkCategory(s.categoryRepo, group.ID, *row.CategoryID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	spec, err := members.splitSpec(row.Split)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("split: %v", err))
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, errs
+	}
+
+	splitMode, splits, err := computeSplits(row.Amount, spec, memberIDs)
+	if err != nil {
+		return nil, nil, []error{fmt.Errorf("split: %v", err)}
+	}
+
+	baseAmount, rate, err := convertAmount(s.rateRepo, row.Amount, currency, group.Currency, date)
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+
+	expense := &model.Expense{
+		GroupID:     group.ID,
+		PaidByID:    payerID,
+		Amount:      row.Amount,
+		Currency:    currency,
+		FXRate:      rate,
+		BaseAmount:  baseAmount,
+		SplitMode:   splitMode,
+		CategoryID:  row.CategoryID,
+		Description: row.Description,
+		CreatedAt:   date,
+	}
+
+	return expense, splits, nil
+}
+
+// memberResolver maps emails to the IDs of group members, looking each email
+// up once
+type memberResolver struct {
+	userRepo   repository.UserRepository
+	memberRepo repository.GroupMemberRepository
+	groupID    int
+	ids        map[string]int
+}
+
+func (m *memberResolver) resolve(email string) (int, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return 0, fmt.Errorf("email is required")
+	}
+	if id, ok := m.ids[email]; ok {
+		return id, nil
+	}
+
+	user, err := m.userRepo.GetUserByEmail(email)
+	if err != nil {
+		return 0, fmt.Errorf("no user with email %s", email)
+	}
+	isMember, err := m.memberRepo.IsMember(m.groupID, user.ID)
+	if err != nil {
+		return 0, err
+	}
+	if !isMember {
+		return 0, fmt.Errorf("%s is not a member of this group", email)
+	}
+
+	m.ids[email] = user.ID
+	return user.ID, nil
+}
+
+// splitSpec turns an import split into a SplitSpec by resolving its emails
+func (m *memberResolver) splitSpec(split *model.ImportSplit) (*model.SplitSpec, error) {
+	if split == nil {
+		return nil, nil
+	}
+
+	spec := &model.SplitSpec{Mode: split.Mode}
+	for _, participant := range split.Participants {
+		userID, err := m.resolve(participant.Email)
+		if err != nil {
+			return nil, err
+		}
+		spec.Participants = append(spec.Participants, model.SplitParticipant{
+			UserID:     userID,
+			Amount:     participant.Amount,
+			Percentage: participant.Percentage,
+			Shares:     participant.Shares,
+		})
+	}
+	return spec, nil
+}
+
+// parseImportDate accepts a date or an RFC 3339 time
+func parseImportDate(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("date must be a date (YYYY-MM-DD) or an RFC 3339 time")
+	}
+	return t, nil
+}
+
+// importCSVColumns are the columns an import CSV may have, in any order.
+// split lists participants as "email[:value]" separated by semicolons, where
+// value is the amount, percentage or shares, depending on split_mode.
+var importCSVColumns = []string{"payer_email", "amount", "currency", "date", "description", "category_id", "split_mode", "split"}
+
+// readImportCSV reads an import CSV. Its first line names the columns;
+// payer_email and amount are required.
+func readImportCSV(r io.Reader) ([]importLine, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !containsColumn(importCSVColumns, name) {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"payer_email", "amount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	var lines []importLine
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		if len(lines) == model.MaxImportRows {
+			return nil, fmt.Errorf("an import can hold at most %d rows", model.MaxImportRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		row, err := importRowFromCSV(field)
+		lines = append(lines, importLine{line: line, row: row, err: err})
+	}
+
+	return lines, nil
+}
+
+func importRowFromCSV(field func(name string) string) (*model.ImportRow, error) {
+	row := &model.ImportRow{
+		PayerEmail:  field("payer_email"),
+		Currency:    field("currency"),
+		Date:        field("date"),
+		Description: field("description"),
+	}
+
+	amount, err := money.ParseAmount(field("amount"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount: %v", err)
+	}
+	row.Amount = amount
+
+	if value := field("category_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid category_id")
+		}
+		row.CategoryID = &id
+	}
+
+	mode, participants := field("split_mode"), field("split")
+	if mode == "" && participants == "" {
+		return row, nil
+	}
+
+	row.Split = &model.ImportSplit{Mode: mode}
+	for _, part := range strings.Split(participants, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		email, value, hasValue := strings.Cut(part, ":")
+		participant := model.ImportSplitParticipant{Email: strings.TrimSpace(email)}
+		value = strings.TrimSpace(value)
+		if hasValue {
+			var err error
+			switch mode {
+			case model.SplitModeExact:
+				participant.Amount, err = money.ParseAmount(value)
+			case model.SplitModePercentage:
+				participant.Percentage, err = strconv.ParseFloat(value, 64)
+			case model.SplitModeShares:
+				participant.Shares, err = strconv.ParseInt(value, 10, 64)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid split value %q for %s", value, participant.Email)
+			}
+		}
+		row.Split.Participants = append(row.Split.Participants, participant)
+	}
+
+	return row, nil
+}
+
+func containsColumn(columns []string, name string) bool {
+	for _, column := range columns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// readImportJSONL reads one JSON ImportRow per line, skipping blank lines
+func readImportJSONL(r io.Reader) ([]importLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []importLine
+	for number := 1; scanner.Scan(); number++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		if len(lines) == model.MaxImportRows {
+			return nil, fmt.Errorf("an import can hold at most %d rows", model.MaxImportRows)
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		row := &model.ImportRow{}
+		if err := decoder.Decode(row); err != nil {
+			lines = append(lines, importLine{line: number, err: fmt.Errorf("invalid JSON: %v", err)})
+			continue
+		}
+		lines = append(lines, importLine{line: number, row: row})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid JSON lines: %v", err)
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repositorymem"
+)
+
+func newImportService(store *repositorymem.Store) *ImportService {
+	return NewImportService(
+		repositorymem.NewUserRepositoryMem(store),
+		repositorymem.NewGroupRepositoryMem(store),
+		repositorymem.NewGroupMemberRepositoryMem(store),
+		repositorymem.NewCategoryRepositoryMem(store),
+		repositorymem.NewExchangeRateRepositoryMem(store),
+		repositorymem.NewUnitOfWorkMem(store),
+	)
+}
+
+func TestImportExpenses(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		body    string
+		dryRun  bool
+		wantErr bool
+		// wantRowErrors is how many errors each row has
+		wantRowErrors []int
+		wantApplied   bool
+		// want is the net balances of ann, bob and cat afterwards
+		want []money.Amount
+	}{
+		{
+			name:   "csv split equally",
+			format: model.ImportFormatCSV,
+			body: "payer_email,amount,date,description\n" +
+				"ann@example.com,30.00,2026-01-02,Dinner\n" +
+				"bob@example.com,6,2026-01-03,Taxi\n",
+			wantRowErrors: []int{0, 0},
+			wantApplied:   true,
+			want:          []money.Amount{1800, -600, -1200},
+		},
+		{
+			name:   "csv with every split mode",
+			format: model.ImportFormatCSV,
+			body: "payer_email,amount,split_mode,split\n" +
+				"ann@example.com,10,exact,bob@example.com:4;cat@example.com:6\n" +
+				"ann@example.com,10,percentage,ann@example.com:50;bob@example.com:50\n" +
+				"cat@example.com,9,shares,ann@example.com:2;cat@example.com:1\n" +
+				"bob@example.com,1,equal,ann@example.com;bob@example.com;cat@example.com\n",
+			wantRowErrors: []int{0, 0, 0, 0},
+			wantApplied:   true,
+			want:          []money.Amount{866, -833, -33},
+		},
+		{
+			name:   "jsonl",
+			format: model.ImportFormatJSONL,
+			body: `{"payer_email":"cat@example.com","amount":12.00,"description":"Lunch"}` + "\n\n" +
+				`{"payer_email":"ann@example.com","amount":5,"split":{"mode":"exact","participants":[{"email":"bob@example.com","amount":5}]}}` + "\n",
+			wantRowErrors: []int{0, 0},
+			wantApplied:   true,
+			want:          []money.Amount{100, -900, 800},
+		},
+		{
+			name:   "dry run stores nothing",
+			format: model.ImportFormatCSV,
+			body: "payer_email,amount\n" +
+				"ann@example.com,30\n",
+			dryRun:        true,
+			wantRowErrors: []int{0},
+			want:          []money.Amount{0, 0, 0},
+		},
+		{
+			name:   "one invalid row stores nothing",
+			format: model.ImportFormatCSV,
+			body: "payer_email,amount,date\n" +
+				"ann@example.com,30,2026-01-02\n" +
+				"nobody@example.com,-1,yesterday\n" +
+				"bob@example.com,abc,\n",
+			wantRowErrors: []int{0, 3, 1},
+			want:          []money.Amount{0, 0, 0},
+		},
+		{
+			name:   "split that does not add up",
+			format: model.ImportFormatCSV,
+			body: "payer_email,amount,split_mode,split\n" +
+				"ann@example.com,10,exact,bob@example.com:4;cat@example.com:5\n",
+			wantRowErrors: []int{1},
+			want:          []money.Amount{0, 0, 0},
+		},
+		{
+			name:    "unknown column",
+			format:  model.ImportFormatCSV,
+			body:    "payer_email,amount,tip\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing amount column",
+			format:  model.ImportFormatCSV,
+			body:    "payer_email,description\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown format",
+			format:  "xlsx",
+			body:    "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := repositorymem.NewStore()
+			groupID, userIDs := seedGroup(t, store, "ann", "bob", "cat")
+			balanceRepo := repositorymem.NewBalanceRepositoryMem(store)
+			imports := newImportService(store)
+
+			response, err := imports.ImportExpenses(groupID, tt.format, strings.NewReader(tt.body), tt.dryRun, userIDs[0])
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ImportExpenses succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ImportExpenses failed: %v", err)
+			}
+
+			var rowErrors []int
+			for _, row := range response.Rows {
+				rowErrors = append(rowErrors, len(row.Errors))
+			}
+			if !reflect.DeepEqual(rowErrors, tt.wantRowErrors) {
+				t.Errorf("row errors = %v, want %v (%+v)", rowErrors, tt.wantRowErrors, response.Rows)
+			}
+			if response.Applied != tt.wantApplied {
+				t.Errorf("applied = %v, want %v", response.Applied, tt.wantApplied)
+			}
+
+			balances := netBalances(t, balanceRepo, groupID)
+			for i, want := range tt.want {
+				if got := balances[userIDs[i]]; got != want {
+					t.Errorf("balance of user %d = %s, want %s", userIDs[i], got, want)
+				}
+			}
+			checkLedger(t, balanceRepo, groupID)
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/mailer"
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+// InvitationService invites people to groups by email. The email carries a
+// single-use token, which the invitee uses to accept or decline.
+type InvitationService struct {
+	invitationRepo repository.InvitationRepository
+	groupRepo      repository.GroupRepository
+	userRepo       repository.UserRepository
+	memberRepo     repository.GroupMemberRepository
+	mailer         mailer.Mailer
+	uow            repository.UnitOfWork
+	// appURL is where the links in invitation emails point to
+	appURL string
+}
+
+func NewInvitationService(
+	invitationRepo repository.InvitationRepository,
+	groupRepo repository.GroupRepository,
+	userRepo repository.UserRepository,
+	memberRepo repository.GroupMemberRepository,
+	mailer mailer.Mailer,
+	uow repository.UnitOfWork,
+	appURL string,
+) *InvitationService {
+	return &InvitationService{
+		invitationRepo: invitationRepo,
+		groupRepo:      groupRepo,
+		userRepo:       userRepo,
+		memberRepo:     memberRepo,
+		mailer:         mailer,
+		uow:            uow,
+		appURL:         strings.TrimRight(appURL, "/"),
+	}
+}
+
+// CreateInvitation stores an invitation and emails its token. If the email
+// cannot be sent, the invitation is revoked.
+func (s *InvitationService) CreateInvitation(req *model.InvitationRequest, inviterID int) (*model.Invitation, error) {
+	role := req.Role
+	if role == "" {
+		role = model.RoleMember
+	}
+	if !validRole(role) || role == model.RoleOwner {
+		return nil, fmt.Errorf("invalid role: %s", role)
+	}
+
+	email := model.NormalizeEmail(req.Email)
+	if email == "" {
+		return nil, fmt.Errorf("email is required")
+	}
+
+	group, err := s.groupRepo.GetGroupByID(req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	inviter, err := s.userRepo.GetUserByID(inviterID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A placeholder member is invited to prove they own the email before
+	// registering with it, so they may already be in the group
+	if user, err := s.userRepo.GetUserByEmail(email); err == nil && !user.Placeholder {
+		isMember, err := s.memberRepo.IsMember(group.ID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if isMember {
+			return nil, fmt.Errorf("user is already a member of this group")
+		}
+	}
+
+	invitations, err := s.invitationRepo.GetInvitationsByGroupID(group.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, existing := range invitations {
+		if model.NormalizeEmail(existing.Email) == email && existing.Status == model.InvitationPending && !existing.Expired(now) {
+			return nil, fmt.Errorf("%s already has a pending invitation to this group", email)
+		}
+	}
+
+	token, err := newRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.invitationRepo.CreateInvitation(&model.Invitation{
+		GroupID:     group.ID,
+		Email:       email,
+		Role:        role,
+		InvitedByID: inviter.ID,
+		TokenHash:   hashToken(token),
+		Status:      model.InvitationPending,
+		ExpiresAt:   now.Add(model.InvitationTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mailer.Send(s.invitationEmail(invitation, group, inviter, token)); err != nil {
+		if revokeErr := s.invitationRepo.RespondToInvitation(invitation.ID, model.InvitationRevoked); revokeErr != nil {
+			return nil, fmt.Errorf("failed to send invitation: %v (and to revoke it: %v)", err, revokeErr)
+		}
+		return nil, fmt.Errorf("failed to send invitation: %v", err)
+	}
+
+	return invitation, nil
+}
+
+func (s *InvitationService) invitationEmail(invitation *model.Invitation, group *model.Group, inviter *model.User, token string) *mailer.Message {
+	link := fmt.Sprintf("%s/invitations/%s", s.appURL, token)
+	return &mailer.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("%s invited you to %s", inviter.Name, group.Name),
+		Body: fmt.Sprintf(
+			"%s invited you to join the group %q with the %s role.\n\n"+
+				"Accept or decline the invitation here:\n%s\n\n"+
+				"The invitation expires on %s.\n",
+			inviter.Name, group.Name, invitation.Role, link, invitation.ExpiresAt.UTC().Format("January 2, 2006 at 15:04 MST"),
+		),
+	}
+}
+
+// GetInvitation returns an invitation, reporting it as expired once it can no
+// longer be answered
+func (s *InvitationService) GetInvitation(id int) (*model.Invitation, error) {
+	invitation, err := s.invitationRepo.GetInvitationByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.Expired(time.Now()) {
+		invitation.Status = model.InvitationExpired
+	}
+	return invitation, nil
+}
+
+// GetGroupInvitations lists the group's invitations, newest first
+func (s *InvitationService) GetGroupInvitations(groupID int) ([]*model.Invitation, error) {
+	invitations, err := s.invitationRepo.GetInvitationsByGroupID(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, invitation := range invitations {
+		if invitation.Expired(now) {
+			invitation.Status = model.InvitationExpired
+		}
+	}
+	if invitations == nil {
+		invitations = []*model.Invitation{}
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation withdraws a pending invitation, so its token can no longer
+// be used
+func (s *InvitationService) RevokeInvitation(id int) error {
+	return s.invitationRepo.RespondToInvitation(id, model.InvitationRevoked)
+}
+
+// AcceptInvitation adds the user to the group with the invited role. Whoever
+// holds the token may accept it, with the account they are signed in with.
+func (s *InvitationService) AcceptInvitation(token string, userID int) (*model.GroupMemberResponse, error) {
+	var response *model.GroupMemberResponse
+	err := s.uow.Do(func(tx repository.Tx) error {
+		invitation, err := pendingInvitation(tx.Invitations(), token)
+		if err != nil {
+			return err
+		}
+
+		user, err := tx.Users().GetUserByID(userID)
+		if err != nil {
+			return err
+		}
+		isMember, err := tx.Members().IsMember(invitation.GroupID, userID)
+		if err != nil {
+			return err
+		}
+		if isMember {
+			return fmt.Errorf("user is already a member of this group")
+		}
+
+		member, err := joinInvitedGroup(tx, invitation, userID)
+		if err != nil {
+			return err
+		}
+
+		response = &model.GroupMemberResponse{
+			ID:       member.ID,
+			GroupID:  member.GroupID,
+			UserID:   member.UserID,
+			UserName: user.Name,
+			Email:    user.Email,
+			Role:     member.Role,
+			AddedAt:  member.AddedAt,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// joinInvitedGroup adds the user to the invitation's group with the invited
+// role and marks the invitation accepted
+func joinInvitedGroup(tx repository.Tx, invitation *model.Invitation, userID int) (*model.GroupMember, error) {
+	// The group may have been deleted since the invitation was sent
+	if _, err := tx.Groups().GetGroupByID(invitation.GroupID); err != nil {
+		return nil, err
+	}
+
+	member, err := tx.Members().AddMember(&model.GroupMember{
+		GroupID: invitation.GroupID,
+		UserID:  userID,
+		Role:    invitation.Role,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Invitations().RespondToInvitation(invitation.ID, model.InvitationAccepted); err != nil {
+		return nil, err
+	}
+	err = recordActivity(tx, member.GroupID, userID, model.ActivityCreated, model.EntityMember, userID, nil, snapshotMember(member))
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// DeclineInvitation turns an invitation down. It needs no account, only the
+// token.
+func (s *InvitationService) DeclineInvitation(token string) error {
+	invitation, err := pendingInvitation(s.invitationRepo, token)
+	if err != nil {
+		return err
+	}
+	return s.invitationRepo.RespondToInvitation(invitation.ID, model.InvitationDeclined)
+}
+
+// pendingInvitation finds the invitation of a token, as long as it can still
+// be answered
+func pendingInvitation(invitationRepo repository.InvitationRepository, token string) (*model.Invitation, error) {
+	invitation, err := invitationRepo.GetInvitationByTokenHash(hashToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("invitation not found")
+	}
+	if invitation.Status != model.InvitationPending {
+		return nil, fmt.Errorf("invitation has already been %s", invitation.Status)
+	}
+	if invitation.Expired(time.Now()) {
+		return nil, fmt.Errorf("invitation has expired")
+	}
+	return invitation, nil
+}
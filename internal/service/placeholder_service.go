@@ -0,0 +1,234 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+// PlaceholderService manages members who have no account yet. Placeholders are
+// users who cannot log in; they pay and share expenses like anyone else until
+// they register with their email or are claimed by a registered user.
+type PlaceholderService struct {
+	userRepo   repository.UserRepository
+	groupRepo  repository.GroupRepository
+	memberRepo repository.GroupMemberRepository
+	uow        repository.UnitOfWork
+}
+
+func NewPlaceholderService(
+	userRepo repository.UserRepository,
+	groupRepo repository.GroupRepository,
+	memberRepo repository.GroupMemberRepository,
+	uow repository.UnitOfWork,
+) *PlaceholderService {
+	return &PlaceholderService{userRepo: userRepo, groupRepo: groupRepo, memberRepo: memberRepo, uow: uow}
+}
+
+// AddPlaceholderMember adds someone without an account to the group. An email
+// that already belongs to a user is refused: that user is added as a member
+// instead.
+func (s *PlaceholderService) AddPlaceholderMember(req *model.PlaceholderRequest, actorID int) (*model.GroupMemberResponse, error) {
+	if _, err := s.groupRepo.GetGroupByID(req.GroupID); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	email := model.NormalizeEmail(req.Email)
+	if email != "" {
+		if _, err := s.userRepo.GetUserByEmail(email); err == nil {
+			return nil, fmt.Errorf("%s already has an account, add them as a member instead", email)
+		}
+	}
+
+	var response *model.GroupMemberResponse
+	err := s.uow.Do(func(tx repository.Tx) error {
+		user, member, err := createPlaceholder(tx, req.GroupID, name, email, actorID)
+		if err != nil {
+			return err
+		}
+
+		response = &model.GroupMemberResponse{
+			ID:          member.ID,
+			GroupID:     member.GroupID,
+			UserID:      user.ID,
+			UserName:    user.Name,
+			Email:       user.Email,
+			Placeholder: true,
+			Role:        member.Role,
+			AddedAt:     member.AddedAt,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetPlaceholder returns a placeholder user
+func (s *PlaceholderService) GetPlaceholder(id int) (*model.User, error) {
+	user, err := s.userRepo.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Placeholder {
+		return nil, fmt.Errorf("user %d is not a placeholder", id)
+	}
+	return user, nil
+}
+
+// ClaimPlaceholder merges a placeholder into the registered user claiming it:
+// the expenses, splits, settlements and group memberships of the placeholder
+// become the user's, and the balances of its groups are rebuilt from the ledger.
+// Payments between the placeholder and the user are soft-deleted.
+func (s *PlaceholderService) ClaimPlaceholder(placeholderID, userID int) (*model.UserResponse, error) {
+	if _, err := s.GetPlaceholder(placeholderID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Placeholder {
+		return nil, fmt.Errorf("a placeholder can only be claimed by a registered user")
+	}
+
+	memberships, err := s.memberRepo.GetUserGroups(placeholderID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.uow.Do(func(tx repository.Tx) error {
+		groupIDs, err := s.releaseLedger(tx, placeholderID, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Users().MergeUser(placeholderID, userID); err != nil {
+			return err
+		}
+		for groupID := range groupIDs {
+			if err := tx.Balances().CalculateBalances(groupID); err != nil {
+				return fmt.Errorf("failed to rebuild balances of group %d: %v", groupID, err)
+			}
+		}
+
+		for _, membership := range memberships {
+			member, err := tx.Members().GetMember(membership.GroupID, userID)
+			if err != nil {
+				return err
+			}
+			err = recordActivity(tx, membership.GroupID, userID, model.ActivityUpdated, model.EntityMember, userID,
+				&memberSnapshot{UserID: placeholderID, Role: membership.Role}, snapshotMember(member))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UserResponse{
+		ID:        user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		CreatedAt: user.CreatedAt,
+	}, nil
+}
+
+// releaseLedger prepares the ledger of a placeholder for merging into userID.
+// Payments between the two would become payments to oneself, so they are
+// soft-deleted and logged. It returns the groups the placeholder has ledger
+// rows or a membership in: only the balances of those change with the merge.
+func (s *PlaceholderService) releaseLedger(tx repository.Tx, placeholderID, userID int) (map[int]bool, error) {
+	groupIDs := make(map[int]bool)
+
+	memberships, err := tx.Members().GetUserGroups(placeholderID)
+	if err != nil {
+		return nil, err
+	}
+	for _, membership := range memberships {
+		groupIDs[membership.GroupID] = true
+	}
+
+	expenses, err := tx.Expenses().GetExpensesByUserID(placeholderID)
+	if err != nil {
+		return nil, err
+	}
+	for _, expense := range expenses {
+		groupIDs[expense.GroupID] = true
+	}
+
+	splits, err := tx.Splits().GetSplitsByUserID(placeholderID)
+	if err != nil {
+		return nil, err
+	}
+	for _, split := range splits {
+		expense, err := tx.Expenses().GetExpenseByID(split.ExpenseID)
+		if err != nil {
+			return nil, err
+		}
+		groupIDs[expense.GroupID] = true
+	}
+
+	settlements, err := tx.Settlements().GetSettlementsByUserID(placeholderID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, settlement := range settlements {
+		groupIDs[settlement.GroupID] = true
+		if settlement.FromUserID != userID && settlement.ToUserID != userID {
+			continue
+		}
+
+		if err := tx.Settlements().DeleteSettlement(settlement.ID, now); err != nil {
+			return nil, err
+		}
+		err := recordActivity(tx, settlement.GroupID, userID, model.ActivityDeleted, model.EntitySettlement, settlement.ID, snapshotSettlement(settlement), nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return groupIDs, nil
+}
+
+// createPlaceholder creates a placeholder user and adds them to the group. A
+// placeholder without an email gets a unique unroutable one, which nobody can
+// register with.
+func createPlaceholder(tx repository.Tx, groupID int, name, email string, actorID int) (*model.User, *model.GroupMember, error) {
+	if email == "" {
+		token, err := newRandomToken()
+		if err != nil {
+			return nil, nil, err
+		}
+		email = fmt.Sprintf("placeholder-%s@placeholder.invalid", strings.ToLower(token[:16]))
+	}
+
+	user, err := tx.Users().CreateUser(&model.User{Name: name, Email: email, Placeholder: true})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	member, err := tx.Members().AddMember(&model.GroupMember{GroupID: groupID, UserID: user.ID, Role: model.RoleMember})
+	if err != nil {
+		return nil, nil, err
+	}
+	err = recordActivity(tx, groupID, actorID, model.ActivityCreated, model.EntityMember, user.ID, nil, snapshotMember(member))
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, member, nil
+}
@@ -0,0 +1,417 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+	"github.com/shreyansh/expense-go-collab-backend/internal/recurrence"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+// dueBatchSize is how many due templates the scheduler picks up per run
+const dueBatchSize = 100
+
+// errTemplateChanged aborts creating an occurrence when the template changed
+// since it was read, typically because another server created it first
+var errTemplateChanged = errors.New("recurring expense changed while creating an occurrence")
+
+// RecurringExpenseService manages recurring expense templates and creates their
+// occurrences as ordinary expenses through the expense service. Each occurrence
+// is created in the same transaction that advances the template, with the
+// template's row locked, so it is created once even when several servers run
+// the scheduler.
+type RecurringExpenseService struct {
+	recurringRepo  repository.RecurringExpenseRepository
+	groupRepo      repository.GroupRepository
+	memberRepo     repository.GroupMemberRepository
+	categoryRepo   repository.CategoryRepository
+	expenseService *ExpenseService
+	uow            repository.UnitOfWork
+}
+
+func NewRecurringExpenseService(
+	recurringRepo repository.RecurringExpenseRepository,
+	groupRepo repository.GroupRepository,
+	memberRepo repository.GroupMemberRepository,
+	categoryRepo repository.CategoryRepository,
+	expenseService *ExpenseService,
+	uow repository.UnitOfWork,
+) *RecurringExpenseService {
+	return &RecurringExpenseService{
+		recurringRepo:  recurringRepo,
+		groupRepo:      groupRepo,
+		memberRepo:     memberRepo,
+		categoryRepo:   categoryRepo,
+		expenseService: expenseService,
+		uow:            uow,
+	}
+}
+
+// CreateRecurringExpense stores a template. Its first occurrence is on the
+// start date, so a start date in the past creates the missed occurrences on the
+// scheduler's next run.
+func (s *RecurringExpenseService) CreateRecurringExpense(req *model.RecurringExpenseRequest, userID int) (*model.RecurringExpense, error) {
+	recurring := &model.RecurringExpense{GroupID: req.GroupID, CreatedByID: userID}
+	if err := s.applyRequest(recurring, req); err != nil {
+		return nil, err
+	}
+
+	first, ok, err := ruleOf(recurring).First()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("schedule has no occurrences")
+	}
+	recurring.NextRunAt = &first
+
+	return s.recurringRepo.CreateRecurringExpense(recurring)
+}
+
+func (s *RecurringExpenseService) GetRecurringExpense(id int) (*model.RecurringExpense, error) {
+	return s.recurringRepo.GetRecurringExpenseByID(id)
+}
+
+func (s *RecurringExpenseService) GetGroupRecurringExpenses(groupID int) ([]*model.RecurringExpense, error) {
+	return s.recurringRepo.GetRecurringExpensesByGroupID(groupID)
+}
+
+// UpdateRecurringExpense replaces the template of future occurrences. When the
+// schedule changes, the next occurrence is the first one from now on; past
+// occurrences of the new schedule are not created.
+func (s *RecurringExpenseService) UpdateRecurringExpense(id int, req *model.RecurringExpenseRequest) (*model.RecurringExpense, error) {
+	existing, err := s.recurringRepo.GetRecurringExpenseByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a start date the schedule keeps its own
+	if req.StartDate == nil {
+		edited := *req
+		edited.StartDate = &existing.StartDate
+		req = &edited
+	}
+
+	updated := &model.RecurringExpense{GroupID: existing.GroupID}
+	if err := s.applyRequest(updated, req); err != nil {
+		return nil, err
+	}
+
+	return s.modify(id, func(recurring *model.RecurringExpense) error {
+		scheduleChanged := updated.Frequency != recurring.Frequency ||
+			updated.Interval != recurring.Interval ||
+			updated.Cron != recurring.Cron ||
+			!updated.StartDate.Equal(recurring.StartDate) ||
+			!sameTime(updated.EndDate, recurring.EndDate)
+
+		recurring.PaidByID = updated.PaidByID
+		recurring.Amount = updated.Amount
+		recurring.Currency = updated.Currency
+		recurring.CategoryID = updated.CategoryID
+		recurring.Description = updated.Description
+		recurring.Split = updated.Split
+		recurring.Frequency = updated.Frequency
+		recurring.Interval = updated.Interval
+		recurring.Cron = updated.Cron
+		recurring.StartDate = updated.StartDate
+		recurring.EndDate = updated.EndDate
+
+		if scheduleChanged {
+			return rescheduleFromNow(recurring)
+		}
+		return nil
+	})
+}
+
+func (s *RecurringExpenseService) DeleteRecurringExpense(id int) error {
+	return s.recurringRepo.DeleteRecurringExpense(id)
+}
+
+// PauseRecurringExpense stops occurrences from being created until the
+// template is resumed
+func (s *RecurringExpenseService) PauseRecurringExpense(id int) (*model.RecurringExpense, error) {
+	return s.modify(id, func(recurring *model.RecurringExpense) error {
+		recurring.Paused = true
+		return nil
+	})
+}
+
+// ResumeRecurringExpense restarts a paused template. Occurrences that fell due
+// while it was paused are not created; an upcoming one, skipped to or not, is
+// kept.
+func (s *RecurringExpenseService) ResumeRecurringExpense(id int) (*model.RecurringExpense, error) {
+	return s.modify(id, func(recurring *model.RecurringExpense) error {
+		if !recurring.Paused {
+			return fmt.Errorf("recurring expense is not paused")
+		}
+		recurring.Paused = false
+		recurring.LastError = ""
+		if recurring.NextRunAt == nil || recurring.NextRunAt.After(time.Now()) {
+			return nil
+		}
+		return rescheduleFromNow(recurring)
+	})
+}
+
+// SkipRecurringExpense moves the template past its next occurrence without
+// creating it
+func (s *RecurringExpenseService) SkipRecurringExpense(id int) (*model.RecurringExpense, error) {
+	return s.modify(id, func(recurring *model.RecurringExpense) error {
+		if recurring.NextRunAt == nil {
+			return fmt.Errorf("recurring expense has no upcoming occurrence")
+		}
+		return advance(recurring, *recurring.NextRunAt)
+	})
+}
+
+// RunDue creates every occurrence due at now, catching up on the ones missed
+// while no scheduler was running, and returns how many expenses it created.
+// A template whose occurrence cannot be created is paused with the error.
+func (s *RecurringExpenseService) RunDue(now time.Time) (int, error) {
+	due, err := s.recurringRepo.GetDueRecurringExpenses(now, dueBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, recurring := range due {
+		for recurring.NextRunAt != nil && !recurring.NextRunAt.After(now) {
+			next, err := s.createOccurrence(recurring)
+			if errors.Is(err, errTemplateChanged) {
+				break
+			}
+			if err != nil {
+				log.Printf("Error creating occurrence of recurring expense %d: %v", recurring.ID, err)
+				s.pauseFailed(recurring, err)
+				break
+			}
+			created++
+			recurring = next
+		}
+	}
+
+	return created, nil
+}
+
+// RunScheduler calls RunDue every interval. It never returns, so it runs in its
+// own goroutine.
+func (s *RecurringExpenseService) RunScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		created, err := s.RunDue(time.Now())
+		if err != nil {
+			log.Printf("Error running recurring expenses: %v", err)
+		} else if created > 0 {
+			log.Printf("Created %d recurring expenses", created)
+		}
+		<-ticker.C
+	}
+}
+
+// createOccurrence creates the expense of the template's next occurrence and
+// advances the template past it, returning the advanced template. It fails
+// with errTemplateChanged when the template was changed or locked by someone
+// else since it was read.
+func (s *RecurringExpenseService) createOccurrence(recurring *model.RecurringExpense) (*model.RecurringExpense, error) {
+	occurrence := *recurring.NextRunAt
+	req := &model.ExpenseRequest{
+		GroupID:     recurring.GroupID,
+		PaidByID:    recurring.PaidByID,
+		Amount:      recurring.Amount,
+		Currency:    recurring.Currency,
+		CategoryID:  recurring.CategoryID,
+		Description: recurring.Description,
+		Split:       recurring.Split,
+	}
+
+	// The occurrence is the server's doing, so it has no actor in the activity log
+	var advanced *model.RecurringExpense
+	_, err := s.expenseService.createExpense(req, occurrence, 0, func(tx repository.Tx) error {
+		locked, err := tx.RecurringExpenses().LockRecurringExpense(recurring.ID)
+		if err != nil {
+			return err
+		}
+		if locked == nil || locked.Paused || !locked.UpdatedAt.Equal(recurring.UpdatedAt) {
+			return errTemplateChanged
+		}
+
+		if err := advance(locked, occurrence); err != nil {
+			return err
+		}
+		locked.LastRunAt = &occurrence
+		advanced, err = tx.RecurringExpenses().UpdateRecurringExpense(locked)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return advanced, nil
+}
+
+// pauseFailed pauses a template whose occurrence could not be created, unless
+// it changed in the meantime
+func (s *RecurringExpenseService) pauseFailed(recurring *model.RecurringExpense, cause error) {
+	err := s.uow.Do(func(tx repository.Tx) error {
+		locked, err := tx.RecurringExpenses().LockRecurringExpense(recurring.ID)
+		if err != nil || locked == nil || !locked.UpdatedAt.Equal(recurring.UpdatedAt) {
+			return err
+		}
+
+		locked.Paused = true
+		locked.LastError = cause.Error()
+		_, err = tx.RecurringExpenses().UpdateRecurringExpense(locked)
+		return err
+	})
+	if err != nil {
+		log.Printf("Error pausing recurring expense %d: %v", recurring.ID, err)
+	}
+}
+
+// modify applies fn to the template with its row locked, so that it cannot
+// interleave with the scheduler creating an occurrence
+func (s *RecurringExpenseService) modify(id int, fn func(recurring *model.RecurringExpense) error) (*model.RecurringExpense, error) {
+	var updated *model.RecurringExpense
+	err := s.uow.Do(func(tx repository.Tx) error {
+		recurring, err := tx.RecurringExpenses().LockRecurringExpense(id)
+		if err != nil {
+			return err
+		}
+		if recurring == nil {
+			return fmt.Errorf("recurring expense is being updated, try again")
+		}
+
+		if err := fn(recurring); err != nil {
+			return err
+		}
+		updated, err = tx.RecurringExpenses().UpdateRecurringExpense(recurring)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// applyRequest validates req against the template's group and copies it into
+// the template, leaving its schedule state alone
+func (s *RecurringExpenseService) applyRequest(recurring *model.RecurringExpense, req *model.RecurringExpenseRequest) error {
+	if req.Amount <= 0 {
+		return fmt.Errorf("amount must be greater than 0")
+	}
+
+	group, err := s.groupRepo.GetGroupByID(recurring.GroupID)
+	if err != nil {
+		return err
+	}
+
+	memberIDs, err := groupMemberIDs(s.memberRepo, recurring.GroupID)
+	if err != nil {
+		return err
+	}
+	if !containsUser(memberIDs, req.PaidByID) {
+		return fmt.Errorf("payer is not a member of this group")
+	}
+
+	var categoryID *int
+	if req.CategoryID != nil && *req.CategoryID != 0 {
+		if err := checkCategory(s.categoryRepo, recurring.GroupID, *req.CategoryID); err != nil {
+			return err
+		}
+		categoryID = req.CategoryID
+	}
+
+	// The split is computed again for each occurrence; this only checks it
+	if _, _, err := computeSplits(req.Amount, req.Split, memberIDs); err != nil {
+		return err
+	}
+
+	currency, err := money.NormalizeCurrency(req.Currency, group.Currency)
+	if err != nil {
+		return err
+	}
+
+	interval := req.Interval
+	if interval == 0 {
+		interval = 1
+	}
+	start := time.Now()
+	if req.StartDate != nil {
+		start = *req.StartDate
+	}
+	// Occurrences are compared after a round trip through the database, which
+	// keeps microseconds at best
+	start = start.UTC().Truncate(time.Second)
+	var end *time.Time
+	if req.EndDate != nil {
+		e := req.EndDate.UTC().Truncate(time.Second)
+		end = &e
+	}
+
+	recurring.PaidByID = req.PaidByID
+	recurring.Amount = req.Amount
+	recurring.Currency = currency
+	recurring.CategoryID = categoryID
+	recurring.Description = req.Description
+	recurring.Split = req.Split
+	recurring.Frequency = req.Frequency
+	recurring.Interval = interval
+	recurring.Cron = ""
+	recurring.StartDate = start
+	recurring.EndDate = end
+	if req.Frequency == recurrence.Cron {
+		recurring.Interval = 1
+		recurring.Cron = req.Cron
+	}
+
+	return ruleOf(recurring).Validate()
+}
+
+func ruleOf(recurring *model.RecurringExpense) *recurrence.Rule {
+	return &recurrence.Rule{
+		Frequency: recurring.Frequency,
+		Interval:  recurring.Interval,
+		Cron:      recurring.Cron,
+		Start:     recurring.StartDate,
+		End:       recurring.EndDate,
+	}
+}
+
+// advance moves the template's next run to its first occurrence after after,
+// or clears it when there is none
+func advance(recurring *model.RecurringExpense, after time.Time) error {
+	next, ok, err := ruleOf(recurring).Next(after)
+	if err != nil {
+		return err
+	}
+
+	recurring.NextRunAt = nil
+	if ok {
+		recurring.NextRunAt = &next
+	}
+	return nil
+}
+
+// rescheduleFromNow moves the template's next run to its first occurrence from
+// now on that is after its last run
+func rescheduleFromNow(recurring *model.RecurringExpense) error {
+	from := time.Now().UTC().Add(-time.Nanosecond)
+	if recurring.LastRunAt != nil && recurring.LastRunAt.After(from) {
+		from = *recurring.LastRunAt
+	}
+	return advance(recurring, from)
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
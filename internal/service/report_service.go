@@ -0,0 +1,83 @@
+package service
+
+import (
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+// ReportService builds spending reports. Every section is aggregated by the
+// repository; the service only decides which sections a report has.
+type ReportService struct {
+	reportRepo repository.ReportRepository
+	groupRepo  repository.GroupRepository
+	userRepo   repository.UserRepository
+}
+
+func NewReportService(reportRepo repository.ReportRepository, groupRepo repository.GroupRepository, userRepo repository.UserRepository) *ReportService {
+	return &ReportService{reportRepo: reportRepo, groupRepo: groupRepo, userRepo: userRepo}
+}
+
+// reportSection is a part of a report and the dimensions it is grouped by
+type reportSection struct {
+	entries    *[]*model.ReportEntry
+	dimensions []string
+}
+
+// GetGroupReport reports what the group spent per period, per member, per
+// member and period, and per category
+func (s *ReportService) GetGroupReport(groupID int, query *model.ReportQuery) (*model.ReportResponse, error) {
+	if _, err := s.groupRepo.GetGroupByID(groupID); err != nil {
+		return nil, err
+	}
+
+	query.GroupID = groupID
+	report := &model.ReportResponse{GroupID: groupID, Bucket: query.Bucket, From: query.From, To: query.To}
+	err := s.fill(query, []reportSection{
+		{&report.Totals, nil},
+		{&report.ByPeriod, []string{model.ReportByPeriod}},
+		{&report.ByCategory, []string{model.ReportByCategory}},
+		{&report.ByUser, []string{model.ReportByUser}},
+		{&report.ByUserAndPeriod, []string{model.ReportByUser, model.ReportByPeriod}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// GetUserReport reports what the user paid and consumed across their groups,
+// per period, per group and per category
+func (s *ReportService) GetUserReport(userID int, query *model.ReportQuery) (*model.ReportResponse, error) {
+	if _, err := s.userRepo.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+
+	query.UserID = userID
+	report := &model.ReportResponse{UserID: userID, Bucket: query.Bucket, From: query.From, To: query.To}
+	err := s.fill(query, []reportSection{
+		{&report.Totals, nil},
+		{&report.ByPeriod, []string{model.ReportByPeriod}},
+		{&report.ByCategory, []string{model.ReportByCategory}},
+		{&report.ByGroup, []string{model.ReportByGroup}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (s *ReportService) fill(query *model.ReportQuery, sections []reportSection) error {
+	for _, section := range sections {
+		entries, err := s.reportRepo.Aggregate(query, section.dimensions)
+		if err != nil {
+			return err
+		}
+		if entries == nil {
+			entries = []*model.ReportEntry{}
+		}
+		*section.entries = entries
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/blobstore"
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+// RetentionService purges soft-deleted groups, expenses and settlements once
+// they have been deleted for longer than the retention period, after which
+// they can no longer be restored. The files attached to purged expenses are
+// removed from the blob store as well.
+type RetentionService struct {
+	groupRepo      repository.GroupRepository
+	expenseRepo    repository.ExpenseRepository
+	settlementRepo repository.SettlementRepository
+	attachmentRepo repository.AttachmentRepository
+	store          blobstore.Store
+	retention      time.Duration
+}
+
+func NewRetentionService(
+	groupRepo repository.GroupRepository,
+	expenseRepo repository.ExpenseRepository,
+	settlementRepo repository.SettlementRepository,
+	attachmentRepo repository.AttachmentRepository,
+	store blobstore.Store,
+	retention time.Duration,
+) *RetentionService {
+	return &RetentionService{
+		groupRepo:      groupRepo,
+		expenseRepo:    expenseRepo,
+		settlementRepo: settlementRepo,
+		attachmentRepo: attachmentRepo,
+		store:          store,
+		retention:      retention,
+	}
+}
+
+// PurgeExpired removes the rows deleted before now minus the retention period
+// and returns how many were removed. Groups go first, taking along everything
+// that belonged to them. The blobs of the purged attachments are deleted
+// afterwards; failing to delete one only leaves it behind, so it is logged.
+func (s *RetentionService) PurgeExpired(now time.Time) (int64, error) {
+	before := now.Add(-s.retention)
+
+	// The rows hold the storage keys, so they are read before they go
+	attachments, err := s.attachmentRepo.GetExpiredAttachments(before)
+	if err != nil {
+		return 0, err
+	}
+
+	groups, err := s.groupRepo.PurgeDeletedGroups(before)
+	if err != nil {
+		return 0, err
+	}
+	expenses, err := s.expenseRepo.PurgeDeletedExpenses(before)
+	s.deletePurgedBlobs(attachments)
+	if err != nil {
+		return groups, err
+	}
+	settlements, err := s.settlementRepo.PurgeDeletedSettlements(before)
+	if err != nil {
+		return groups + expenses, err
+	}
+
+	return groups + expenses + settlements, nil
+}
+
+// deletePurgedBlobs deletes the blobs of the attachments that are gone.
+// An expense restored since its attachments were read was not purged, so
+// each expense is checked again before its blobs are deleted.
+func (s *RetentionService) deletePurgedBlobs(attachments []*model.Attachment) {
+	remaining := make(map[int]map[int]bool)
+	for _, attachment := range attachments {
+		kept, ok := remaining[attachment.ExpenseID]
+		if !ok {
+			current, err := s.attachmentRepo.GetAttachmentsByExpenseID(attachment.ExpenseID)
+			if err != nil {
+				log.Printf("Error checking attachments of purged expense %d: %v", attachment.ExpenseID, err)
+				continue
+			}
+			kept = make(map[int]bool)
+			for _, a := range current {
+				kept[a.ID] = true
+			}
+			remaining[attachment.ExpenseID] = kept
+		}
+		if !kept[attachment.ID] {
+			deleteBlobs(s.store, attachment)
+		}
+	}
+}
+
+// RunRetention calls PurgeExpired every interval. It never returns, so it runs
+// in its own goroutine.
+func (s *RetentionService) RunRetention(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.PurgeExpired(time.Now())
+		if err != nil {
+			log.Printf("Error purging deleted rows: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d deleted groups, expenses and settlements", purged)
+		}
+		<-ticker.C
+	}
+}
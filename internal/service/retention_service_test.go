@@ -0,0 +1,90 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/blobstore"
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repositorymem"
+)
+
+// TestPurgeExpired checks that purging an expense deletes the files attached
+// to it, and only those
+func TestPurgeExpired(t *testing.T) {
+	tests := []struct {
+		name       string
+		after      time.Duration
+		wantPurged int64
+		// wantBlobs is whether the receipts of the deleted expense and of the
+		// live one are still stored
+		wantBlobs [2]bool
+	}{
+		{name: "within the retention period", after: 0, wantPurged: 0, wantBlobs: [2]bool{true, true}},
+		{name: "past the retention period", after: 2 * time.Hour, wantPurged: 1, wantBlobs: [2]bool{false, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := repositorymem.NewStore()
+			groupID, userIDs := seedGroup(t, store, "ann", "bob")
+			ann := userIDs[0]
+			expenses := newExpenseService(store)
+
+			attachmentRepo := repositorymem.NewAttachmentRepositoryMem(store)
+			expenseRepo := repositorymem.NewExpenseRepositoryMem(store)
+			blobs := blobstore.NewLocalStore(t.TempDir())
+			attachments := NewAttachmentService(attachmentRepo, expenseRepo, blobs, 1<<20)
+			retention := NewRetentionService(
+				repositorymem.NewGroupRepositoryMem(store),
+				expenseRepo,
+				repositorymem.NewSettlementRepositoryMem(store),
+				attachmentRepo,
+				blobs,
+				time.Hour,
+			)
+
+			var keys []string
+			for i := 0; i < 2; i++ {
+				expense, err := expenses.CreateExpense(&model.ExpenseRequest{GroupID: groupID, PaidByID: ann, Amount: 1000}, ann)
+				if err != nil {
+					t.Fatal(err)
+				}
+				uploaded, err := attachments.UploadAttachment(expense.ID, ann, "receipt.pdf", strings.NewReader("%PDF-1.4\nreceipt"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				attachment, err := attachmentRepo.GetAttachmentByID(uploaded.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				keys = append(keys, attachment.StorageKey)
+
+				// Only the first expense is deleted
+				if i == 0 {
+					if err := expenses.DeleteExpense(expense.ID, ann); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			purged, err := retention.PurgeExpired(time.Now().Add(tt.after))
+			if err != nil {
+				t.Fatalf("PurgeExpired failed: %v", err)
+			}
+			if purged != tt.wantPurged {
+				t.Errorf("purged %d, want %d", purged, tt.wantPurged)
+			}
+			for i, key := range keys {
+				blob, err := blobs.Get(key)
+				if err == nil {
+					blob.Close()
+				}
+				if got := err == nil; got != tt.wantBlobs[i] {
+					t.Errorf("blob %s exists = %v, want %v", key, got, tt.wantBlobs[i])
+				}
+			}
+		})
+	}
+}
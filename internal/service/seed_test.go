@@ -0,0 +1,43 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repositorymem"
+)
+
+// seedGroup stores a user for each name and a USD group owned by the first of
+// them, with the others as members. It returns the group ID and the user IDs
+// in the order of names.
+func seedGroup(t *testing.T, store *repositorymem.Store, names ...string) (int, []int) {
+	t.Helper()
+
+	users := repositorymem.NewUserRepositoryMem(store)
+	var userIDs []int
+	for _, name := range names {
+		user, err := users.CreateUser(&model.User{Email: fmt.Sprintf("%s@example.com", name), Name: name})
+		if err != nil {
+			t.Fatalf("creating user %s: %v", name, err)
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+
+	group, err := repositorymem.NewGroupRepositoryMem(store).CreateGroup(&model.Group{Name: "Trip", CreatorID: userIDs[0], Currency: "USD"})
+	if err != nil {
+		t.Fatalf("creating group: %v", err)
+	}
+	members := repositorymem.NewGroupMemberRepositoryMem(store)
+	for i, userID := range userIDs {
+		role := model.RoleMember
+		if i == 0 {
+			role = model.RoleOwner
+		}
+		if _, err := members.AddMember(&model.GroupMember{GroupID: group.ID, UserID: userID, Role: role}); err != nil {
+			t.Fatalf("adding user %d: %v", userID, err)
+		}
+	}
+
+	return group.ID, userIDs
+}
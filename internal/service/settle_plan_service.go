@@ -0,0 +1,127 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+type SettlePlanService struct {
+	balanceRepo repository.BalanceRepository
+	groupRepo   repository.GroupRepository
+}
+
+func NewSettlePlanService(balanceRepo repository.BalanceRepository, groupRepo repository.GroupRepository) *SettlePlanService {
+	return &SettlePlanService{balanceRepo: balanceRepo, groupRepo: groupRepo}
+}
+
+// GetSettlePlan returns the transfers needed to zero every balance in the group.
+// When simplify is true the debts are netted across the whole group so that as few
+// transfers as possible are made; otherwise the original pairwise debts are kept.
+func (s *SettlePlanService) GetSettlePlan(groupID int, simplify bool) (*model.SettlePlan, error) {
+	group, err := s.groupRepo.GetGroupByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var settlements []model.SettlementRequest
+
+	if simplify {
+		balances, err := s.balanceRepo.GetGroupBalances(groupID)
+		if err != nil {
+			return nil, err
+		}
+		settlements = simplifyDebts(groupID, balances)
+	} else {
+		pairs, err := s.balanceRepo.GetPairwiseBalances(groupID)
+		if err != nil {
+			return nil, err
+		}
+		for _, pair := range pairs {
+			settlements = append(settlements, model.SettlementRequest{
+				GroupID:    groupID,
+				FromUserID: pair.FromUserID,
+				ToUserID:   pair.ToUserID,
+				Amount:     pair.Amount,
+			})
+		}
+	}
+
+	if settlements == nil {
+		settlements = []model.SettlementRequest{}
+	}
+
+	// Balances are kept in the group currency, so the transfers are too
+	for i := range settlements {
+		settlements[i].Currency = group.Currency
+	}
+
+	return &model.SettlePlan{
+		GroupID:     groupID,
+		Simplified:  simplify,
+		Currency:    group.Currency,
+		Settlements: settlements,
+	}, nil
+}
+
+type netPosition struct {
+	userID int
+	amount money.Amount
+}
+
+// simplifyDebts greedily matches the largest debtor with the largest creditor until
+// every net balance is settled. This yields at most n-1 transfers for n users.
+func simplifyDebts(groupID int, balances map[int]money.Amount) []model.SettlementRequest {
+	var debtors, creditors []*netPosition
+	for userID, amount := range balances {
+		if amount < 0 {
+			debtors = append(debtors, &netPosition{userID: userID, amount: -amount})
+		} else if amount > 0 {
+			creditors = append(creditors, &netPosition{userID: userID, amount: amount})
+		}
+	}
+
+	sortPositions(debtors)
+	sortPositions(creditors)
+
+	var settlements []model.SettlementRequest
+	i, j := 0, 0
+	for i < len(debtors) && j < len(creditors) {
+		debtor, creditor := debtors[i], creditors[j]
+
+		amount := debtor.amount
+		if creditor.amount < amount {
+			amount = creditor.amount
+		}
+		settlements = append(settlements, model.SettlementRequest{
+			GroupID:    groupID,
+			FromUserID: debtor.userID,
+			ToUserID:   creditor.userID,
+			Amount:     amount,
+		})
+
+		debtor.amount -= amount
+		creditor.amount -= amount
+		if debtor.amount == 0 {
+			i++
+		}
+		if creditor.amount == 0 {
+			j++
+		}
+	}
+
+	return settlements
+}
+
+// sortPositions orders positions by amount descending, breaking ties by user ID so
+// that the same balances always produce the same plan
+func sortPositions(positions []*netPosition) {
+	sort.Slice(positions, func(a, b int) bool {
+		if positions[a].amount != positions[b].amount {
+			return positions[a].amount > positions[b].amount
+		}
+		return positions[a].userID < positions[b].userID
+	})
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+func TestSimplifyDebts(t *testing.T) {
+	transfer := func(from, to int, amount money.Amount) model.SettlementRequest {
+		return model.SettlementRequest{GroupID: 7, FromUserID: from, ToUserID: to, Amount: amount}
+	}
+
+	tests := []struct {
+		name     string
+		balances map[int]money.Amount
+		want     []model.SettlementRequest
+	}{
+		{
+			name:     "settled",
+			balances: map[int]money.Amount{1: 0, 2: 0},
+			want:     nil,
+		},
+		{
+			name:     "one debt",
+			balances: map[int]money.Amount{1: 500, 2: -500},
+			want:     []model.SettlementRequest{transfer(2, 1, 500)},
+		},
+		{
+			name:     "one creditor",
+			balances: map[int]money.Amount{1: 900, 2: -300, 3: -600},
+			want:     []model.SettlementRequest{transfer(3, 1, 600), transfer(2, 1, 300)},
+		},
+		{
+			name:     "largest debtor pays largest creditor first",
+			balances: map[int]money.Amount{1: 700, 2: 300, 3: -800, 4: -200},
+			want:     []model.SettlementRequest{transfer(3, 1, 700), transfer(3, 2, 100), transfer(4, 2, 200)},
+		},
+		{
+			name:     "ties go to the lower user ID",
+			balances: map[int]money.Amount{1: -100, 2: -100, 3: 100, 4: 100},
+			want:     []model.SettlementRequest{transfer(1, 3, 100), transfer(2, 4, 100)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := simplifyDebts(7, tt.balances)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("simplifyDebts = %+v, want %+v", got, tt.want)
+			}
+
+			// Paying the plan settles everyone, in at most n-1 transfers
+			left := make(map[int]money.Amount, len(tt.balances))
+			for userID, amount := range tt.balances {
+				left[userID] = amount
+			}
+			for _, s := range got {
+				left[s.FromUserID] += s.Amount
+				left[s.ToUserID] -= s.Amount
+			}
+			for userID, amount := range left {
+				if amount != 0 {
+					t.Errorf("user %d is left with %s", userID, amount)
+				}
+			}
+			if len(got) > 0 && len(got) > len(tt.balances)-1 {
+				t.Errorf("%d transfers for %d users", len(got), len(tt.balances))
+			}
+		})
+	}
+}
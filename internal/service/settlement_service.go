@@ -2,75 +2,279 @@ package service
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
 	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
 )
 
 type SettlementService struct {
 	settlementRepo repository.SettlementRepository
 	userRepo       repository.UserRepository
+	groupRepo      repository.GroupRepository
+	memberRepo     repository.GroupMemberRepository
 	balanceRepo    repository.BalanceRepository
+	rateRepo       repository.ExchangeRateRepository
+	uow            repository.UnitOfWork
 }
 
 func NewSettlementService(
 	settlementRepo repository.SettlementRepository,
 	userRepo repository.UserRepository,
+	groupRepo repository.GroupRepository,
+	memberRepo repository.GroupMemberRepository,
 	balanceRepo repository.BalanceRepository,
+	rateRepo repository.ExchangeRateRepository,
+	uow repository.UnitOfWork,
 ) *SettlementService {
 	return &SettlementService{
 		settlementRepo: settlementRepo,
 		userRepo:       userRepo,
+		groupRepo:      groupRepo,
+		memberRepo:     memberRepo,
 		balanceRepo:    balanceRepo,
+		rateRepo:       rateRepo,
+		uow:            uow,
 	}
 }
 
+func (s *SettlementService) newSettlementResponse(settlement *model.Settlement, baseCurrency string) *model.SettlementResponse {
+	response := &model.SettlementResponse{
+		ID:           settlement.ID,
+		GroupID:      settlement.GroupID,
+		FromUserID:   settlement.FromUserID,
+		ToUserID:     settlement.ToUserID,
+		Amount:       settlement.Amount,
+		Currency:     settlement.Currency,
+		FXRate:       settlement.FXRate,
+		BaseAmount:   settlement.BaseAmount,
+		BaseCurrency: baseCurrency,
+		Description:  settlement.Description,
+		CreatedAt:    settlement.CreatedAt,
+	}
+
+	if fromUser, err := s.userRepo.GetUserByID(settlement.FromUserID); err == nil {
+		response.FromUserName = fromUser.Name
+	}
+	if toUser, err := s.userRepo.GetUserByID(settlement.ToUserID); err == nil {
+		response.ToUserName = toUser.Name
+	}
+
+	return response
+}
+
 // CreateSettlement creates a new payment settlement and updates user balances
-func (s *SettlementService) CreateSettlement(req *model.SettlementRequest) (*model.SettlementResponse, error) {
+func (s *SettlementService) CreateSettlement(req *model.SettlementRequest, actorID int) (*model.SettlementResponse, error) {
+	settlement, baseCurrency, err := s.prepareSettlement(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var created *model.Settlement
+	err = s.uow.Do(func(tx repository.Tx) error {
+		created, err = postSettlement(tx, settlement, actorID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepare response with user names
+	return s.newSettlementResponse(created, baseCurrency), nil
+}
+
+// CreateSettlements records a batch of settlements, such as a settle plan.
+// Either every settlement in the batch is recorded or none of them is.
+func (s *SettlementService) CreateSettlements(reqs []model.SettlementRequest, actorID int) ([]*model.SettlementResponse, error) {
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("no settlements provided")
+	}
+
+	settlements := make([]*model.Settlement, len(reqs))
+	baseCurrencies := make([]string, len(reqs))
+	for i := range reqs {
+		settlement, baseCurrency, err := s.prepareSettlement(&reqs[i])
+		if err != nil {
+			return nil, fmt.Errorf("settlement %d: %v", i, err)
+		}
+		settlements[i] = settlement
+		baseCurrencies[i] = baseCurrency
+	}
+
+	created := make([]*model.Settlement, len(settlements))
+	err := s.uow.Do(func(tx repository.Tx) error {
+		for i, settlement := range settlements {
+			var err error
+			created[i], err = postSettlement(tx, settlement, actorID)
+			if err != nil {
+				return fmt.Errorf("settlement %d: %v", i, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []*model.SettlementResponse
+	for i, settlement := range created {
+		responses = append(responses, s.newSettlementResponse(settlement, baseCurrencies[i]))
+	}
+
+	return responses, nil
+}
+
+// postSettlement records a settlement, reduces what the payer owes the
+// recipient in the group's balances by the amount paid, and records it in the
+// activity log
+func postSettlement(tx repository.Tx, settlement *model.Settlement, actorID int) (*model.Settlement, error) {
+	created, err := tx.Settlements().CreateSettlement(settlement)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Balances().AdjustBalance(created.GroupID, created.FromUserID, created.ToUserID, -created.BaseAmount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update balances: %v", err)
+	}
+
+	err = recordActivity(tx, created.GroupID, actorID, model.ActivityCreated, model.EntitySettlement, created.ID, nil, snapshotSettlement(created))
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// DeleteSettlement soft-deletes a settlement and adds the amount back to what
+// the payer owes the recipient. The settlement is locked first, and the
+// balances only change once the delete is known to have changed it, so
+// concurrent deletes reverse it once.
+func (s *SettlementService) DeleteSettlement(id, actorID int) error {
+	return s.uow.Do(func(tx repository.Tx) error {
+		settlement, err := tx.Settlements().LockSettlement(id)
+		if err != nil {
+			return err
+		}
+		if settlement.DeletedAt != nil {
+			return fmt.Errorf("settlement not found")
+		}
+
+		// Fails when no live settlement was changed
+		if err := tx.Settlements().DeleteSettlement(id, time.Now()); err != nil {
+			return err
+		}
+
+		err = tx.Balances().AdjustBalance(settlement.GroupID, settlement.FromUserID, settlement.ToUserID, settlement.BaseAmount)
+		if err != nil {
+			return fmt.Errorf("failed to update balances: %v", err)
+		}
+
+		return recordActivity(tx, settlement.GroupID, actorID, model.ActivityDeleted, model.EntitySettlement, id, snapshotSettlement(settlement), nil)
+	})
+}
+
+// RestoreSettlement brings back a soft-deleted settlement and applies it to the
+// balances again, locking it like DeleteSettlement. A settlement deleted with
+// its group comes back with the group.
+func (s *SettlementService) RestoreSettlement(id, actorID int) (*model.SettlementResponse, error) {
+	err := s.uow.Do(func(tx repository.Tx) error {
+		settlement, err := tx.Settlements().LockSettlement(id)
+		if err != nil {
+			return err
+		}
+		if settlement.DeletedAt == nil {
+			return fmt.Errorf("deleted settlement not found")
+		}
+		if _, err := tx.Groups().GetGroupByID(settlement.GroupID); err != nil {
+			return fmt.Errorf("the settlement's group is deleted, restore the group first")
+		}
+		// Claiming a placeholder turns payments between it and the claimer
+		// into payments to oneself, deleted when it is claimed
+		if settlement.FromUserID == settlement.ToUserID {
+			return fmt.Errorf("a payment to oneself cannot be restored")
+		}
+
+		// Fails when no deleted settlement was changed
+		if err := tx.Settlements().RestoreSettlement(id); err != nil {
+			return err
+		}
+
+		err = tx.Balances().AdjustBalance(settlement.GroupID, settlement.FromUserID, settlement.ToUserID, -settlement.BaseAmount)
+		if err != nil {
+			return fmt.Errorf("failed to update balances: %v", err)
+		}
+
+		return recordActivity(tx, settlement.GroupID, actorID, model.ActivityRestored, model.EntitySettlement, id, nil, snapshotSettlement(settlement))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetSettlementByID(id)
+}
+
+// prepareSettlement validates a settlement request and converts it into the group
+// currency. It returns the settlement to store and the group currency.
+func (s *SettlementService) prepareSettlement(req *model.SettlementRequest) (*model.Settlement, string, error) {
 	// Validate users exist
 	fromUser, err := s.userRepo.GetUserByID(req.FromUserID)
 	if err != nil {
-		return nil, fmt.Errorf("from user not found: %v", err)
+		return nil, "", fmt.Errorf("from user not found: %v", err)
 	}
 
 	toUser, err := s.userRepo.GetUserByID(req.ToUserID)
 	if err != nil {
-		return nil, fmt.Errorf("to user not found: %v", err)
+		return nil, "", fmt.Errorf("to user not found: %v", err)
 	}
 
 	// Validate amount
 	if req.Amount <= 0 {
-		return nil, fmt.Errorf("amount must be greater than 0")
+		return nil, "", fmt.Errorf("amount must be greater than 0")
 	}
 
-	// Create settlement record
-	settlement := &model.Settlement{
-		GroupID:     req.GroupID,
-		FromUserID:  req.FromUserID,
-		ToUserID:    req.ToUserID,
-		Amount:      req.Amount,
-		Description: req.Description,
+	if req.FromUserID == req.ToUserID {
+		return nil, "", fmt.Errorf("cannot settle with yourself")
 	}
 
-	created, err := s.settlementRepo.CreateSettlement(settlement)
+	group, err := s.groupRepo.GetGroupByID(req.GroupID)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	// Prepare response with user names
-	response := &model.SettlementResponse{
-		ID:           created.ID,
-		GroupID:      created.GroupID,
-		FromUserID:   created.FromUserID,
-		FromUserName: fromUser.Name,
-		ToUserID:     created.ToUserID,
-		ToUserName:   toUser.Name,
-		Amount:       created.Amount,
-		Description:  created.Description,
-		CreatedAt:    created.CreatedAt,
-	}
-
-	return response, nil
+	// Both sides of the payment must belong to the group
+	for _, userID := range []int{fromUser.ID, toUser.ID} {
+		isMember, err := s.memberRepo.IsMember(req.GroupID, userID)
+		if err != nil {
+			return nil, "", err
+		}
+		if !isMember {
+			return nil, "", fmt.Errorf("user %d is not a member of this group", userID)
+		}
+	}
+
+	// Payments default to the group currency and are converted to it otherwise
+	currency, err := money.NormalizeCurrency(req.Currency, group.Currency)
+	if err != nil {
+		return nil, "", err
+	}
+
+	baseAmount, rate, err := convertAmount(s.rateRepo, req.Amount, currency, group.Currency, time.Now())
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &model.Settlement{
+		GroupID:     req.GroupID,
+		FromUserID:  fromUser.ID,
+		ToUserID:    toUser.ID,
+		Amount:      req.Amount,
+		Currency:    currency,
+		FXRate:      rate,
+		BaseAmount:  baseAmount,
+		Description: req.Description,
+	}, group.Currency, nil
 }
 
 // GetSettlementByID retrieves a settlement by ID
@@ -80,87 +284,55 @@ func (s *SettlementService) GetSettlementByID(id int) (*model.SettlementResponse
 		return nil, err
 	}
 
-	fromUser, _ := s.userRepo.GetUserByID(settlement.FromUserID)
-	toUser, _ := s.userRepo.GetUserByID(settlement.ToUserID)
-
-	response := &model.SettlementResponse{
-		ID:           settlement.ID,
-		GroupID:      settlement.GroupID,
-		FromUserID:   settlement.FromUserID,
-		FromUserName: fromUser.Name,
-		ToUserID:     settlement.ToUserID,
-		ToUserName:   toUser.Name,
-		Amount:       settlement.Amount,
-		Description:  settlement.Description,
-		CreatedAt:    settlement.CreatedAt,
-	}
-
-	return response, nil
+	currencies := newGroupCurrencies(s.groupRepo)
+	return s.newSettlementResponse(settlement, currencies.get(settlement.GroupID)), nil
 }
 
-// GetSettlementsByGroupID retrieves all settlements in a group
-func (s *SettlementService) GetSettlementsByGroupID(groupID int) (*model.GroupSettlementResponse, error) {
-	settlements, err := s.settlementRepo.GetSettlementsByGroupID(groupID)
+// GetSettlementsByGroupID retrieves one page of the settlements in a group
+func (s *SettlementService) GetSettlementsByGroupID(groupID int, query *model.ListQuery) (*model.GroupSettlementResponse, error) {
+	query.GroupID = groupID
+	settlements, next, err := s.settlementRepo.ListSettlements(query)
 	if err != nil {
 		return nil, err
 	}
 
+	currency := newGroupCurrencies(s.groupRepo).get(groupID)
+
 	var responses []model.SettlementResponse
-	totalAmount := 0.0
+	var totalAmount money.Amount
 
+	// The total is reported in the group currency
 	for _, settlement := range settlements {
-		fromUser, _ := s.userRepo.GetUserByID(settlement.FromUserID)
-		toUser, _ := s.userRepo.GetUserByID(settlement.ToUserID)
-
-		response := model.SettlementResponse{
-			ID:           settlement.ID,
-			GroupID:      settlement.GroupID,
-			FromUserID:   settlement.FromUserID,
-			FromUserName: fromUser.Name,
-			ToUserID:     settlement.ToUserID,
-			ToUserName:   toUser.Name,
-			Amount:       settlement.Amount,
-			Description:  settlement.Description,
-			CreatedAt:    settlement.CreatedAt,
-		}
-		responses = append(responses, response)
-		totalAmount += settlement.Amount
+		responses = append(responses, *s.newSettlementResponse(settlement, currency))
+		totalAmount += settlement.BaseAmount
 	}
 
 	return &model.GroupSettlementResponse{
 		Settlements: responses,
 		TotalAmount: totalAmount,
+		Currency:    currency,
+		NextCursor:  next,
 	}, nil
 }
 
-// GetSettlementsByUserID retrieves all settlements for a user
-func (s *SettlementService) GetSettlementsByUserID(userID int) ([]*model.SettlementResponse, error) {
-	settlements, err := s.settlementRepo.GetSettlementsByUserID(userID)
+// GetSettlementsByUserID retrieves one page of the settlements a user sent or
+// received, and the cursor of the next page
+func (s *SettlementService) GetSettlementsByUserID(userID int, query *model.ListQuery) ([]*model.SettlementResponse, string, error) {
+	query.UserID = userID
+	settlements, next, err := s.settlementRepo.ListSettlements(query)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
+	currencies := newGroupCurrencies(s.groupRepo)
+
 	var responses []*model.SettlementResponse
 
 	for _, settlement := range settlements {
-		fromUser, _ := s.userRepo.GetUserByID(settlement.FromUserID)
-		toUser, _ := s.userRepo.GetUserByID(settlement.ToUserID)
-
-		response := &model.SettlementResponse{
-			ID:           settlement.ID,
-			GroupID:      settlement.GroupID,
-			FromUserID:   settlement.FromUserID,
-			FromUserName: fromUser.Name,
-			ToUserID:     settlement.ToUserID,
-			ToUserName:   toUser.Name,
-			Amount:       settlement.Amount,
-			Description:  settlement.Description,
-			CreatedAt:    settlement.CreatedAt,
-		}
-		responses = append(responses, response)
+		responses = append(responses, s.newSettlementResponse(settlement, currencies.get(settlement.GroupID)))
 	}
 
-	return responses, nil
+	return responses, next, nil
 }
 
 // GetAllSettlements retrieves all settlements
@@ -170,24 +342,12 @@ func (s *SettlementService) GetAllSettlements() ([]*model.SettlementResponse, er
 		return nil, err
 	}
 
+	currencies := newGroupCurrencies(s.groupRepo)
+
 	var responses []*model.SettlementResponse
 
 	for _, settlement := range settlements {
-		fromUser, _ := s.userRepo.GetUserByID(settlement.FromUserID)
-		toUser, _ := s.userRepo.GetUserByID(settlement.ToUserID)
-
-		response := &model.SettlementResponse{
-			ID:           settlement.ID,
-			GroupID:      settlement.GroupID,
-			FromUserID:   settlement.FromUserID,
-			FromUserName: fromUser.Name,
-			ToUserID:     settlement.ToUserID,
-			ToUserName:   toUser.Name,
-			Amount:       settlement.Amount,
-			Description:  settlement.Description,
-			CreatedAt:    settlement.CreatedAt,
-		}
-		responses = append(responses, response)
+		responses = append(responses, s.newSettlementResponse(settlement, currencies.get(settlement.GroupID)))
 	}
 
 	return responses, nil
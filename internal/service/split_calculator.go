@@ -0,0 +1,123 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+// percentageBasisPoints is 100% expressed in basis points (hundredths of a percent)
+const percentageBasisPoints = 10000
+
+// computeSplits turns a split spec into the splits of an expense of the given
+// amount. Every participant must be one of memberIDs and appear only once, and the
+// resulting split amounts always add up exactly to amount. A nil spec splits the
+// expense equally among all members.
+func computeSplits(amount money.Amount, spec *model.SplitSpec, memberIDs []int) (string, []*model.ExpenseSplit, error) {
+	if spec == nil {
+		spec = &model.SplitSpec{Mode: model.SplitModeEqual}
+	}
+
+	mode := spec.Mode
+	if mode == "" {
+		mode = model.SplitModeEqual
+	}
+
+	participants := spec.Participants
+	if mode == model.SplitModeEqual && len(participants) == 0 {
+		for _, userID := range memberIDs {
+			participants = append(participants, model.SplitParticipant{UserID: userID})
+		}
+	}
+	if len(participants) == 0 {
+		return "", nil, fmt.Errorf("split needs at least one participant")
+	}
+
+	members := make(map[int]bool, len(memberIDs))
+	for _, userID := range memberIDs {
+		members[userID] = true
+	}
+
+	// Work in user ID order so leftover cents always land on the same participants
+	participants = append([]model.SplitParticipant(nil), participants...)
+	sort.Slice(participants, func(i, j int) bool { return participants[i].UserID < participants[j].UserID })
+
+	weights := make([]int64, len(participants))
+	for i, participant := range participants {
+		if !members[participant.UserID] {
+			return "", nil, fmt.Errorf("user %d is not a member of this group", participant.UserID)
+		}
+		if i > 0 && participants[i-1].UserID == participant.UserID {
+			return "", nil, fmt.Errorf("user %d appears more than once in the split", participant.UserID)
+		}
+
+		switch mode {
+		case model.SplitModeEqual:
+			weights[i] = 1
+		case model.SplitModeExact:
+			if participant.Amount <= 0 {
+				return "", nil, fmt.Errorf("split amount for user %d must be greater than 0", participant.UserID)
+			}
+			weights[i] = int64(participant.Amount)
+		case model.SplitModePercentage:
+			basisPoints := math.Round(participant.Percentage * 100)
+			if basisPoints <= 0 {
+				return "", nil, fmt.Errorf("split percentage for user %d must be greater than 0", participant.UserID)
+			}
+			weights[i] = int64(basisPoints)
+		case model.SplitModeShares:
+			if participant.Shares <= 0 {
+				return "", nil, fmt.Errorf("split shares for user %d must be greater than 0", participant.UserID)
+			}
+			weights[i] = participant.Shares
+		default:
+			return "", nil, fmt.Errorf("unknown split mode: %s", mode)
+		}
+	}
+
+	splits, err := splitByWeights(amount, mode, participants, weights)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return mode, splits, nil
+}
+
+// splitByWeights divides amount between participants according to the weights
+// for the given mode, checking the totals that each mode has to respect
+func splitByWeights(amount money.Amount, mode string, participants []model.SplitParticipant, weights []int64) ([]*model.ExpenseSplit, error) {
+	var total int64
+	for _, w := range weights {
+		total += w
+	}
+
+	switch mode {
+	case model.SplitModeExact:
+		if money.Amount(total) != amount {
+			return nil, fmt.Errorf("split amounts add up to %s, expected %s", money.Amount(total), amount)
+		}
+	case model.SplitModePercentage:
+		if total != percentageBasisPoints {
+			return nil, fmt.Errorf("split percentages add up to %.2f%%, expected 100%%", float64(total)/100)
+		}
+	}
+
+	amounts, err := money.Allocate(amount, weights)
+	if err != nil {
+		return nil, err
+	}
+
+	splits := make([]*model.ExpenseSplit, len(participants))
+	for i, participant := range participants {
+		splits[i] = &model.ExpenseSplit{
+			UserID: participant.UserID,
+			Amount: amounts[i],
+			Weight: weights[i],
+		}
+	}
+
+	return splits, nil
+}
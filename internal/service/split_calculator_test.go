@@ -0,0 +1,165 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+)
+
+func TestComputeSplits(t *testing.T) {
+	members := []int{1, 2, 3}
+
+	tests := []struct {
+		name     string
+		amount   money.Amount
+		spec     *model.SplitSpec
+		wantMode string
+		// want maps each participant to their amount
+		want    map[int]money.Amount
+		wantErr bool
+	}{
+		{
+			name:     "nil spec splits equally among members",
+			amount:   1000,
+			spec:     nil,
+			wantMode: model.SplitModeEqual,
+			want:     map[int]money.Amount{1: 334, 2: 333, 3: 333},
+		},
+		{
+			name:     "empty mode means equal",
+			amount:   600,
+			spec:     &model.SplitSpec{},
+			wantMode: model.SplitModeEqual,
+			want:     map[int]money.Amount{1: 200, 2: 200, 3: 200},
+		},
+		{
+			name:   "equal among some members, leftover cents by user ID",
+			amount: 1001,
+			spec: &model.SplitSpec{Mode: model.SplitModeEqual, Participants: []model.SplitParticipant{
+				{UserID: 3}, {UserID: 1},
+			}},
+			wantMode: model.SplitModeEqual,
+			want:     map[int]money.Amount{1: 501, 3: 500},
+		},
+		{
+			name:   "exact",
+			amount: 1000,
+			spec: &model.SplitSpec{Mode: model.SplitModeExact, Participants: []model.SplitParticipant{
+				{UserID: 1, Amount: 250}, {UserID: 2, Amount: 750},
+			}},
+			wantMode: model.SplitModeExact,
+			want:     map[int]money.Amount{1: 250, 2: 750},
+		},
+		{
+			name:   "exact must add up to the amount",
+			amount: 1000,
+			spec: &model.SplitSpec{Mode: model.SplitModeExact, Participants: []model.SplitParticipant{
+				{UserID: 1, Amount: 250}, {UserID: 2, Amount: 700},
+			}},
+			wantErr: true,
+		},
+		{
+			name:   "exact amounts must be positive",
+			amount: 1000,
+			spec: &model.SplitSpec{Mode: model.SplitModeExact, Participants: []model.SplitParticipant{
+				{UserID: 1, Amount: 1000}, {UserID: 2, Amount: 0},
+			}},
+			wantErr: true,
+		},
+		{
+			name:   "percentage",
+			amount: 1000,
+			spec: &model.SplitSpec{Mode: model.SplitModePercentage, Participants: []model.SplitParticipant{
+				{UserID: 1, Percentage: 33.33}, {UserID: 2, Percentage: 33.33}, {UserID: 3, Percentage: 33.34},
+			}},
+			wantMode: model.SplitModePercentage,
+			want:     map[int]money.Amount{1: 333, 2: 333, 3: 334},
+		},
+		{
+			name:   "percentages must add up to 100",
+			amount: 1000,
+			spec: &model.SplitSpec{Mode: model.SplitModePercentage, Participants: []model.SplitParticipant{
+				{UserID: 1, Percentage: 50}, {UserID: 2, Percentage: 40},
+			}},
+			wantErr: true,
+		},
+		{
+			name:   "shares",
+			amount: 1000,
+			spec: &model.SplitSpec{Mode: model.SplitModeShares, Participants: []model.SplitParticipant{
+				{UserID: 1, Shares: 1}, {UserID: 2, Shares: 2},
+			}},
+			wantMode: model.SplitModeShares,
+			want:     map[int]money.Amount{1: 333, 2: 667},
+		},
+		{
+			name:   "shares must be positive",
+			amount: 1000,
+			spec: &model.SplitSpec{Mode: model.SplitModeShares, Participants: []model.SplitParticipant{
+				{UserID: 1, Shares: 1}, {UserID: 2, Shares: -1},
+			}},
+			wantErr: true,
+		},
+		{
+			name:   "participants must be members",
+			amount: 1000,
+			spec: &model.SplitSpec{Mode: model.SplitModeEqual, Participants: []model.SplitParticipant{
+				{UserID: 1}, {UserID: 4},
+			}},
+			wantErr: true,
+		},
+		{
+			name:   "participants appear once",
+			amount: 1000,
+			spec: &model.SplitSpec{Mode: model.SplitModeEqual, Participants: []model.SplitParticipant{
+				{UserID: 1}, {UserID: 1},
+			}},
+			wantErr: true,
+		},
+		{
+			name:    "non-equal modes need participants",
+			amount:  1000,
+			spec:    &model.SplitSpec{Mode: model.SplitModeShares},
+			wantErr: true,
+		},
+		{
+			name:    "unknown mode",
+			amount:  1000,
+			spec:    &model.SplitSpec{Mode: "random", Participants: []model.SplitParticipant{{UserID: 1}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, splits, err := computeSplits(tt.amount, tt.spec, members)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("computeSplits succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("computeSplits failed: %v", err)
+			}
+			if mode != tt.wantMode {
+				t.Errorf("mode = %q, want %q", mode, tt.wantMode)
+			}
+
+			got := make(map[int]money.Amount, len(splits))
+			var sum money.Amount
+			for _, split := range splits {
+				got[split.UserID] = split.Amount
+				sum += split.Amount
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splits = %v, want %v", got, tt.want)
+			}
+			if sum != tt.amount {
+				t.Errorf("splits add up to %s, want %s", sum, tt.amount)
+			}
+		})
+	}
+}
@@ -0,0 +1,550 @@
+package service
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
+)
+
+// splitwiseColumns are the columns a Splitwise group export starts with. One
+// column per person follows, holding how the row changed their balance:
+// positive when they paid more than their share, negative when they owe.
+var splitwiseColumns = []string{"date", "description", "category", "cost", "currency"}
+
+// splitwiseRow is a row read from a Splitwise export, or the error that kept
+// it from being read
+type splitwiseRow struct {
+	line        int
+	date        time.Time
+	description string
+	category    string
+	cost        money.Amount
+	currency    string
+	nets        []money.Amount
+	err         error
+}
+
+// splitwiseExport is a parsed Splitwise group export
+type splitwiseExport struct {
+	people []string
+	rows   []*splitwiseRow
+	// totals holds the Total balance rows by currency
+	totals map[string]*splitwiseRow
+}
+
+// splitwisePerson is a person column mapped to a group member. Placeholders get
+// a negative user ID until they are created.
+type splitwisePerson struct {
+	response *model.SplitwisePerson
+	userID   int
+}
+
+// importedSplitwiseRow is a validated row, ready to be stored
+type importedSplitwiseRow struct {
+	result     *model.ImportRowResult
+	expenses   []*importedExpense
+	settlement *model.Settlement
+}
+
+// ImportSplitwise recreates the expenses and payments of a Splitwise group
+// export. Each person column is mapped to a group member, or to a new
+// placeholder member. Expenses paid by one person keep the exact share of
+// every participant; expenses paid by several people become one expense per
+// payer, split exactly among those who owe so that each owes what their
+// column says in total, which moves the balances the same way. Payments
+// become settlements. Like ImportExpenses, nothing is stored in a dry run or
+// when any row is invalid.
+func (s *ImportService) ImportSplitwise(groupID int, r io.Reader, options *model.SplitwiseImportOptions, actorID int) (*model.SplitwiseImportResponse, error) {
+	group, err := s.groupRepo.GetGroupByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	export, err := readSplitwiseCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := export.checkTotals(); err != nil {
+		return nil, err
+	}
+
+	people, err := s.mapSplitwisePeople(groupID, export.people, options)
+	if err != nil {
+		return nil, err
+	}
+
+	memberIDs, err := groupMemberIDs(s.memberRepo, groupID)
+	if err != nil {
+		return nil, err
+	}
+	for _, person := range people {
+		if person.response.Placeholder {
+			memberIDs = append(memberIDs, person.userID)
+		}
+	}
+
+	categories, err := s.categoryRepo.GetCategoriesForGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	categoryIDs := make(map[string]int, len(categories))
+	for _, category := range categories {
+		categoryIDs[strings.ToLower(category.Name)] = category.ID
+	}
+
+	response := &model.SplitwiseImportResponse{
+		ImportResponse: model.ImportResponse{DryRun: options.DryRun, Total: len(export.rows), Rows: []*model.ImportRowResult{}},
+		People:         make([]*model.SplitwisePerson, len(people)),
+	}
+	for i, person := range people {
+		response.People[i] = person.response
+	}
+
+	var imported []*importedSplitwiseRow
+	for _, row := range export.rows {
+		result := &model.ImportRowResult{Line: row.line}
+		response.Rows = append(response.Rows, result)
+
+		if row.err != nil {
+			result.Errors = []string{row.err.Error()}
+			continue
+		}
+
+		item, err := s.validateSplitwiseRow(group, memberIDs, people, categoryIDs, row)
+		if err != nil {
+			result.Errors = []string{err.Error()}
+			continue
+		}
+		if item == nil {
+			result.Skipped = true
+		} else {
+			item.result = result
+			imported = append(imported, item)
+		}
+		response.Valid++
+	}
+
+	response.Invalid = response.Total - response.Valid
+	if options.DryRun || response.Invalid > 0 || len(imported) == 0 {
+		return response, nil
+	}
+
+	err = s.uow.Do(func(tx repository.Tx) error {
+		userIDs := make(map[int]int)
+		for _, person := range people {
+			if !person.response.Placeholder {
+				continue
+			}
+			created, _, err := createPlaceholder(tx, groupID, person.response.Name, "", actorID)
+			if err != nil {
+				return fmt.Errorf("placeholder for %s: %v", person.response.Name, err)
+			}
+			userIDs[person.userID] = created.ID
+			person.response.UserID = created.ID
+		}
+		userID := func(id int) int {
+			if created, ok := userIDs[id]; ok {
+				return created
+			}
+			return id
+		}
+
+		for _, item := range imported {
+			for _, expense := range item.expenses {
+				expense.expense.PaidByID = userID(expense.expense.PaidByID)
+				for _, split := range expense.splits {
+					split.UserID = userID(split.UserID)
+				}
+				created, err := recordExpense(tx, expense.expense, expense.splits, actorID)
+				if err != nil {
+					return fmt.Errorf("line %d: %v", item.result.Line, err)
+				}
+				item.result.ExpenseIDs = append(item.result.ExpenseIDs, created.ID)
+			}
+
+			if item.settlement != nil {
+				item.settlement.FromUserID = userID(item.settlement.FromUserID)
+				item.settlement.ToUserID = userID(item.settlement.ToUserID)
+				created, err := postSettlement(tx, item.settlement, actorID)
+				if err != nil {
+					return fmt.Errorf("line %d: %v", item.result.Line, err)
+				}
+				item.result.SettlementID = created.ID
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response.Applied = true
+	return response, nil
+}
+
+// mapSplitwisePeople maps every person column to a group member: through the
+// given emails first, then by name
+func (s *ImportService) mapSplitwisePeople(groupID int, names []string, options *model.SplitwiseImportOptions) ([]*splitwisePerson, error) {
+	members, err := s.memberRepo.GetGroupMembers(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group members: %v", err)
+	}
+	byName := make(map[string][]int)
+	for _, member := range members {
+		user, err := s.userRepo.GetUserByID(member.UserID)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.ToLower(strings.TrimSpace(user.Name))
+		byName[name] = append(byName[name], user.ID)
+	}
+
+	for name := range options.Members {
+		if !containsColumn(names, name) {
+			return nil, fmt.Errorf("the export has no person column %q", name)
+		}
+	}
+
+	resolver := &memberResolver{userRepo: s.userRepo, memberRepo: s.memberRepo, groupID: groupID, ids: make(map[string]int)}
+	people := make([]*splitwisePerson, len(names))
+	mapped := make(map[int]string, len(names))
+	placeholders := 0
+	for i, name := range names {
+		person := &splitwisePerson{response: &model.SplitwisePerson{Name: name}}
+		people[i] = person
+
+		if email, ok := options.Members[name]; ok {
+			if person.userID, err = resolver.resolve(email); err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+		} else {
+			switch ids := byName[strings.ToLower(name)]; {
+			case len(ids) == 1:
+				person.userID = ids[0]
+			case len(ids) > 1:
+				return nil, fmt.Errorf("%s: several members have this name, map it to an email", name)
+			case options.Placeholders:
+				placeholders++
+				person.userID = -placeholders
+				person.response.Placeholder = true
+				continue
+			default:
+				return nil, fmt.Errorf("%s: no member has this name, map it to an email", name)
+			}
+		}
+
+		if other, ok := mapped[person.userID]; ok {
+			return nil, fmt.Errorf("%s and %s are mapped to the same member", other, name)
+		}
+		mapped[person.userID] = name
+		person.response.UserID = person.userID
+	}
+
+	return people, nil
+}
+
+// validateSplitwiseRow turns a row into expenses or a settlement. It returns
+// nil when the row moves no money, like an expense someone paid for themselves.
+func (s *ImportService) validateSplitwiseRow(
+	group *model.Group,
+	memberIDs []int,
+	people []*splitwisePerson,
+	categoryIDs map[string]int,
+	row *splitwiseRow,
+) (*importedSplitwiseRow, error) {
+	if row.cost <= 0 {
+		return nil, fmt.Errorf("cost must be greater than 0")
+	}
+
+	currency, err := money.NormalizeCurrency(row.currency, group.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	var total money.Amount
+	var payers, debtors []int
+	for i, net := range row.nets {
+		total += net
+		switch {
+		case net > 0:
+			payers = append(payers, i)
+		case net < 0:
+			debtors = append(debtors, i)
+		}
+	}
+	if total != 0 {
+		return nil, fmt.Errorf("person columns add up to %s, expected 0", total)
+	}
+	if len(payers) == 0 {
+		return nil, nil
+	}
+
+	if strings.EqualFold(row.category, model.SplitwisePaymentCategory) {
+		if len(payers) != 1 || len(debtors) != 1 {
+			return nil, fmt.Errorf("a payment must be from one person to one other")
+		}
+
+		amount := row.nets[payers[0]]
+		baseAmount, rate, err := convertAmount(s.rateRepo, amount, currency, group.Currency, row.date)
+		if err != nil {
+			return nil, err
+		}
+
+		return &importedSplitwiseRow{settlement: &model.Settlement{
+			GroupID:     group.ID,
+			FromUserID:  people[payers[0]].userID,
+			ToUserID:    people[debtors[0]].userID,
+			Amount:      amount,
+			Currency:    currency,
+			FXRate:      rate,
+			BaseAmount:  baseAmount,
+			Description: row.description,
+			CreatedAt:   row.date,
+		}}, nil
+	}
+
+	var categoryID *int
+	if id, ok := categoryIDs[strings.ToLower(row.category)]; ok {
+		categoryID = &id
+	}
+
+	item := &importedSplitwiseRow{}
+	if len(payers) == 1 {
+		// The payer's own share is what they paid minus what they are owed
+		payer := payers[0]
+		spec := &model.SplitSpec{Mode: model.SplitModeExact}
+		if share := row.cost - row.nets[payer]; share > 0 {
+			spec.Participants = append(spec.Participants, model.SplitParticipant{UserID: people[payer].userID, Amount: share})
+		} else if share < 0 {
+			return nil, fmt.Errorf("%s is owed more than the cost", people[payer].response.Name)
+		}
+		for _, debtor := range debtors {
+			spec.Participants = append(spec.Participants, model.SplitParticipant{UserID: people[debtor].userID, Amount: -row.nets[debtor]})
+		}
+
+		expense, err := s.splitwiseExpense(group, memberIDs, people[payer].userID, row.cost, currency, categoryID, spec, row)
+		if err != nil {
+			return nil, err
+		}
+		item.expenses = append(item.expenses, expense)
+		return item, nil
+	}
+
+	// The debts are handed out to the payers in one pass, so every debtor
+	// owes exactly their amount in total and nobody gets an empty split
+	owed := make([]money.Amount, len(debtors))
+	for i, debtor := range debtors {
+		owed[i] = -row.nets[debtor]
+	}
+	next := 0
+	for _, payer := range payers {
+		spec := &model.SplitSpec{Mode: model.SplitModeExact}
+		for due := row.nets[payer]; due > 0; next++ {
+			share := owed[next]
+			if share > due {
+				share = due
+			}
+			spec.Participants = append(spec.Participants, model.SplitParticipant{UserID: people[debtors[next]].userID, Amount: share})
+			owed[next] -= share
+			due -= share
+			if owed[next] > 0 {
+				break
+			}
+		}
+
+		expense, err := s.splitwiseExpense(group, memberIDs, people[payer].userID, row.nets[payer], currency, categoryID, spec, row)
+		if err != nil {
+			return nil, err
+		}
+		item.expenses = append(item.expenses, expense)
+	}
+	return item, nil
+}
+
+func (s *ImportService) splitwiseExpense(
+	group *model.Group,
+	memberIDs []int,
+	payerID int,
+	amount money.Amount,
+	currency string,
+	categoryID *int,
+	spec *model.SplitSpec,
+	row *splitwiseRow,
+) (*importedExpense, error) {
+	splitMode, splits, err := computeSplits(amount, spec, memberIDs)
+	if err != nil {
+		return nil, fmt.Errorf("split: %v", err)
+	}
+
+	baseAmount, rate, err := convertAmount(s.rateRepo, amount, currency, group.Currency, row.date)
+	if err != nil {
+		return nil, err
+	}
+
+	return &importedExpense{
+		expense: &model.Expense{
+			GroupID:     group.ID,
+			PaidByID:    payerID,
+			Amount:      amount,
+			Currency:    currency,
+			FXRate:      rate,
+			BaseAmount:  baseAmount,
+			SplitMode:   splitMode,
+			CategoryID:  categoryID,
+			Description: row.description,
+			CreatedAt:   row.date,
+		},
+		splits: splits,
+	}, nil
+}
+
+// checkTotals checks that the rows of each currency add up to its Total
+// balance row, so the export is known to be complete
+func (e *splitwiseExport) checkTotals() error {
+	sums := make(map[string][]money.Amount)
+	for _, row := range e.rows {
+		if row.err != nil {
+			continue
+		}
+		currency := strings.ToUpper(row.currency)
+		if sums[currency] == nil {
+			sums[currency] = make([]money.Amount, len(e.people))
+		}
+		for i, net := range row.nets {
+			sums[currency][i] += net
+		}
+	}
+
+	for currency, total := range e.totals {
+		if total.err != nil {
+			return fmt.Errorf("line %d: %v", total.line, total.err)
+		}
+		sum := sums[currency]
+		for i, expected := range total.nets {
+			var got money.Amount
+			if sum != nil {
+				got = sum[i]
+			}
+			if got != expected {
+				return fmt.Errorf("line %d: the %s total balance of %s is %s, but the rows add up to %s", total.line, currency, e.people[i], expected, got)
+			}
+		}
+	}
+	return nil
+}
+
+// readSplitwiseCSV reads a Splitwise group export
+func readSplitwiseCSV(r io.Reader) (*splitwiseExport, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %v", err)
+	}
+	if len(header) <= len(splitwiseColumns) {
+		return nil, fmt.Errorf("a Splitwise export has the columns %s followed by one column per person", strings.Join(splitwiseColumns, ", "))
+	}
+	for i, name := range splitwiseColumns {
+		if column := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))); column != name {
+			return nil, fmt.Errorf("expected column %q, got %q", name, column)
+		}
+	}
+
+	export := &splitwiseExport{totals: make(map[string]*splitwiseRow)}
+	for _, name := range header[len(splitwiseColumns):] {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("a person column has no name")
+		}
+		if containsColumn(export.people, name) {
+			return nil, fmt.Errorf("person column %q appears more than once", name)
+		}
+		export.people = append(export.people, name)
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := readSplitwiseRow(line, record, export.people)
+		if strings.EqualFold(row.description, model.SplitwiseTotalBalance) {
+			export.totals[strings.ToUpper(row.currency)] = row
+			continue
+		}
+		if len(export.rows) == model.MaxImportRows {
+			return nil, fmt.Errorf("an import can hold at most %d rows", model.MaxImportRows)
+		}
+		export.rows = append(export.rows, row)
+	}
+
+	return export, nil
+}
+
+func readSplitwiseRow(line int, record []string, people []string) *splitwiseRow {
+	field := func(i int) string {
+		if i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	row := &splitwiseRow{
+		line:        line,
+		description: field(1),
+		category:    field(2),
+		currency:    field(4),
+		nets:        make([]money.Amount, len(people)),
+	}
+	// The Total balance rows have no date nor cost
+	if !strings.EqualFold(row.description, model.SplitwiseTotalBalance) {
+		if row.date, row.err = parseImportDate(field(0)); row.err != nil {
+			return row
+		}
+		cost, err := money.ParseAmount(field(3))
+		if err != nil {
+			row.err = fmt.Errorf("invalid cost: %v", err)
+			return row
+		}
+		row.cost = cost
+	}
+
+	for i := range row.nets {
+		value := field(len(splitwiseColumns) + i)
+		if value == "" {
+			continue
+		}
+		net, err := money.ParseAmount(value)
+		if err != nil {
+			row.err = fmt.Errorf("invalid amount for %s: %v", people[i], err)
+			return row
+		}
+		row.nets[i] = net
+	}
+	return row
+}
+
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
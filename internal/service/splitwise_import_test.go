@@ -0,0 +1,191 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shreyansh/expense-go-collab-backend/internal/model"
+	"github.com/shreyansh/expense-go-collab-backend/internal/money"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repositorymem"
+)
+
+func TestImportSplitwise(t *testing.T) {
+	const header = "Date,Description,Category,Cost,Currency,ann,bob,cat\n"
+
+	tests := []struct {
+		name   string
+		body   string
+		dryRun bool
+		// noPlaceholders turns off placeholders for unmatched columns
+		noPlaceholders bool
+		wantErr        bool
+		// wantInvalid is how many rows are rejected
+		wantInvalid int
+		wantApplied bool
+		// want is the net balances of ann, bob and cat afterwards
+		want []money.Amount
+		// wantSplits is how many splits each created expense has
+		wantSplits []int
+	}{
+		{
+			name: "one payer keeps exact shares",
+			body: header +
+				"2026-01-02,Dinner,General,30.00,USD,20.00,-10.00,-10.00\n" +
+				"2026-01-03,Taxi,Transportation,10.00,USD,-2.50,7.50,-5.00\n",
+			wantApplied: true,
+			want:        []money.Amount{1750, -250, -1500},
+			wantSplits:  []int{3, 3},
+		},
+		{
+			name: "several payers, each debtor owes exactly their column",
+			body: header +
+				"2026-01-02,Snacks,General,0.02,USD,0.01,0.01,-0.02\n" +
+				"2026-01-03,Hotel,General,90.00,USD,30.00,-40.00,10.00\n",
+			wantApplied: true,
+			want:        []money.Amount{3001, -3999, 998},
+			wantSplits:  []int{1, 1, 1, 1},
+		},
+		{
+			name: "several payers and debtors get no empty splits",
+			body: "Date,Description,Category,Cost,Currency,ann,bob,cat,dan\n" +
+				"2026-01-02,Gum,General,0.02,USD,0.01,0.01,-0.01,-0.01\n",
+			wantApplied: true,
+			want:        []money.Amount{1, 1, -1},
+			wantSplits:  []int{1, 1},
+		},
+		{
+			name: "payment becomes a settlement",
+			body: header +
+				"2026-01-02,Dinner,General,30.00,USD,20.00,-10.00,-10.00\n" +
+				"2026-01-04,bob paid ann,Payment,10.00,USD,-10.00,10.00,0.00\n",
+			wantApplied: true,
+			want:        []money.Amount{1000, 0, -1000},
+			wantSplits:  []int{3},
+		},
+		{
+			name: "rows that move no money are skipped",
+			body: header +
+				"2026-01-02,Own coffee,General,3.00,USD,0.00,0.00,0.00\n" +
+				"2026-01-02,Dinner,General,30.00,USD,20.00,-10.00,-10.00\n",
+			wantApplied: true,
+			want:        []money.Amount{2000, -1000, -1000},
+			wantSplits:  []int{3},
+		},
+		{
+			name: "matching total balance row",
+			body: header +
+				"2026-01-02,Dinner,General,30.00,USD,20.00,-10.00,-10.00\n" +
+				",Total balance,,,USD,20.00,-10.00,-10.00\n",
+			wantApplied: true,
+			want:        []money.Amount{2000, -1000, -1000},
+			wantSplits:  []int{3},
+		},
+		{
+			name: "total balance that does not match",
+			body: header +
+				"2026-01-02,Dinner,General,30.00,USD,20.00,-10.00,-10.00\n" +
+				",Total balance,,,USD,25.00,-15.00,-10.00\n",
+			wantErr: true,
+		},
+		{
+			name: "dry run stores nothing",
+			body: header +
+				"2026-01-02,Dinner,General,30.00,USD,20.00,-10.00,-10.00\n",
+			dryRun: true,
+			want:   []money.Amount{0, 0, 0},
+		},
+		{
+			name: "columns that do not add up",
+			body: header +
+				"2026-01-02,Dinner,General,30.00,USD,20.00,-10.00,-9.00\n" +
+				"2026-01-03,Taxi,General,10.00,USD,5.00,-5.00,0.00\n",
+			wantInvalid: 1,
+			want:        []money.Amount{0, 0, 0},
+		},
+		{
+			name: "payment between more than two people",
+			body: header +
+				"2026-01-02,Payback,Payment,10.00,USD,10.00,-5.00,-5.00\n",
+			wantInvalid: 1,
+			want:        []money.Amount{0, 0, 0},
+		},
+		{
+			name: "a column matching no member without placeholders",
+			body: "Date,Description,Category,Cost,Currency,ann,zed\n" +
+				"2026-01-02,Dinner,General,10.00,USD,5.00,-5.00\n",
+			noPlaceholders: true,
+			wantErr:        true,
+		},
+		{
+			name:    "not a Splitwise export",
+			body:    "payer_email,amount\nann@example.com,10\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := repositorymem.NewStore()
+			groupID, userIDs := seedGroup(t, store, "ann", "bob", "cat")
+			balanceRepo := repositorymem.NewBalanceRepositoryMem(store)
+			imports := newImportService(store)
+			expenses := newExpenseService(store)
+
+			options := &model.SplitwiseImportOptions{DryRun: tt.dryRun, Placeholders: !tt.noPlaceholders}
+			response, err := imports.ImportSplitwise(groupID, strings.NewReader(tt.body), options, userIDs[0])
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ImportSplitwise succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ImportSplitwise failed: %v", err)
+			}
+
+			if response.Invalid != tt.wantInvalid {
+				t.Errorf("invalid rows = %d, want %d (%+v)", response.Invalid, tt.wantInvalid, response.Rows)
+			}
+			if response.Applied != tt.wantApplied {
+				t.Errorf("applied = %v, want %v", response.Applied, tt.wantApplied)
+			}
+
+			var splits []int
+			for _, row := range response.Rows {
+				for _, expenseID := range row.ExpenseIDs {
+					expense, err := expenses.GetExpenseByID(expenseID)
+					if err != nil {
+						t.Fatal(err)
+					}
+					expenseSplits, err := expenses.GetSplitsByExpenseID(expenseID)
+					if err != nil {
+						t.Fatal(err)
+					}
+					var total money.Amount
+					for _, split := range expenseSplits {
+						if split.Amount <= 0 {
+							t.Errorf("expense %d has a split of %s", expenseID, split.Amount)
+						}
+						total += split.Amount
+					}
+					if total != expense.Amount {
+						t.Errorf("splits of expense %d add up to %s, want %s", expenseID, total, expense.Amount)
+					}
+					splits = append(splits, len(expenseSplits))
+				}
+			}
+			if !reflect.DeepEqual(splits, tt.wantSplits) {
+				t.Errorf("expenses have %v splits, want %v", splits, tt.wantSplits)
+			}
+
+			balances := netBalances(t, balanceRepo, groupID)
+			for i, want := range tt.want {
+				if got := balances[userIDs[i]]; got != want {
+					t.Errorf("balance of user %d = %s, want %s", userIDs[i], got, want)
+				}
+			}
+			checkLedger(t, balanceRepo, groupID)
+		})
+	}
+}
@@ -4,34 +4,52 @@ import (
 	"fmt"
 
 	"github.com/shreyansh/expense-go-collab-backend/internal/model"
-	"github.com/shreyansh/expense-go-collab-backend/internal/repositorypg"
+	"github.com/shreyansh/expense-go-collab-backend/internal/repository"
 )
 
 type UserService struct {
-	repo *repositorypg.UserRepositoryPG
+	repo repository.UserRepository
+	uow  repository.UnitOfWork
 }
 
-func NewUserService(repo *repositorypg.UserRepositoryPG) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo repository.UserRepository, uow repository.UnitOfWork) *UserService {
+	return &UserService{repo: repo, uow: uow}
 }
 
-func (s *UserService) RegisterUser(email, name string) (*model.UserResponse, error) {
+// RegisterUser creates a user who logs in with the given email and password.
+// When a placeholder member was added with the email, registering claims it:
+// the placeholder becomes the new user, with everything recorded for it. As
+// nothing else proves the new user owns the email, that takes the token of a
+// pending invitation sent to it, which registering accepts.
+func (s *UserService) RegisterUser(email, name, password, invitationToken string) (*model.UserResponse, error) {
+	email = model.NormalizeEmail(email)
 	if email == "" || name == "" {
 		return nil, fmt.Errorf("email and name are required")
 	}
 
-	// Check if user already exists
-	existing, err := s.repo.GetUserByEmail(email)
-	if err == nil && existing != nil {
-		return nil, fmt.Errorf("user with email already exists")
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
 	}
 
 	user := &model.User{
-		Email: email,
-		Name:  name,
+		Email:        email,
+		Name:         name,
+		PasswordHash: passwordHash,
 	}
 
-	createdUser, err := s.repo.CreateUser(user)
+	// Check if user already exists
+	var createdUser *model.User
+	existing, err := s.repo.GetUserByEmail(email)
+	switch {
+	case err == nil && existing.Placeholder:
+		user.ID = existing.ID
+		createdUser, err = s.registerPlaceholder(user, invitationToken)
+	case err == nil && existing != nil:
+		return nil, fmt.Errorf("user with email already exists")
+	default:
+		createdUser, err = s.repo.CreateUser(user)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -44,22 +62,44 @@ func (s *UserService) RegisterUser(email, name string) (*model.UserResponse, err
 	}, nil
 }
 
-func (s *UserService) LoginUser(email string) (*model.UserResponse, error) {
-	if email == "" {
-		return nil, fmt.Errorf("email is required")
+// registerPlaceholder turns a placeholder into a registered user, once the
+// invitation token shows the user received email sent to the placeholder's
+// address
+func (s *UserService) registerPlaceholder(user *model.User, invitationToken string) (*model.User, error) {
+	if invitationToken == "" {
+		return nil, fmt.Errorf("%s belongs to a group member without an account; register with the invitation sent to it", user.Email)
 	}
 
-	user, err := s.repo.GetUserByEmail(email)
+	var registered *model.User
+	err := s.uow.Do(func(tx repository.Tx) error {
+		invitation, err := pendingInvitation(tx.Invitations(), invitationToken)
+		if err != nil {
+			return err
+		}
+		if model.NormalizeEmail(invitation.Email) != user.Email {
+			return fmt.Errorf("the invitation was sent to another email")
+		}
+
+		registered, err = tx.Users().RegisterPlaceholder(user)
+		if err != nil {
+			return err
+		}
+
+		isMember, err := tx.Members().IsMember(invitation.GroupID, user.ID)
+		if err != nil {
+			return err
+		}
+		if isMember {
+			return tx.Invitations().RespondToInvitation(invitation.ID, model.InvitationAccepted)
+		}
+		_, err = joinInvitedGroup(tx, invitation, user.ID)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, err
 	}
 
-	return &model.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
-	}, nil
+	return registered, nil
 }
 
 func (s *UserService) GetUserByID(id int) (*model.UserResponse, error) {
@@ -69,30 +109,35 @@ func (s *UserService) GetUserByID(id int) (*model.UserResponse, error) {
 	}
 
 	return &model.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
+		ID:          user.ID,
+		Email:       user.Email,
+		Name:        user.Name,
+		Placeholder: user.Placeholder,
+		CreatedAt:   user.CreatedAt,
 	}, nil
 }
 
-func (s *UserService) GetAllUsers() ([]*model.UserResponse, error) {
-	users, err := s.repo.GetAllUsers()
+// GetVisibleUsers returns one page of the users the user can see, themselves
+// and the members of their groups, and the cursor of the next page
+func (s *UserService) GetVisibleUsers(userID int, query *model.ListQuery) ([]*model.UserResponse, string, error) {
+	query.UserID = userID
+	users, next, err := s.repo.ListUsers(query)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	var responses []*model.UserResponse
 	for _, user := range users {
 		responses = append(responses, &model.UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Name:      user.Name,
-			CreatedAt: user.CreatedAt,
+			ID:          user.ID,
+			Email:       user.Email,
+			Name:        user.Name,
+			Placeholder: user.Placeholder,
+			CreatedAt:   user.CreatedAt,
 		})
 	}
 
-	return responses, nil
+	return responses, next, nil
 }
 
 func (s *UserService) UpdateUser(id int, name string) (*model.UserResponse, error) {
@@ -107,10 +152,11 @@ func (s *UserService) UpdateUser(id int, name string) (*model.UserResponse, erro
 	}
 
 	return &model.UserResponse{
-		ID:        updatedUser.ID,
-		Email:     updatedUser.Email,
-		Name:      updatedUser.Name,
-		CreatedAt: updatedUser.CreatedAt,
+		ID:          updatedUser.ID,
+		Email:       updatedUser.Email,
+		Name:        updatedUser.Name,
+		Placeholder: updatedUser.Placeholder,
+		CreatedAt:   updatedUser.CreatedAt,
 	}, nil
 }
 